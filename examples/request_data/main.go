@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"time"
 
 	"github.com/kivle/msfs2020-go/simconnect"
@@ -19,12 +22,6 @@ type Report struct {
 	Longitude float64   `name:"Plane Longitude" unit:"degrees"`
 }
 
-func (r *Report) RequestData(s *simconnect.SimConnect) {
-	defineID := s.GetDefineID(r)
-	requestID := defineID
-	s.RequestDataOnSimObjectType(requestID, defineID, 0, simconnect.SIMOBJECT_TYPE_USER)
-}
-
 func main() {
 	s, err := simconnect.New("Request Data")
 	if err != nil {
@@ -32,65 +29,40 @@ func main() {
 	}
 	fmt.Println("Connected to Flight Simulator!")
 
-	report := &Report{}
-	s.RegisterDataDefinition(report)
-	report.RequestData(s)
-
-	/*
-		fmt.Println("SubscribeToSystemEvent")
-		eventSimStartID := simconnect.DWORD(0)
-		s.SubscribeToSystemEvent(eventSimStartID, "SimStart")
-	*/
-
-	for {
-		ppData, r1, err := s.GetNextDispatch()
-
-		if r1 < 0 {
-			if uint32(r1) == simconnect.E_FAIL {
-				// skip error, means no new messages?
-				continue
-			} else {
-				panic(fmt.Errorf("GetNextDispatch error: %d %s", r1, err))
-			}
-		}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
 
-		recvInfo := *(*simconnect.Recv)(ppData)
-		//fmt.Println(ppData, pcbData, recvInfo)
+	c := simconnect.NewClient(s)
+	go c.Run(ctx, 100*time.Millisecond)
 
-		switch recvInfo.ID {
-		case simconnect.RECV_ID_EXCEPTION:
-			recvErr := *(*simconnect.RecvException)(ppData)
-			fmt.Printf("SIMCONNECT_RECV_ID_EXCEPTION %#v\n", recvErr)
+	go func() {
+		for msg := range c.Messages() {
+			recvInfo := (*simconnect.Recv)(simconnect.MessagePointer(msg))
+			switch recvInfo.ID {
+			case simconnect.RECV_ID_EXCEPTION:
+				recvErr := (*simconnect.RecvException)(simconnect.MessagePointer(msg))
+				fmt.Printf("SIMCONNECT_RECV_ID_EXCEPTION %#v\n", *recvErr)
 
-		case simconnect.RECV_ID_OPEN:
-			recvOpen := *(*simconnect.RecvOpen)(ppData)
-			fmt.Println("SIMCONNECT_RECV_ID_OPEN", fmt.Sprintf("%s", recvOpen.ApplicationName))
-			//spew.Dump(recvOpen)
-		case simconnect.RECV_ID_EVENT:
-			recvEvent := *(*simconnect.RecvEvent)(ppData)
-			fmt.Println("SIMCONNECT_RECV_ID_EVENT")
-			//spew.Dump(recvEvent)
+			case simconnect.RECV_ID_OPEN:
+				recvOpen := (*simconnect.RecvOpen)(simconnect.MessagePointer(msg))
+				fmt.Println("SIMCONNECT_RECV_ID_OPEN", fmt.Sprintf("%s", recvOpen.ApplicationName))
 
-			switch recvEvent.EventID {
-			//case eventSimStartID:
-			//	fmt.Println("SimStart Event")
 			default:
-				fmt.Println("unknown SIMCONNECT_RECV_ID_EVENT", recvEvent.EventID)
-			}
-
-		case simconnect.RECV_ID_SIMOBJECT_DATA_BYTYPE:
-			recvData := *(*simconnect.RecvSimobjectDataByType)(ppData)
-			fmt.Println("SIMCONNECT_RECV_SIMOBJECT_DATA_BYTYPE")
-
-			switch recvData.RequestID {
-			case s.DefineMap["Report"]:
-				report := (*Report)(ppData)
-				fmt.Printf("REPORT: %s: GPS: %.6f,%.6f Altitude: %.0f\n", report.Title, report.Latitude, report.Longitude, report.Altitude)
-				report.RequestData(s)
+				fmt.Println("recvInfo.dwID unknown", recvInfo.ID)
 			}
-
-		default:
-			fmt.Println("recvInfo.dwID unknown", recvInfo.ID)
+		}
+	}()
+
+	for ctx.Err() == nil {
+		reqCtx, reqCancel := context.WithTimeout(ctx, 5*time.Second)
+		report := &Report{}
+		err := c.GetOnce(reqCtx, report)
+		reqCancel()
+
+		if err != nil {
+			fmt.Println("GetOnce:", err)
+		} else {
+			fmt.Printf("REPORT: %s: GPS: %.6f,%.6f Altitude: %.0f\n", report.Title, report.Latitude, report.Longitude, report.Altitude)
 		}
 
 		time.Sleep(500 * time.Millisecond)
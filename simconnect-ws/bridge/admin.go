@@ -0,0 +1,144 @@
+package bridge
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var adminTemplate = template.Must(template.New("adminPage").Parse(`<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>simconnect-ws clients</title>
+  <style>
+    body { font-family: "Segoe UI", Tahoma, sans-serif; margin: 0; padding: 24px; background: #0f172a; color: #e2e8f0; }
+    h1 { font-size: 28px; margin: 0 0 8px; }
+    p { max-width: 920px; line-height: 1.5; }
+    .code { font-size: 40px; letter-spacing: 6px; background: #1f2937; border: 1px solid #334155; border-radius: 8px; padding: 8px 16px; display: inline-block; }
+    table { border-collapse: collapse; margin-top: 12px; }
+    td, th { text-align: left; padding: 6px 14px; border-bottom: 1px solid #334155; }
+    button { background: #1f2937; color: #e2e8f0; border: 1px solid #475569; border-radius: 6px; padding: 4px 12px; cursor: pointer; }
+    code { background: #0b1222; padding: 2px 6px; border-radius: 4px; }
+  </style>
+</head>
+<body>
+  <h1>Clients</h1>
+  <p>Devices on the network pair by sending this code to <code>POST /api/pair</code>. It works once and expires after {{.TTL}}.</p>
+  <div class="code">{{.Code}}</div>
+  <form method="post"><input type="hidden" name="action" value="pair"><p><button>New code</button></p></form>
+
+  {{if .Clients}}
+  <table>
+    <tr><th>Name</th><th>ID</th><th>Role</th><th>Paired</th><th></th></tr>
+    {{range .Clients}}
+    <tr>
+      <td>{{.Name}}</td>
+      <td><code>{{.ID}}</code></td>
+      <td><form method="post"><input type="hidden" name="action" value="role"><input type="hidden" name="id" value="{{.ID}}">
+        <select name="role" onchange="this.form.submit()">{{$role := .Role}}{{range $.Roles}}<option{{if eq . $role}} selected{{end}}>{{.}}</option>{{end}}</select>
+      </form></td>
+      <td>{{.Created.Local.Format "2006-01-02 15:04"}}</td>
+      <td><form method="post"><input type="hidden" name="action" value="revoke"><input type="hidden" name="id" value="{{.ID}}"><button>Revoke</button></form></td>
+    </tr>
+    {{end}}
+  </table>
+  {{else}}
+  <p>No paired clients.</p>
+  {{end}}
+</body>
+</html>
+`))
+
+// adminHandler serves the page for pairing and revoking clients, to this
+// machine only. Pages of other sites, rebound DNS names included, are
+// refused by their Host or Origin.
+func (a *authStore) adminHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopback(r) || !a.loopbackHost(r.Host, r.TLS != nil) {
+			http.Error(w, "the admin page is only available on this machine", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !a.loopbackOrigin(origin) {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			switch r.FormValue("action") {
+			case "pair":
+				a.NewPairingCode()
+			case "role":
+				role, err := parseRole(r.FormValue("role"))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if _, err := a.SetRole(r.FormValue("id"), role); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			case "revoke":
+				if _, err := a.Revoke(r.FormValue("id")); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+			}
+			http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		_ = adminTemplate.Execute(w, struct {
+			Code    string
+			TTL     string
+			Clients []pairedClient
+			Roles   []string
+		}{
+			Code:    a.PairingCode(),
+			TTL:     pairingCodeTTL.String(),
+			Clients: a.Clients(),
+			Roles:   roleNames,
+		})
+	}
+}
+
+// loopbackHost reports whether hostport names this machine by a loopback
+// name, on one of the ports the server listens on.
+func (a *authStore) loopbackHost(hostport string, secure bool) bool {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = hostport, "80"
+		if secure {
+			port = "443"
+		}
+	}
+	switch strings.ToLower(strings.Trim(host, "[]")) {
+	case "localhost", "127.0.0.1", "::1":
+	default:
+		return false
+	}
+
+	a.settings.mu.RLock()
+	defer a.settings.mu.RUnlock()
+	return port == portFromAddr(a.settings.httpListen) || port == portFromAddr(a.settings.httpsListen)
+}
+
+// loopbackOrigin reports whether origin is a page the server serves on a
+// loopback name. "null", sent by sandboxed frames and data: URLs, is not.
+func (a *authStore) loopbackOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" || u.Path != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "http":
+		return a.loopbackHost(u.Host, false)
+	case "https":
+		return a.loopbackHost(u.Host, true)
+	}
+	return false
+}
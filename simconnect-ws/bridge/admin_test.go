@@ -0,0 +1,48 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminRefusesOtherHostsAndOrigins(t *testing.T) {
+	a := &authStore{
+		clients:  map[string]*pairedClient{},
+		active:   map[string]map[*context.CancelFunc]bool{},
+		settings: NewSettings(),
+	}
+	h := a.adminHandler()
+
+	for _, tc := range []struct {
+		method, remote, host, origin string
+		want                         int
+	}{
+		{"GET", "127.0.0.1:5000", "localhost:9000", "", http.StatusOK},
+		{"GET", "[::1]:5000", "[::1]:9443", "", http.StatusOK},
+		{"GET", "127.0.0.1:5000", "127.0.0.1:9000", "http://localhost:9000", http.StatusOK},
+		{"POST", "127.0.0.1:5000", "localhost:9000", "http://127.0.0.1:9000", http.StatusSeeOther},
+		{"GET", "192.168.1.20:5000", "localhost:9000", "", http.StatusForbidden},
+		// a rebound DNS name reaches the server with its own Host
+		{"GET", "127.0.0.1:5000", "attacker.example:9000", "", http.StatusForbidden},
+		{"POST", "127.0.0.1:5000", "attacker.example:9000", "http://attacker.example:9000", http.StatusForbidden},
+		{"GET", "127.0.0.1:5000", "localhost:8080", "", http.StatusForbidden},
+		{"GET", "127.0.0.1:5000", "localhost", "", http.StatusForbidden},
+		{"POST", "127.0.0.1:5000", "localhost:9000", "null", http.StatusForbidden},
+		{"POST", "127.0.0.1:5000", "localhost:9000", "https://attacker.example", http.StatusForbidden},
+		{"GET", "127.0.0.1:5000", "localhost:9000", "http://localhost:8080", http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(tc.method, "/admin", nil)
+		r.RemoteAddr = tc.remote
+		r.Host = tc.host
+		if tc.origin != "" {
+			r.Header.Set("Origin", tc.origin)
+		}
+		w := httptest.NewRecorder()
+		h(w, r)
+		if w.Code != tc.want {
+			t.Errorf("%s from %s to %s, origin %q: got %d, want %d", tc.method, tc.remote, tc.host, tc.origin, w.Code, tc.want)
+		}
+	}
+}
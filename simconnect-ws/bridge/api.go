@@ -0,0 +1,230 @@
+package bridge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+const (
+	maxAPIBody = 64 * 1024
+	apiTimeout = 5 * time.Second
+)
+
+// errUnavailable is the error code when the server cannot take a request.
+const errUnavailable = "unavailable"
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// apiCall runs a request handler on the simconnect loop for an HTTP request.
+type apiCall struct {
+	typ     string
+	payload json.RawMessage
+	client  Client
+	done    chan apiResult
+}
+
+type apiResult struct {
+	result interface{}
+	err    error
+}
+
+// run answers the call on b; s is nil while no simulator is connected.
+func (c *apiCall) run(b *Bridge, s *simconnect.SimConnect) {
+	r := &request{
+		version: protocolVersion,
+		typ:     c.typ,
+		payload: c.payload,
+		s:       s,
+		b:       b,
+		client:  c.client,
+	}
+	r.respond = func(_ string, result interface{}, err error) {
+		if err == nil {
+			b.audit.record(r, "rest")
+		}
+		c.done <- apiResult{result: result, err: err}
+	}
+
+	_, result, err := b.dispatch(r)
+	if err != errDeferred {
+		r.respond("", result, err)
+	}
+}
+
+// registerAPI adds the REST endpoints under /api/ to mux, all but pairing
+// behind auth, and returns their paths.
+func (b *Bridge) registerAPI(mux *http.ServeMux) map[string]bool {
+	ws, hist, auth := b.ws, b.hist, b.auth
+	paths := map[string]bool{}
+	handle := func(path string, h http.HandlerFunc) {
+		paths[path] = true
+		mux.HandleFunc(path, auth.requireAuth(h))
+	}
+
+	handle("/api/aircraft", apiGet(func(r *http.Request) (interface{}, error) {
+		plane := hist.latestPlane(peer{caps: map[string]bool{capTypedTelemetry: true}})
+		if plane == nil {
+			return nil, newProtocolError(errNotFound, "no aircraft data yet")
+		}
+		return plane, nil
+	}))
+
+	handle("/api/traffic", apiGet(func(r *http.Request) (interface{}, error) {
+		return hist.traffic.list(), nil
+	}))
+
+	handle("/api/track", apiGet(func(r *http.Request) (interface{}, error) {
+		var since int64
+		if v := r.URL.Query().Get("since"); v != "" {
+			var err error
+			if since, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return nil, newProtocolError(errBadRequest, "invalid since %q", v)
+			}
+		}
+		return hist.trackSince(time.Unix(0, since*int64(time.Millisecond)), time.Now()), nil
+	}))
+
+	handle("/api/info", apiGet(func(r *http.Request) (interface{}, error) {
+		return map[string]interface{}{
+			"server":      "simconnect-ws",
+			"version":     b.opts.Version,
+			"build_time":  b.opts.BuildTime,
+			"protocol":    []int{0, protocolVersion},
+			"caps":        serverCaps,
+			"connections": ws.ConnectionCount(),
+			"sim":         b.sim.info(),
+		}, nil
+	}))
+
+	handle("/api/teleport", b.apiPost("teleport"))
+	handle("/api/event", b.apiPost("event"))
+
+	// pairing is how clients get a token in the first place
+	paths["/api/pair"] = true
+	mux.HandleFunc("/api/pair", auth.pairHandler())
+	return paths
+}
+
+func apiGet(get func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeAPIError(w, http.StatusMethodNotAllowed, newProtocolError(errBadRequest, "use GET"))
+			return
+		}
+		result, err := get(r)
+		if err != nil {
+			writeAPIError(w, 0, err)
+			return
+		}
+		writeAPI(w, http.StatusOK, result)
+	}
+}
+
+// apiPost hands the JSON body to the websocket request handler for typ, so
+// both paths share validation and permissions.
+func (b *Bridge) apiPost(typ string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			writeAPI(w, http.StatusNoContent, nil)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeAPIError(w, http.StatusMethodNotAllowed, newProtocolError(errBadRequest, "use POST"))
+			return
+		}
+		if err := requireJSON(r); err != nil {
+			writeAPIError(w, http.StatusUnsupportedMediaType, err)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxAPIBody))
+		if err != nil {
+			writeAPIError(w, 0, newProtocolError(errBadRequest, "reading body: %s", err))
+			return
+		}
+		if len(body) == 0 {
+			body = []byte("{}")
+		}
+
+		call := &apiCall{typ: typ, payload: body, client: clientFromContext(r.Context()), done: make(chan apiResult, 1)}
+		timeout := time.NewTimer(apiTimeout)
+		defer timeout.Stop()
+
+		select {
+		case b.apiCalls <- call:
+		case <-timeout.C:
+			writeAPIError(w, 0, newProtocolError(errUnavailable, "the server is busy"))
+			return
+		}
+
+		res := <-call.done
+		if res.err != nil {
+			writeAPIError(w, 0, res.err)
+			return
+		}
+		writeAPI(w, http.StatusOK, res.result)
+	}
+}
+
+// requireJSON refuses bodies that aren't JSON. Browsers send other types
+// cross-site without asking first, so this keeps forms and text/plain
+// posts from other sites out.
+func requireJSON(r *http.Request) error {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mt != "application/json" {
+		return newProtocolError(errBadRequest, "send the body as Content-Type: application/json")
+	}
+	return nil
+}
+
+func writeAPI(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	if v == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError writes err as a protocolError, with the HTTP status for its
+// code unless status is set.
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	perr, ok := err.(*protocolError)
+	if !ok {
+		perr = newProtocolError("internal", "%s", err)
+	}
+	if status == 0 {
+		switch perr.Code {
+		case errBadRequest, errUnsupportedVersion:
+			status = http.StatusBadRequest
+		case errUnauthorized:
+			status = http.StatusUnauthorized
+		case errForbidden:
+			status = http.StatusForbidden
+		case errNotFound, errUnknownType:
+			status = http.StatusNotFound
+		case errUnavailable, errSimUnavailable:
+			status = http.StatusServiceUnavailable
+		case errRateLimited:
+			status = http.StatusTooManyRequests
+		default:
+			status = http.StatusInternalServerError
+		}
+	}
+	writeAPI(w, status, perr)
+}
@@ -0,0 +1,568 @@
+package bridge
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	pairingCodeTTL      = 10 * time.Minute
+	maxPairingFailures  = 5
+	pairingLockout      = time.Minute
+	maxPairingLockout   = time.Hour
+	localClientID       = "local"
+	authFileName        = "simconnect-ws-auth.json"
+	pairingCodeDigits   = 6
+	maxClientNameLength = 64
+)
+
+// pairedClient is a token handed out by pairing.
+type pairedClient struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	Created time.Time `json:"created"`
+}
+
+// role returns what c may do; clients from before roles were copilots.
+func (c pairedClient) role() Role {
+	r, err := parseRole(c.Role)
+	if err != nil {
+		return RoleCopilot
+	}
+	return r
+}
+
+// Client is who a request came from.
+type Client struct {
+	ID     string
+	Name   string
+	Role   Role
+	Remote string
+}
+
+// authStore holds the server secret and the paired clients. Tokens are a
+// client id with an HMAC of it under the secret, so only ids are stored.
+type authStore struct {
+	path string
+
+	mu       sync.Mutex
+	secret   []byte
+	clients  map[string]*pairedClient
+	code     string
+	codeEnds time.Time
+	failures int
+	// after maxPairingFailures wrong codes pairing is locked, for twice as
+	// long each time until a client pairs or a new code is made
+	lockouts    int
+	lockedUntil time.Time
+
+	// cancels the requests in flight per client id
+	active map[string]map[*context.CancelFunc]bool
+
+	// allowOrigin reports whether browser pages from an origin may use the
+	// server
+	allowOrigin func(origin string) bool
+	// settings give the role of new clients, whether clients need a token
+	// and the roles bound to origins
+	settings *Settings
+}
+
+// authFile is what authStore keeps on disk.
+type authFile struct {
+	Secret  string          `json:"secret"`
+	Clients []*pairedClient `json:"clients"`
+}
+
+// loadAuth reads the auth file next to the executable, creating it with a
+// new secret on the first run.
+func loadAuth(allowOrigin func(origin string) bool, settings *Settings) (*authStore, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate executable: %w", err)
+	}
+	a := &authStore{
+		path:        filepath.Join(filepath.Dir(exe), authFileName),
+		clients:     map[string]*pairedClient{},
+		active:      map[string]map[*context.CancelFunc]bool{},
+		allowOrigin: allowOrigin,
+		settings:    settings,
+	}
+
+	buf, err := ioutil.ReadFile(a.path)
+	switch {
+	case err == nil:
+		var f authFile
+		if err := json.Unmarshal(buf, &f); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", a.path, err)
+		}
+		if a.secret, err = hex.DecodeString(f.Secret); err != nil || len(a.secret) == 0 {
+			return nil, fmt.Errorf("invalid secret in %s", a.path)
+		}
+		for _, c := range f.Clients {
+			c.Role = c.role().String()
+			a.clients[c.ID] = c
+		}
+		return a, nil
+
+	case os.IsNotExist(err):
+		a.secret = make([]byte, 32)
+		if _, err := rand.Read(a.secret); err != nil {
+			return nil, fmt.Errorf("generate secret: %w", err)
+		}
+		if err := a.save(); err != nil {
+			return nil, err
+		}
+		return a, nil
+
+	default:
+		return nil, fmt.Errorf("read %s: %w", a.path, err)
+	}
+}
+
+// save writes the store to disk; a.mu is held or a is not shared yet.
+func (a *authStore) save() error {
+	f := authFile{Secret: hex.EncodeToString(a.secret), Clients: a.sortedClients()}
+	buf, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(a.path, buf, 0600); err != nil {
+		return fmt.Errorf("write %s: %w", a.path, err)
+	}
+	return nil
+}
+
+// sortedClients returns the paired clients, oldest first; a.mu is held.
+func (a *authStore) sortedClients() []*pairedClient {
+	clients := make([]*pairedClient, 0, len(a.clients))
+	for _, c := range a.clients {
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Created.Before(clients[j].Created) })
+	return clients
+}
+
+// Clients returns copies of the paired clients, oldest first.
+func (a *authStore) Clients() []pairedClient {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var clients []pairedClient
+	for _, c := range a.sortedClients() {
+		clients = append(clients, *c)
+	}
+	return clients
+}
+
+func (a *authStore) sign(id string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(id))
+	return id + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// check returns the client a token belongs to.
+func (a *authStore) check(token string) (pairedClient, bool) {
+	i := strings.IndexByte(token, '.')
+	if i <= 0 {
+		return pairedClient{}, false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	id := token[:i]
+	c, ok := a.clients[id]
+	if !ok || !hmac.Equal([]byte(a.sign(id)), []byte(token)) {
+		return pairedClient{}, false
+	}
+	return *c, true
+}
+
+// PairingCode returns the current pairing code, making a new one if the last
+// expired.
+func (a *authStore) PairingCode() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.currentCode(time.Now())
+}
+
+// currentCode returns the pairing code at now; a.mu is held.
+func (a *authStore) currentCode(now time.Time) string {
+	if a.code != "" && now.Before(a.codeEnds) {
+		return a.code
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		panic(fmt.Errorf("generate pairing code: %w", err))
+	}
+	a.code = fmt.Sprintf("%0*d", pairingCodeDigits, n)
+	a.codeEnds = now.Add(pairingCodeTTL)
+	a.failures = 0
+	authLog.Info("pairing code", "code", a.code, "valid_for", pairingCodeTTL)
+	return a.code
+}
+
+// NewPairingCode replaces the pairing code, unlocking pairing, and returns
+// the new one.
+func (a *authStore) NewPairingCode() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.newCode()
+	a.lockouts = 0
+	a.lockedUntil = time.Time{}
+	return a.currentCode(time.Now())
+}
+
+// newCode drops the current pairing code; a.mu is held.
+func (a *authStore) newCode() {
+	a.code = ""
+}
+
+// Pair trades the pairing code for a token for a client called name. The
+// code works once; too many wrong guesses replace it and lock pairing.
+func (a *authStore) Pair(code, name string) (pairedClient, string, error) {
+	return a.pair(code, name, time.Now())
+}
+
+// pair is Pair at now.
+func (a *authStore) pair(code, name string, now time.Time) (pairedClient, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "unnamed"
+	}
+	if len(name) > maxClientNameLength {
+		name = name[:maxClientNameLength]
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Before(a.lockedUntil) {
+		return pairedClient{}, "", newProtocolError(errRateLimited, "pairing is locked for %s after wrong codes; a new code unlocks it",
+			a.lockedUntil.Sub(now).Round(time.Second))
+	}
+	current := a.currentCode(now)
+	if subtle.ConstantTimeCompare([]byte(code), []byte(current)) != 1 {
+		a.failures++
+		if a.failures >= maxPairingFailures {
+			a.newCode()
+			a.lock(now)
+		}
+		return pairedClient{}, "", newProtocolError(errUnauthorized, "wrong or expired pairing code")
+	}
+	a.newCode()
+	a.lockouts = 0
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return pairedClient{}, "", err
+	}
+	a.settings.mu.RLock()
+	newRole := a.settings.pairRole
+	a.settings.mu.RUnlock()
+	c := &pairedClient{ID: hex.EncodeToString(idBytes), Name: name, Role: newRole.String(), Created: time.Now().UTC()}
+	a.clients[c.ID] = c
+	if err := a.save(); err != nil {
+		delete(a.clients, c.ID)
+		return pairedClient{}, "", err
+	}
+
+	authLog.Info("paired client", "name", c.Name, "client", c.ID, "role", c.Role)
+	return *c, a.sign(c.ID), nil
+}
+
+// lock stops pairing after too many wrong codes; a.mu is held.
+func (a *authStore) lock(now time.Time) {
+	d := maxPairingLockout
+	if a.lockouts < 16 && pairingLockout<<a.lockouts < maxPairingLockout {
+		d = pairingLockout << a.lockouts
+	}
+	a.lockouts++
+	a.lockedUntil = now.Add(d)
+	authLog.Warn("too many wrong pairing codes, pairing locked", "for", d)
+}
+
+// Revoke forgets the client with id and ends its open requests and
+// connections.
+func (a *authStore) Revoke(id string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c, ok := a.clients[id]
+	if !ok {
+		return false, nil
+	}
+	delete(a.clients, id)
+	if err := a.save(); err != nil {
+		a.clients[id] = c
+		return false, err
+	}
+
+	for cancel := range a.active[id] {
+		(*cancel)()
+	}
+	delete(a.active, id)
+
+	authLog.Info("revoked client", "name", c.Name, "client", c.ID)
+	return true, nil
+}
+
+// SetRole changes the role of the client with id. Its open requests and
+// connections end so it comes back with the new role.
+func (a *authStore) SetRole(id string, r Role) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	c, ok := a.clients[id]
+	if !ok {
+		return false, nil
+	}
+	old := c.Role
+	c.Role = r.String()
+	if err := a.save(); err != nil {
+		c.Role = old
+		return false, err
+	}
+
+	for cancel := range a.active[id] {
+		(*cancel)()
+	}
+	delete(a.active, id)
+
+	authLog.Info("changed client role", "name", c.Name, "client", c.ID, "role", c.Role)
+	return true, nil
+}
+
+// track makes a request of client id end when the client is revoked or its
+// role changes, and
+// returns the context to use and a func to call once the request is done.
+func (a *authStore) track(ctx context.Context, id string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	a.mu.Lock()
+	if a.active[id] == nil {
+		a.active[id] = map[*context.CancelFunc]bool{}
+	}
+	a.active[id][&cancel] = true
+	a.mu.Unlock()
+
+	return ctx, func() {
+		a.mu.Lock()
+		delete(a.active[id], &cancel)
+		if len(a.active[id]) == 0 {
+			delete(a.active, id)
+		}
+		a.mu.Unlock()
+		cancel()
+	}
+}
+
+type authContextKey struct{}
+
+// clientFromContext returns the client a request came from. Requests that
+// never passed requireAuth come from nobody and may only view.
+func clientFromContext(ctx context.Context) Client {
+	if ctx == nil {
+		return Client{Role: RoleViewer}
+	}
+	c, ok := ctx.Value(authContextKey{}).(Client)
+	if !ok {
+		return Client{Role: RoleViewer}
+	}
+	return c
+}
+
+// isLoopback reports whether r came from this machine.
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requestToken returns the token r presents, as a bearer token or, for
+// browsers that cannot set headers on websockets and EventSource, in the
+// token query parameter.
+func requestToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	return r.URL.Query().Get("token")
+}
+
+// originAllowed reports whether the Origin of r, if any, is one whose pages
+// may use the server.
+func (a *authStore) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || a.allowOrigin == nil || a.allowOrigin(origin)
+}
+
+// isLocal reports whether r comes from this machine and not from a page of
+// another site open in a browser on it.
+func (a *authStore) isLocal(r *http.Request) bool {
+	return isLoopback(r) && a.originAllowed(r)
+}
+
+// allowCORS lets pages from allowed origins read the response to r.
+func (a *authStore) allowCORS(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	w.Header().Add("Vary", "Origin")
+	if origin == "" || !a.originAllowed(r) {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+}
+
+// requireAuth lets requests from this machine and from paired clients
+// through to h, with who they are and their role in the context. Requests
+// from this machine are instructors, those let in by -no-auth viewers, and
+// either is capped by the role bound to its origin. Pages from origins that
+// aren't allowed count as remote even in a browser on this machine.
+func (a *authStore) requireAuth(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.allowCORS(w, r)
+		if r.Method == http.MethodOptions {
+			h(w, r)
+			return
+		}
+
+		client := Client{ID: localClientID, Name: "this machine", Role: RoleInstructor, Remote: r.RemoteAddr}
+		if token := requestToken(r); token != "" {
+			c, ok := a.check(token)
+			if !ok {
+				writeAPIError(w, 0, newProtocolError(errUnauthorized, "invalid or revoked token"))
+				return
+			}
+			client.ID, client.Name, client.Role = c.ID, c.Name, c.role()
+		} else if !a.isLocal(r) {
+			a.settings.mu.RLock()
+			open := a.settings.noAuth
+			a.settings.mu.RUnlock()
+			if !open {
+				writeAPIError(w, 0, newProtocolError(errUnauthorized, "pair with the server and send its token"))
+				return
+			}
+			client.ID, client.Name, client.Role = "anonymous", "anonymous", RoleViewer
+		}
+		client.Role = a.settings.capForOrigin(client.Role, r.Header.Get("Origin"))
+
+		ctx, done := a.track(context.WithValue(r.Context(), authContextKey{}, client), client.ID)
+		defer done()
+		h(w, r.WithContext(ctx))
+	}
+}
+
+// pairHandler trades a pairing code for a token.
+func (a *authStore) pairHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.allowCORS(w, r)
+		if r.Method == http.MethodOptions {
+			writeAPI(w, http.StatusNoContent, nil)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeAPIError(w, http.StatusMethodNotAllowed, newProtocolError(errBadRequest, "use POST"))
+			return
+		}
+		if err := requireJSON(r); err != nil {
+			writeAPIError(w, http.StatusUnsupportedMediaType, err)
+			return
+		}
+
+		var req struct {
+			Code string `json:"code"`
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBody)).Decode(&req); err != nil {
+			writeAPIError(w, 0, newProtocolError(errBadRequest, "invalid JSON: %s", err))
+			return
+		}
+
+		c, token, err := a.Pair(strings.TrimSpace(req.Code), req.Name)
+		if err != nil {
+			writeAPIError(w, 0, err)
+			return
+		}
+		writeAPI(w, http.StatusOK, map[string]interface{}{"id": c.ID, "name": c.Name, "token": token})
+	}
+}
+
+// console reads commands for managing clients from in and answers on out.
+func (a *authStore) console(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "pair":
+			a.NewPairingCode()
+
+		case "clients":
+			clients := a.Clients()
+			if len(clients) == 0 {
+				fmt.Fprintln(out, "no paired clients")
+			}
+			for _, c := range clients {
+				fmt.Fprintf(out, "%s  %-20s %-10s paired %s\n", c.ID, c.Name, c.role(), c.Created.Local().Format("2006-01-02 15:04"))
+			}
+
+		case "role":
+			if len(fields) != 3 {
+				fmt.Fprintln(out, "usage: role <id> <role>")
+				continue
+			}
+			r, err := parseRole(fields[2])
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			ok, err := a.SetRole(fields[1], r)
+			if err != nil {
+				fmt.Fprintln(out, "role:", err)
+			} else if !ok {
+				fmt.Fprintf(out, "no client %s\n", fields[1])
+			}
+
+		case "revoke":
+			if len(fields) != 2 {
+				fmt.Fprintln(out, "usage: revoke <id>")
+				continue
+			}
+			ok, err := a.Revoke(fields[1])
+			if err != nil {
+				fmt.Fprintln(out, "revoke:", err)
+			} else if !ok {
+				fmt.Fprintf(out, "no client %s\n", fields[1])
+			}
+
+		default:
+			fmt.Fprintln(out, "commands: pair (new pairing code), clients, role <id> <role>, revoke <id>")
+		}
+	}
+}
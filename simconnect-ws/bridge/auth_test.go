@@ -0,0 +1,87 @@
+package bridge
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testAuthStore(t *testing.T) (*authStore, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "simconnect-ws-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &authStore{
+		path:     filepath.Join(dir, authFileName),
+		secret:   []byte("secret"),
+		clients:  map[string]*pairedClient{},
+		active:   map[string]map[*context.CancelFunc]bool{},
+		settings: NewSettings(),
+	}
+	return a, func() { os.RemoveAll(dir) }
+}
+
+// codeAt returns the pairing code at now.
+func (a *authStore) codeAt(now time.Time) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.currentCode(now)
+}
+
+func errorCode(err error) string {
+	if perr, ok := err.(*protocolError); ok {
+		return perr.Code
+	}
+	return ""
+}
+
+// wrong guesses lock pairing, for longer each time, even for the right code
+func TestPairingLockout(t *testing.T) {
+	a, cleanup := testAuthStore(t)
+	defer cleanup()
+	now := time.Now()
+
+	for round, lockout := range []time.Duration{pairingLockout, 2 * pairingLockout, 4 * pairingLockout} {
+		for i := 0; i < maxPairingFailures; i++ {
+			if _, _, err := a.pair("wrong", "guesser", now); errorCode(err) != errUnauthorized {
+				t.Fatalf("round %d guess %d: got %v, want %s", round, i, err, errUnauthorized)
+			}
+		}
+
+		code := a.codeAt(now)
+		if _, _, err := a.pair(code, "guesser", now); errorCode(err) != errRateLimited {
+			t.Fatalf("round %d: right code while locked: got %v, want %s", round, err, errRateLimited)
+		}
+		if _, _, err := a.pair(code, "guesser", now.Add(lockout-time.Second)); errorCode(err) != errRateLimited {
+			t.Fatalf("round %d: locked for less than %s: %v", round, lockout, err)
+		}
+		now = now.Add(lockout)
+	}
+
+	// the lock doubles up to maxPairingLockout
+	a.mu.Lock()
+	a.lockouts = 20
+	a.lock(now)
+	locked := a.lockedUntil.Sub(now)
+	a.mu.Unlock()
+	if locked != maxPairingLockout {
+		t.Errorf("locked for %s, want %s", locked, maxPairingLockout)
+	}
+
+	// a new code from the operator lifts the lock
+	code := a.NewPairingCode()
+	c, token, err := a.pair(code, "cockpit", now)
+	if err != nil {
+		t.Fatalf("pairing after a new code: %v", err)
+	}
+	if got, ok := a.check(token); !ok || got.ID != c.ID {
+		t.Errorf("token of %s not accepted", c.ID)
+	}
+	if a.lockouts != 0 {
+		t.Errorf("%d lockouts counted after pairing", a.lockouts)
+	}
+}
@@ -0,0 +1,689 @@
+// Package bridge ties a SimConnect session to websocket, SSE and REST
+// clients. It is what simconnect-ws runs, and can be embedded in other
+// programs.
+//
+// Each Bridge has its own settings and sim state; only logging is set up
+// per process.
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+	"github.com/kivle/msfs2020-go/simconnect-ws/logging"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+// shutdownTimeout is how long clients get to receive what is queued for
+// them on exit.
+const shutdownTimeout = 5 * time.Second
+
+// retryInterval is how long the bridge waits between attempts to connect.
+const retryInterval = 5 * time.Second
+
+// Options are what an embedding program decides rather than the settings.
+type Options struct {
+	// Name is the SimConnect client name, "simconnect-ws" if empty.
+	Name string
+	// Version and BuildTime are reported to clients.
+	Version   string
+	BuildTime string
+
+	// Settings are those of the flags and the config file; nil uses
+	// NewSettings. The bridge changes them on config reloads.
+	Settings *Settings
+
+	// Source is where telemetry comes from; nil follows the -replay and
+	// -record-dir settings, connecting to the simulator by default.
+	Source Source
+
+	// NoListen leaves serving Handler to the caller instead of listening
+	// on the HTTP and HTTPS addresses of the settings.
+	NoListen bool
+}
+
+// Bridge connects a telemetry source to the websocket hub, SSE, the REST API
+// and any added outputs, and runs client commands against the sim.
+type Bridge struct {
+	opts     Options
+	settings *Settings
+	// explicit are the flags given on the command line, which the config
+	// file does not override.
+	explicit map[string]bool
+
+	ws    *websockets.Websocket
+	peers *peers
+	subs  *subscriptions
+	hist  *history
+	auth  *authStore
+	tls   *TLSAssets
+
+	mux      *http.ServeMux
+	handler  http.Handler
+	apiPaths map[string]bool
+	logFile  io.Closer
+	closers  []io.Closer // of the config file's outputs
+	audit    *auditLog
+
+	sim          *simStatus
+	metrics      *serverMetrics
+	deltas       planeDeltas
+	telemetrySeq uint64
+	lookups      *facilityLookups
+	events       *clientEvents
+	teleports    *sentCalls
+
+	// handlers, roles and controls start as the built-in request tables;
+	// HandleCommand adds to them.
+	handlers map[string]requestHandler
+	roles    map[string]Role
+	controls map[string]bool
+
+	apiCalls      chan *apiCall
+	configReloads chan *config
+	configPath    string
+}
+
+// New reads the config file, sets up logging and prepares the HTTP
+// endpoints, the TLS certificate and pairing. Flags must be parsed first.
+func New(opts Options) (*Bridge, error) {
+	if opts.Name == "" {
+		opts.Name = "simconnect-ws"
+	}
+	st := opts.Settings
+	if st == nil {
+		st = NewSettings()
+	}
+
+	explicit := st.explicitFlags()
+	path := resolveConfigPath(st.configPath)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.apply(&configApply{explicit: explicit, startup: true}, st, nil)
+
+	if st.trafficRadius > maxTrafficRadius {
+		st.trafficRadius = maxTrafficRadius
+	}
+	if st.planeInterval <= 0 {
+		st.planeInterval = 200 * time.Millisecond
+	}
+
+	b := &Bridge{
+		opts:          opts,
+		settings:      st,
+		explicit:      explicit,
+		audit:         &auditLog{},
+		sim:           &simStatus{},
+		metrics:       newServerMetrics(),
+		lookups:       &facilityLookups{},
+		events:        &clientEvents{},
+		teleports:     &sentCalls{},
+		handlers:      map[string]requestHandler{},
+		roles:         map[string]Role{},
+		controls:      map[string]bool{},
+		apiCalls:      make(chan *apiCall),
+		configReloads: make(chan *config),
+		configPath:    path,
+	}
+	for typ, h := range requestHandlers {
+		b.handlers[typ] = h
+	}
+	for typ, need := range requestRoles {
+		b.roles[typ] = need
+	}
+	for typ, control := range controlRequests {
+		b.controls[typ] = control
+	}
+	if b.logFile, err = setupLogging(st); err != nil {
+		return nil, err
+	}
+
+	b.ws = websockets.New(st.allowAllOrigins)
+	b.ws.SetOrigins(st.allowAllOrigins, st.allowedOrigins)
+	b.peers = newPeers()
+	b.subs = newSubscriptions(b.peers)
+	b.hist = newHistory(st.trackHistory, st.trackInterval)
+	for _, oc := range st.outputs {
+		o, closer, err := oc.open()
+		if err != nil {
+			b.Close()
+			return nil, err
+		}
+		b.AddOutput(o)
+		b.closers = append(b.closers, closer)
+	}
+	b.ws.OnClose(b.subs.Drop)
+	b.ws.OnClose(b.peers.Drop)
+	// clients learn right away whether the sim is there
+	b.ws.OnOpen(func(c *websockets.Connection) {
+		c.TrySend(b.peers.encode(c, "sim_status", b.sim.info()))
+	})
+
+	if b.tls, err = ensureTLSAssets(st.tlsCertPath, st.tlsKeyPath, st.httpsListen); err != nil {
+		return nil, fmt.Errorf("prepare TLS assets: %w", err)
+	}
+	tlsLog.Info("TLS enabled", "certificate", b.tls.CertPath)
+
+	if b.auth, err = loadAuth(b.ws.AllowsOrigin, st); err != nil {
+		return nil, fmt.Errorf("prepare auth: %w", err)
+	}
+	if err := b.audit.open(st.auditLogPath); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", b.auth.requireAuth(b.ws.Serve))
+	mux.HandleFunc("/cert.pem", certificateDownloadHandler(b.tls, "pem"))
+	mux.HandleFunc("/cert.der", certificateDownloadHandler(b.tls, "der"))
+	mux.HandleFunc("/status", b.statusHandler())
+	mux.HandleFunc("/metrics", b.auth.requireAuth(b.metricsHandler()))
+	b.apiPaths = b.registerAPI(mux)
+	mux.HandleFunc("/events", b.auth.requireAuth(sseHandler(b.peers.events, st)))
+	mux.HandleFunc("/admin", b.auth.adminHandler())
+	mux.HandleFunc("/", certificateInfoHandler(b.tls, st.httpListen, st.httpsListen))
+	b.mux = mux
+	b.handler = withRequestIDs(mux)
+
+	return b, nil
+}
+
+// Handler serves the websocket, SSE, REST, status and admin endpoints.
+func (b *Bridge) Handler() http.Handler {
+	return b.handler
+}
+
+// AddOutput sends every broadcast packet to o as well. Call it before Run.
+func (b *Bridge) AddOutput(o Output) {
+	b.peers.outputs = append(b.peers.outputs, o)
+}
+
+// Console logs the pairing code, then reads pair, clients, role and revoke
+// commands from in until it ends and writes the answers to out.
+func (b *Bridge) Console(in io.Reader, out io.Writer) {
+	b.auth.PairingCode()
+	authLog.Info("manage paired clients at /admin or type pair, clients or revoke <id>",
+		"admin", "http://localhost"+hostWithPort("", portFromAddr(b.settings.httpListen))+"/admin")
+	b.auth.console(in, out)
+}
+
+// Close closes the outputs of the config file, the audit log and the log
+// file.
+func (b *Bridge) Close() error {
+	for _, c := range b.closers {
+		c.Close()
+	}
+	b.closers = nil
+	b.audit.close()
+	if b.logFile == nil {
+		return nil
+	}
+	return b.logFile.Close()
+}
+
+// Run serves clients and connects to the source, reconnecting whenever the
+// session ends, until ctx ends or a listener fails. Clients then get what
+// is queued for them and a close frame. The error is that of the listener.
+func (b *Bridge) Run(ctx context.Context) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	go b.peers.reportDropped(ctx, b.ws, droppedReportInterval)
+	go watchConfig(ctx, b.configPath, b.configReloads)
+	st := b.settings
+
+	// handlers run under serveCtx, which outlives ctx until the websockets
+	// are closed so streaming handlers end last
+	serveCtx, stopServing := context.WithCancel(context.Background())
+	defer stopServing()
+	var servers []*http.Server
+	listenErrors := make(chan error, 2)
+
+	if !b.opts.NoListen {
+		baseContext := func(net.Listener) context.Context { return serveCtx }
+		httpServer := &http.Server{Addr: st.httpListen, Handler: b.handler, BaseContext: baseContext, ErrorLog: httpErrorLog()}
+		httpsServer := &http.Server{Addr: st.httpsListen, Handler: b.handler, BaseContext: baseContext, ErrorLog: httpErrorLog()}
+		servers = append(servers, httpServer, httpsServer)
+
+		go func() {
+			httpLog.Info("HTTP listening", "addr", httpServer.Addr)
+			if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+				httpLog.Error("HTTP listener failed", "addr", httpServer.Addr, "err", err)
+				listenErrors <- err
+				stop()
+			}
+		}()
+
+		go func() {
+			httpLog.Info("HTTPS listening", "addr", httpsServer.Addr)
+			if err := httpsServer.ListenAndServeTLS(b.tls.CertPath, b.tls.KeyPath); err != http.ErrServerClosed {
+				httpLog.Error("HTTPS listener failed", "addr", httpsServer.Addr, "err", err)
+				listenErrors <- err
+				stop()
+			}
+		}()
+	}
+
+	for ctx.Err() == nil {
+		b.runSession(ctx)
+
+		retry := time.After(retryInterval)
+	wait:
+		for {
+			select {
+			case c := <-b.configReloads:
+				b.reloadConfig(c)
+			case <-b.ws.NewConnection:
+				// nothing to send until the sim is back
+			case call := <-b.apiCalls:
+				call.run(b, nil)
+			case m := <-b.ws.ReceiveMessages:
+				b.handleClientMessage(m, nil)
+			case <-retry:
+				break wait
+			case <-ctx.Done():
+				break wait
+			}
+		}
+	}
+
+	b.shutdown(servers, stopServing)
+	select {
+	case err := <-listenErrors:
+		return err
+	default:
+		return nil
+	}
+}
+
+// shutdown closes the websockets after they sent what they have queued,
+// then ends the streaming handlers and stops the servers, giving up after
+// shutdownTimeout.
+func (b *Bridge) shutdown(servers []*http.Server, stopServing context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := b.ws.Shutdown(ctx, "server shutting down"); err != nil {
+		wsLog.Warn("closing websockets", "err", err)
+	}
+	stopServing()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			httpLog.Warn("stopping server", "addr", srv.Addr, "err", err)
+		}
+	}
+}
+
+// runSession connects to the source and serves clients from the session
+// until the sim quits, the connection fails, a replay ends or ctx does.
+func (b *Bridge) runSession(ctx context.Context) {
+	ws, subs, peers, hist, auth, st := b.ws, b.subs, b.peers, b.hist, b.auth, b.settings
+
+	src := b.opts.Source
+	if src == nil {
+		src = defaultSource(st)
+	}
+	session, err := openSimSession(b.opts.Name, src)
+	if err != nil {
+		if !isIgnorableSimConnectError(err) {
+			simLog.Error("failed to create simconnect connection", "err", err)
+		} else {
+			simLog.Debug("simulator not running", "err", err)
+		}
+		return
+	}
+	if session.Done != nil {
+		simLog.Info("replaying")
+	} else {
+		simLog.Info("connected to flight simulator")
+	}
+	defer session.Close()
+	s := session.SimConnect
+
+	b.sim.setConnected(true, time.Now())
+	b.metrics.connected()
+	b.publishSimStatus()
+	defer func() {
+		b.sim.setConnected(false, time.Now())
+		b.publishSimStatus()
+	}()
+
+	report := &Report{}
+	err = s.RegisterDataDefinition(report)
+	if err != nil {
+		simLog.Error("failed to register data definition", "definition", "Report", "err", err)
+		return
+	}
+
+	trafficReport := &TrafficReport{}
+	err = s.RegisterDataDefinition(trafficReport)
+	if err != nil {
+		simLog.Error("failed to register data definition", "definition", "TrafficReport", "err", err)
+		return
+	}
+
+	teleportReport := &TeleportRequest{}
+	err = s.RegisterDataDefinition(teleportReport)
+	if err != nil {
+		simLog.Error("failed to register data definition", "definition", "TeleportRequest", "err", err)
+		return
+	}
+
+	eventSimStartID := s.GetEventID()
+	//s.SubscribeToSystemEvent(eventSimStartID, "SimStart")
+	//s.SubscribeToFacilities(simconnect.FACILITY_LIST_TYPE_AIRPORT, s.GetDefineID(&simconnect.DataFacilityAirport{}))
+	//s.SubscribeToFacilities(simconnect.FACILITY_LIST_TYPE_WAYPOINT, s.GetDefineID(&simconnect.DataFacilityWaypoint{}))
+
+	var lastReport Report
+
+	ui := simconnect.NewUI(s)
+	defer ui.Close()
+	ui.Print(simconnect.TEXT_TYPE_PRINT_WHITE, 15, "simconnect-ws connected, pairing code "+auth.PairingCode())
+	if err := b.addMenu(ui, &lastReport); err != nil {
+		simLog.Warn("failed to add menu", "err", err)
+	}
+
+	subs.Reset()
+	b.deltas.reset()
+	b.events.reset()
+	defer b.events.reset()
+	b.teleports.reset()
+	defer b.teleports.reset()
+	b.lookups.reset()
+	defer b.lookups.reset()
+	publishTraffic(ws, peers, hist.traffic.reset())
+
+	simconnectTick := time.NewTicker(100 * time.Millisecond)
+	defer simconnectTick.Stop()
+	subscriptionTick := time.NewTicker(minSubscriptionRate / 2)
+	defer subscriptionTick.Stop()
+	planePositionTick := time.NewTicker(st.planeInterval)
+	defer func() { planePositionTick.Stop() }()
+
+	// traffic is off without an interval or when its stream is disabled
+	var trafficTicker *time.Ticker
+	var trafficTick <-chan time.Time
+	startTraffic := func() {
+		if trafficTicker != nil {
+			trafficTicker.Stop()
+			trafficTicker, trafficTick = nil, nil
+		}
+		if st.trafficInterval > 0 && st.streamEnabled("traffic") {
+			trafficTicker = time.NewTicker(st.trafficInterval)
+			trafficTick = trafficTicker.C
+		} else {
+			publishTraffic(ws, peers, hist.traffic.reset())
+		}
+	}
+	startTraffic()
+	defer func() {
+		if trafficTicker != nil {
+			trafficTicker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-planePositionTick.C:
+			report.RequestData(s)
+
+		case now := <-subscriptionTick.C:
+			if st.streamEnabled("subscriptions") {
+				subs.Poll(s, now)
+			}
+
+		case c := <-b.configReloads:
+			if b.reloadConfig(c) {
+				planePositionTick.Stop()
+				planePositionTick = time.NewTicker(st.planeInterval)
+				startTraffic()
+			}
+
+		case <-trafficTick:
+			publishTraffic(ws, peers, hist.traffic.startSweep())
+			trafficReport.RequestData(s, simconnect.DWORD(st.trafficRadius))
+			//s.RequestFacilitiesList(simconnect.FACILITY_LIST_TYPE_AIRPORT, airportRequestID)
+			//s.RequestFacilitiesList(simconnect.FACILITY_LIST_TYPE_WAYPOINT, waypointRequestID)
+
+		case now := <-simconnectTick.C:
+			b.lookups.expire(now)
+			b.events.expire(now)
+			b.teleports.expire(now)
+			for {
+				ppData, r1, err := s.GetNextDispatch()
+				if err != nil {
+					simLog.Debug("dispatch error", "err", err)
+				}
+				if r1 < 0 {
+					if uint32(r1) == simconnect.E_FAIL {
+						break
+					}
+					// anything but an empty queue means the connection is gone
+					simLog.Warn("simconnect dispatch failed, ending session", "result", fmt.Sprintf("%#x", uint32(r1)), "err", err)
+					return
+				}
+				if ppData == nil {
+					simLog.Debug("dispatch returned no data")
+					break
+				}
+
+				recvInfo := *(*simconnect.Recv)(ppData)
+				b.sim.dataReceived(now)
+				b.metrics.received(recvInfo.ID)
+
+				switch recvInfo.ID {
+				case simconnect.RECV_ID_QUIT:
+					simLog.Info("flight simulator quit")
+					return
+
+				case simconnect.RECV_ID_EXCEPTION:
+					recvErr := *(*simconnect.RecvException)(ppData)
+					b.sim.addException(recvErr, now)
+					b.metrics.exception(recvErr.Exception)
+					if subs.Exception(recvErr) || b.events.exception(recvErr) || b.teleports.exception(recvErr) {
+						break
+					}
+					simLog.Warn("simconnect exception",
+						"exception", simconnect.ExceptionNames[recvErr.Exception],
+						"send_id", recvErr.SendID,
+						"index", recvErr.Index,
+					)
+
+				case simconnect.RECV_ID_OPEN:
+					recvOpen := *(*simconnect.RecvOpen)(ppData)
+					b.sim.setOpen(recvOpen)
+					b.publishSimStatus()
+					simLog.Info("flight simulator info",
+						"codename", cString(recvOpen.ApplicationName[:]),
+						"version", fmt.Sprintf("%d.%d (%d.%d)",
+							recvOpen.ApplicationVersionMajor, recvOpen.ApplicationVersionMinor,
+							recvOpen.ApplicationBuildMajor, recvOpen.ApplicationBuildMinor),
+						"simconnect", fmt.Sprintf("%d.%d (%d.%d)",
+							recvOpen.SimConnectVersionMajor, recvOpen.SimConnectVersionMinor,
+							recvOpen.SimConnectBuildMajor, recvOpen.SimConnectBuildMinor),
+					)
+
+				case simconnect.RECV_ID_EVENT:
+					recvEvent := *(*simconnect.RecvEvent)(ppData)
+					if ui.HandleEvent(&recvEvent) {
+						break
+					}
+
+					switch recvEvent.EventID {
+					case eventSimStartID:
+						simLog.Info("sim started")
+					default:
+						simLog.Debug("unknown event", "event_id", recvEvent.EventID)
+					}
+				case simconnect.RECV_ID_SYSTEM_STATE:
+					state := (*simconnect.RecvSystemState)(ppData)
+					if !b.events.confirmed(s, state.RequestID) && !b.teleports.confirmed(state.RequestID) {
+						simLog.Debug("unexpected system state", "request_id", state.RequestID)
+					}
+
+				case simconnect.RECV_ID_WAYPOINT_LIST:
+					var waypointList simconnect.RecvFacilityWaypointList
+					if err := waypointList.UnmarshalBinary(simconnect.MessageBytes(ppData)); err != nil {
+						simLog.Warn("bad waypoint list", "err", err)
+						break
+					}
+					simLog.Debug("waypoint list", "list", waypointList)
+
+				case simconnect.RECV_ID_AIRPORT_LIST:
+					var airportList simconnect.RecvFacilityAirportList
+					if err := airportList.UnmarshalBinary(simconnect.MessageBytes(ppData)); err != nil {
+						simLog.Warn("bad airport list", "err", err)
+						break
+					}
+					simLog.Debug("airport list", "list", airportList)
+
+				case simconnect.RECV_ID_FACILITY_DATA:
+					b.lookups.data(simconnect.MessageBytes(ppData))
+
+				case simconnect.RECV_ID_FACILITY_DATA_END:
+					b.lookups.end(simconnect.MessageBytes(ppData))
+
+				case simconnect.RECV_ID_SIMOBJECT_DATA_BYTYPE:
+					if subs.Deliver(simconnect.MessageBytes(ppData)) {
+						break
+					}
+
+					recvData := *(*simconnect.RecvSimobjectDataByType)(ppData)
+
+					switch recvData.RequestID {
+					case s.GetDefineID(report):
+						report = (*Report)(ppData)
+						lastReport = *report
+
+						now := time.Now()
+						b.telemetrySeq++
+						legacy := legacyPlanePayload(report)
+						typed := typedPlanePayload(report, b.telemetrySeq, now)
+						changed, keyframe := b.deltas.next(typed["values"].(map[string]float64), now, st.keyframeInterval, st.deadbands)
+						deltaSeq := b.deltas.seq
+						hist.addPlane(report, legacy, typed, deltaSeq, now)
+						if simLog.Enabled(logging.Debug) {
+							simLog.Debug("report", "report", fmt.Sprintf("%#v", report))
+							simLog.Debug("broadcast plane", "plane", legacy)
+						}
+
+						peers.broadcastFunc(ws, "plane", "plane", func(c *websockets.Connection, p peer) (string, map[string]interface{}) {
+							if p.caps[capDelta] {
+								if peers.takeSnapshot(c) || keyframe {
+									return variantFull, fullPlanePayload(typed, deltaSeq)
+								}
+								if len(changed) == 0 {
+									return "", nil
+								}
+								return variantDelta, deltaPlanePayload(typed, deltaSeq, changed)
+							}
+							if p.caps[capTypedTelemetry] {
+								return "typed", typed
+							}
+							return "legacy", legacy
+						})
+
+					case s.GetDefineID(trafficReport):
+						trafficReport = (*TrafficReport)(ppData)
+						if simLog.Enabled(logging.Debug) {
+							simLog.Debug("traffic report", "report", trafficReport.Inspect())
+						}
+						publishTraffic(ws, peers, hist.traffic.update(
+							trafficReport.Aircraft(), recvData.EntryNumber, recvData.OutOf,
+						))
+					}
+
+				default:
+					simLog.Debug("unhandled message", "id", simconnect.RecvIDNames[recvInfo.ID])
+				}
+			}
+			b.metrics.dispatched(now)
+
+		case <-session.Done:
+			simLog.Info("replay finished")
+			return
+
+		case <-ctx.Done():
+			simLog.Info("closing simconnect session")
+			return
+
+		case m := <-ws.NewConnection:
+			// new connections speak v0 until they say hello
+			if plane := hist.latestPlane(peer{}); plane != nil {
+				f := peers.encode(m.Connection, "plane", plane)
+				f.Stream = "plane"
+				m.Connection.TrySend(f)
+			}
+
+		case call := <-b.apiCalls:
+			call.run(b, s)
+
+		case m := <-ws.ReceiveMessages:
+			b.handleClientMessage(m, s)
+		}
+	}
+}
+
+// addMenu puts a simconnect-ws entry in the simulator's Add-ons menu.
+func (b *Bridge) addMenu(ui *simconnect.UI, lastReport *Report) error {
+	ws, peers, hist, auth, st := b.ws, b.peers, b.hist, b.auth, b.settings
+
+	menu, err := ui.AddMenu("simconnect-ws", nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = menu.AddItem("Show pairing code", func() {
+		ui.Print(simconnect.TEXT_TYPE_PRINT_WHITE, 30, "simconnect-ws: pairing code "+auth.PairingCode())
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = menu.AddItem("Toggle teleport", func() {
+		st.disableTeleport = !st.disableTeleport
+		if st.disableTeleport {
+			ui.Print(simconnect.TEXT_TYPE_PRINT_WHITE, 5, "simconnect-ws: teleport disabled")
+		} else {
+			ui.Print(simconnect.TEXT_TYPE_PRINT_WHITE, 5, "simconnect-ws: teleport enabled")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = menu.AddItem("Mark position", func() {
+		mark := map[string]interface{}{
+			"latitude":  lastReport.Latitude,
+			"longitude": lastReport.Longitude,
+			"altitude":  fmt.Sprintf("%.0f", lastReport.Altitude),
+		}
+		peers.broadcast(ws, "mark", mark)
+		hist.addEvent("mark", mark, time.Now())
+		ui.Print(simconnect.TEXT_TYPE_PRINT_WHITE, 5, fmt.Sprintf(
+			"simconnect-ws: marked %.4f %.4f", lastReport.Latitude, lastReport.Longitude,
+		))
+	})
+	return err
+}
+
+func isIgnorableSimConnectError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "The operation completed successfully") {
+		return true
+	}
+	if strings.Contains(msg, "SimConnect_Open error: -2147467259") {
+		return true
+	}
+	return false
+}
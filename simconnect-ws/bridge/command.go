@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+// Command is a request from a websocket or REST client for a handler added
+// with Bridge.HandleCommand.
+type Command struct {
+	r *request
+}
+
+// Type is the packet type the command was sent as.
+func (c *Command) Type() string {
+	return c.r.typ
+}
+
+// Client is the client that sent the command.
+func (c *Command) Client() Client {
+	return c.r.client
+}
+
+// Decode unmarshals the payload of the command into v.
+func (c *Command) Decode(v interface{}) error {
+	return decodePayload(c.r.payload, v)
+}
+
+// SimConnect is the session the command arrived in, or nil while no
+// simulator is connected.
+func (c *Command) SimConnect() *simconnect.SimConnect {
+	return c.r.s
+}
+
+// CommandHandler handles a command on the bridge goroutine and returns the
+// payload of its reply.
+type CommandHandler func(c *Command) (interface{}, error)
+
+// CommandError is an error a client gets with code, such as "bad_request",
+// "forbidden", "not_found" or "sim_unavailable". Other errors reach clients as
+// "internal".
+func CommandError(code, format string, args ...interface{}) error {
+	return newProtocolError(code, format, args...)
+}
+
+// HandleCommand answers commands of type typ from clients with at least the
+// role need, replacing any built-in handler of that type. Websocket clients
+// send them as packets and REST clients to POST /api/<typ>; commands that
+// need more than a viewer go to the audit log. Call it before Run.
+func (b *Bridge) HandleCommand(typ string, need Role, h CommandHandler) {
+	b.roles[typ] = need
+	b.controls[typ] = need > RoleViewer
+	b.handlers[typ] = func(r *request) (string, interface{}, error) {
+		result, err := h(&Command{r: r})
+		return "ack", result, err
+	}
+
+	path := "/api/" + typ
+	if !b.apiPaths[path] {
+		b.apiPaths[path] = true
+		b.mux.HandleFunc(path, b.auth.requireAuth(b.apiPost(typ)))
+	}
+}
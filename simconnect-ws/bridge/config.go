@@ -0,0 +1,436 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect-ws/logging"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+const configPollInterval = 2 * time.Second
+
+// streams that can be turned off
+var streamNames = []string{"traffic", "subscriptions", "sse"}
+
+// config is the config file. Missing keys leave their setting alone, and
+// flags given on the command line win over the file.
+type config struct {
+	ListenHTTP  *string `json:"listen_http"`
+	ListenHTTPS *string `json:"listen_https"`
+	TLSCert     *string `json:"tls_cert"`
+	TLSKey      *string `json:"tls_key"`
+
+	AllowedOrigins  []string `json:"allowed_origins"`
+	AllowAllOrigins *bool    `json:"allow_all_origins"`
+
+	PlaneInterval    *duration          `json:"plane_interval"`
+	TrafficInterval  *duration          `json:"traffic_interval"`
+	TrafficRadius    *uint              `json:"traffic_radius"`
+	TrackHistory     *duration          `json:"track_history"`
+	TrackInterval    *duration          `json:"track_interval"`
+	KeyframeInterval *duration          `json:"keyframe_interval"`
+	Deadband         map[string]float64 `json:"deadband"`
+	Streams          map[string]bool    `json:"streams"`
+
+	Outputs []outputConfig `json:"outputs"`
+
+	NoAuth          *bool             `json:"no_auth"`
+	PairRole        *string           `json:"pair_role"`
+	OriginRoles     map[string]string `json:"origin_roles"`
+	DisableTeleport *bool             `json:"disable_teleport"`
+	DisableEvents   *bool             `json:"disable_events"`
+	CopilotEvents   []string          `json:"copilot_events"`
+
+	RecordDir *string `json:"record_dir"`
+	AuditLog  *string `json:"audit_log"`
+	Verbose   *bool   `json:"verbose"`
+
+	LogLevel      *string `json:"log_level"`
+	LogFormat     *string `json:"log_format"`
+	LogFile       *string `json:"log_file"`
+	LogMaxSize    *uint   `json:"log_max_size"`
+	LogMaxBackups *uint   `json:"log_max_backups"`
+
+	// parsed by check
+	pairRole    Role
+	originRoles originRoleFlag
+}
+
+// duration is a time.Duration written like "200ms" in the config file.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("durations are strings like \"2s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return fmt.Errorf("negative duration %s", s)
+	}
+	*d = duration(v)
+	return nil
+}
+
+// loadConfig reads the config file at path. A missing file is no config.
+func loadConfig(path string) (*config, error) {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var c config
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := c.check(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// check validates what json can't, so a bad file changes nothing.
+func (c *config) check() error {
+	if c.PairRole != nil {
+		r, err := parseRole(*c.PairRole)
+		if err != nil {
+			return fmt.Errorf("pair_role: %w", err)
+		}
+		c.pairRole = r
+	}
+	if c.OriginRoles != nil {
+		c.originRoles = originRoleFlag{}
+		for origin, name := range c.OriginRoles {
+			if err := c.originRoles.Set(origin + "=" + name); err != nil {
+				return fmt.Errorf("origin_roles: %w", err)
+			}
+		}
+	}
+	for field, amount := range c.Deadband {
+		if err := checkDeadbandField(field); err != nil {
+			return fmt.Errorf("deadband: %w", err)
+		}
+		if amount < 0 {
+			return fmt.Errorf("deadband: negative amount for %s", field)
+		}
+	}
+	for i, o := range c.Outputs {
+		if err := o.check(); err != nil {
+			return fmt.Errorf("outputs[%d]: %w", i, err)
+		}
+	}
+	for name := range c.Streams {
+		if !isStreamName(name) {
+			return fmt.Errorf("streams: unknown stream %q, use %s", name, strings.Join(streamNames, ", "))
+		}
+	}
+	if c.LogLevel != nil {
+		if err := logging.CheckLevels(*c.LogLevel); err != nil {
+			return fmt.Errorf("log_level: %w", err)
+		}
+	}
+	if c.LogFormat != nil {
+		if err := checkLogFormat(*c.LogFormat); err != nil {
+			return fmt.Errorf("log_format: %w", err)
+		}
+	}
+	if c.PlaneInterval != nil && *c.PlaneInterval == 0 {
+		return fmt.Errorf("plane_interval must be above 0")
+	}
+	return nil
+}
+
+// configApply tracks which settings a config changes.
+type configApply struct {
+	explicit map[string]bool // flags given on the command line
+	startup  bool
+
+	live    bool     // a setting the main loop uses changed
+	restart []string // settings that only apply after a restart
+}
+
+// use reports whether the setting behind flagName should take a value from
+// the file that differs from the current one.
+func (a *configApply) use(flagName string, differs, live bool) bool {
+	if !differs || a.explicit[flagName] {
+		return false
+	}
+	if !a.startup && !live {
+		a.restart = append(a.restart, flagName)
+		return false
+	}
+	if live {
+		a.live = true
+	}
+	return true
+}
+
+// apply puts the settings in c into st. At startup that is every
+// setting; later, only those safe to change while running.
+func (c *config) apply(a *configApply, st *Settings, ws *websockets.Websocket) {
+	if c.ListenHTTP != nil && a.use("listen-http", *c.ListenHTTP != st.httpListen, false) {
+		st.httpListen = *c.ListenHTTP
+	}
+	if c.ListenHTTPS != nil && a.use("listen-https", *c.ListenHTTPS != st.httpsListen, false) {
+		st.httpsListen = *c.ListenHTTPS
+	}
+	if c.TLSCert != nil && a.use("tls-cert", *c.TLSCert != st.tlsCertPath, false) {
+		st.tlsCertPath = *c.TLSCert
+	}
+	if c.TLSKey != nil && a.use("tls-key", *c.TLSKey != st.tlsKeyPath, false) {
+		st.tlsKeyPath = *c.TLSKey
+	}
+	if c.TrackHistory != nil && a.use("track-history", time.Duration(*c.TrackHistory) != st.trackHistory, false) {
+		st.trackHistory = time.Duration(*c.TrackHistory)
+	}
+	if c.TrackInterval != nil && a.use("track-interval", time.Duration(*c.TrackInterval) != st.trackInterval, false) {
+		st.trackInterval = time.Duration(*c.TrackInterval)
+	}
+	if c.RecordDir != nil && a.use("record-dir", *c.RecordDir != st.recordDir, false) {
+		st.recordDir = *c.RecordDir
+	}
+	if c.AuditLog != nil && a.use("audit-log", *c.AuditLog != st.auditLogPath, false) {
+		st.auditLogPath = *c.AuditLog
+	}
+	if c.LogFormat != nil && a.use("log-format", *c.LogFormat != st.logFormat, false) {
+		st.logFormat = *c.LogFormat
+	}
+	if c.LogFile != nil && a.use("log-file", *c.LogFile != st.logFilePath, false) {
+		st.logFilePath = *c.LogFile
+	}
+	if c.LogMaxSize != nil && a.use("log-max-size", *c.LogMaxSize != st.logMaxSize, false) {
+		st.logMaxSize = *c.LogMaxSize
+	}
+	if c.LogMaxBackups != nil && a.use("log-max-backups", *c.LogMaxBackups != st.logMaxBackups, false) {
+		st.logMaxBackups = *c.LogMaxBackups
+	}
+	if c.Outputs != nil && a.use("outputs", !reflect.DeepEqual(c.Outputs, st.outputs), false) {
+		st.outputs = c.Outputs
+	}
+
+	levelsChanged := false
+	if c.Verbose != nil && a.use("verbose", *c.Verbose != st.verbose, true) {
+		st.verbose = *c.Verbose
+		levelsChanged = true
+	}
+	if c.LogLevel != nil && a.use("log-level", *c.LogLevel != st.logLevels, true) {
+		st.logLevels = *c.LogLevel
+		levelsChanged = true
+	}
+	// at startup setupLogging applies them
+	if levelsChanged && !a.startup {
+		if err := applyLogLevels(st); err != nil {
+			configLog.Warn("applying log levels", "err", err)
+		}
+	}
+
+	originsChanged := false
+	if c.AllowedOrigins != nil && a.use("origins", strings.Join(c.AllowedOrigins, ",") != st.allowedOrigins.String(), true) {
+		st.allowedOrigins = stringList(c.AllowedOrigins)
+		originsChanged = true
+	}
+	if c.AllowAllOrigins != nil && a.use("allow-all-origins", *c.AllowAllOrigins != st.allowAllOrigins, true) {
+		st.allowAllOrigins = *c.AllowAllOrigins
+		originsChanged = true
+	}
+	if originsChanged && ws != nil {
+		ws.SetOrigins(st.allowAllOrigins, st.allowedOrigins)
+	}
+
+	if c.PlaneInterval != nil && a.use("plane-interval", time.Duration(*c.PlaneInterval) != st.planeInterval, true) {
+		st.planeInterval = time.Duration(*c.PlaneInterval)
+	}
+	if c.TrafficInterval != nil && a.use("traffic-interval", time.Duration(*c.TrafficInterval) != st.trafficInterval, true) {
+		st.trafficInterval = time.Duration(*c.TrafficInterval)
+	}
+	if c.TrafficRadius != nil && a.use("traffic-radius", *c.TrafficRadius != st.trafficRadius, true) {
+		st.trafficRadius = *c.TrafficRadius
+		if st.trafficRadius > maxTrafficRadius {
+			st.trafficRadius = maxTrafficRadius
+		}
+	}
+	if c.KeyframeInterval != nil && a.use("keyframe-interval", time.Duration(*c.KeyframeInterval) != st.keyframeInterval, true) {
+		st.keyframeInterval = time.Duration(*c.KeyframeInterval)
+	}
+	// the file's deadbands replace earlier ones, so a field it drops is
+	// back to its default
+	if c.Deadband != nil {
+		merged := defaultDeadbands.clone()
+		for k, v := range c.Deadband {
+			merged[k] = v
+		}
+		if a.use("deadband", merged.String() != st.deadbands.String(), true) {
+			st.deadbands = merged
+		}
+	}
+	if c.DisableTeleport != nil && a.use("disable-teleport", *c.DisableTeleport != st.disableTeleport, true) {
+		st.disableTeleport = *c.DisableTeleport
+	}
+	if c.DisableEvents != nil && a.use("disable-events", *c.DisableEvents != st.disableEvents, true) {
+		st.disableEvents = *c.DisableEvents
+	}
+	if c.CopilotEvents != nil {
+		events := eventSet{}
+		events.add(c.CopilotEvents...)
+		if a.use("copilot-events", events.String() != st.configCopilotEvents.String(), true) {
+			st.configCopilotEvents = events
+		}
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if c.NoAuth != nil && a.use("no-auth", *c.NoAuth != st.noAuth, true) {
+		st.noAuth = *c.NoAuth
+	}
+	if c.PairRole != nil && a.use("pair-role", c.pairRole != st.pairRole, true) {
+		st.pairRole = c.pairRole
+	}
+	if c.originRoles != nil && a.use("origin-role", c.originRoles.String() != st.originRoles.String(), true) {
+		st.originRoles = c.originRoles
+	}
+	if c.Streams != nil {
+		streams := streamSet{}
+		for _, name := range streamNames {
+			if on, ok := c.Streams[name]; ok && !on {
+				streams[name] = true
+			}
+		}
+		if a.use("disable-streams", streams.String() != st.disabledStreams.String(), true) {
+			st.disabledStreams = streams
+		}
+	}
+}
+
+// resolveConfigPath makes path absolute, next to the exe if relative.
+func resolveConfigPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(filepath.Dir(exe), path)
+}
+
+// watchConfig sends the config at path on reloads each time the file
+// changes, until ctx ends.
+func watchConfig(ctx context.Context, path string, reloads chan<- *config) {
+	tick := time.NewTicker(configPollInterval)
+	defer tick.Stop()
+
+	last := configStamp(path)
+	for {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+		stamp := configStamp(path)
+		if stamp == last {
+			continue
+		}
+		last = stamp
+
+		c, err := loadConfig(path)
+		if err != nil {
+			configLog.Warn("not reloading config", "err", err)
+			continue
+		}
+		select {
+		case reloads <- c:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// configStamp tells versions of a file apart.
+func configStamp(path string) string {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", fi.ModTime().UnixNano(), fi.Size())
+}
+
+// reloadConfig applies the live settings of a reloaded config and reports
+// whether the main loop needs to pick up new rates.
+func (b *Bridge) reloadConfig(c *config) bool {
+	a := &configApply{explicit: b.explicit}
+	c.apply(a, b.settings, b.ws)
+	if len(a.restart) > 0 {
+		configLog.Info("config reloaded", "restart_to_apply", strings.Join(a.restart, ", "))
+	} else {
+		configLog.Info("config reloaded")
+	}
+	return a.live
+}
+
+// stringList is a flag.Value that takes comma separated strings.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = nil
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// streamSet is a flag.Value that takes comma separated stream names.
+type streamSet map[string]bool
+
+func (s streamSet) String() string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (s streamSet) Set(v string) error {
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if !isStreamName(name) {
+			return fmt.Errorf("unknown stream %q, use %s", name, strings.Join(streamNames, ", "))
+		}
+		s[name] = true
+	}
+	return nil
+}
+
+func isStreamName(name string) bool {
+	for _, n := range streamNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a config file that sets every key to something no flag below uses
+const testConfig = `{
+	"listen_http": "127.0.0.1:9100",
+	"listen_https": "127.0.0.1:9543",
+	"tls_cert": "file-cert.pem",
+	"tls_key": "file-key.pem",
+	"allowed_origins": ["https://file.example"],
+	"allow_all_origins": false,
+	"plane_interval": "5s",
+	"traffic_interval": "5s",
+	"traffic_radius": 5000,
+	"track_history": "5m",
+	"track_interval": "5s",
+	"keyframe_interval": "5s",
+	"deadband": {"heading": 2},
+	"streams": {"traffic": false},
+	"outputs": [{"type": "file", "path": "file.jsonl"}],
+	"no_auth": false,
+	"pair_role": "instructor",
+	"origin_roles": {"https://file.example": "copilot"},
+	"disable_teleport": false,
+	"disable_events": false,
+	"copilot_events": ["GEAR_TOGGLE"],
+	"record_dir": "file-records",
+	"audit_log": "file-audit.log",
+	"verbose": false,
+	"log_level": "error",
+	"log_format": "logfmt",
+	"log_file": "file.log",
+	"log_max_size": 50,
+	"log_max_backups": 50
+}`
+
+// every flag the config file has a key for, set to differ from testConfig
+var testFlags = []string{
+	"-listen-http=127.0.0.1:9001",
+	"-listen-https=127.0.0.1:9444",
+	"-tls-cert=flag-cert.pem",
+	"-tls-key=flag-key.pem",
+	"-origins=https://flag.example",
+	"-allow-all-origins",
+	"-plane-interval=1s",
+	"-traffic-interval=1s",
+	"-traffic-radius=1000",
+	"-track-history=1m",
+	"-track-interval=1s",
+	"-keyframe-interval=1s",
+	"-deadband=altitude=1",
+	"-disable-streams=sse",
+	"-no-auth",
+	"-pair-role=viewer",
+	"-origin-role=https://flag.example=viewer",
+	"-disable-teleport",
+	"-disable-events",
+	"-copilot-events=FLAPS_UP",
+	"-record-dir=flag-records",
+	"-audit-log=flag-audit.log",
+	"-verbose",
+	"-log-level=warn",
+	"-log-format=json",
+	"-log-file=flag.log",
+	"-log-max-size=1",
+	"-log-max-backups=1",
+}
+
+func loadTestConfig(t *testing.T) *config {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "simconnect-ws-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "simconnect-ws.json")
+	if err := ioutil.WriteFile(path, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func flagValues(fs *flag.FlagSet) map[string]string {
+	values := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
+
+// flags given on the command line win over the file, at startup and on
+// reloads
+func TestExplicitFlagsWinOverConfig(t *testing.T) {
+	c := loadTestConfig(t)
+
+	st := NewSettings()
+	fs := flag.NewFlagSet("simconnect-ws", flag.ContinueOnError)
+	st.RegisterFlags(fs)
+	if err := fs.Parse(testFlags); err != nil {
+		t.Fatal(err)
+	}
+	want := flagValues(fs)
+
+	for _, startup := range []bool{true, false} {
+		a := &configApply{explicit: st.explicitFlags(), startup: startup}
+		c.apply(a, st, nil)
+
+		for name, got := range flagValues(fs) {
+			if got != want[name] {
+				t.Errorf("startup %v: -%s is %q after the config, want %q", startup, name, got, want[name])
+			}
+		}
+		if a.live {
+			t.Errorf("startup %v: the config changed a live setting", startup)
+		}
+		if len(a.restart) > 0 {
+			t.Errorf("startup %v: the config wants a restart for %v", startup, a.restart)
+		}
+		if len(st.configCopilotEvents) > 0 {
+			t.Errorf("startup %v: copilot events %v from the config", startup, st.configCopilotEvents)
+		}
+	}
+	// outputs have no flag, so the file's apply
+	if len(st.outputs) != 1 {
+		t.Errorf("outputs %v, want the config's", st.outputs)
+	}
+}
+
+// without flags the file's values apply
+func TestConfigAppliesWithoutFlags(t *testing.T) {
+	c := loadTestConfig(t)
+
+	st := NewSettings()
+	st.verbose = true
+	c.apply(&configApply{explicit: map[string]bool{}, startup: true}, st, nil)
+
+	if st.verbose {
+		t.Error("verbose not taken from the config")
+	}
+	if st.planeInterval.String() != "5s" {
+		t.Errorf("plane interval %s, want 5s", st.planeInterval)
+	}
+	if len(st.outputs) != 1 || st.outputs[0].Path != "file.jsonl" {
+		t.Errorf("outputs %v, want the config's", st.outputs)
+	}
+	if st.pairRole != RoleInstructor {
+		t.Errorf("pair role %s, want instructor", st.pairRole)
+	}
+}
@@ -0,0 +1,163 @@
+package bridge
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// capDelta is the hello capability that switches a connection's plane
+// packets to a full snapshot followed by diffs of the changed values.
+const capDelta = "delta"
+
+// defaultDeadbands is how far each plane value may move before a delta
+// carries it. Values without a deadband are sent on any change.
+var defaultDeadbands = deadbandFlag{
+	"altitude":       1,
+	"latitude":       0.000001,
+	"longitude":      0.000001,
+	"heading":        0.1,
+	"ground_course":  0.1,
+	"ground_heading": 0.1,
+	"ground_speed":   0.5,
+	"airspeed":       0.5,
+	"airspeed_true":  0.5,
+	"vertical_speed": 10,
+	"flaps":          0.1,
+	"trim":           0.05,
+	"rudder_trim":    0.05,
+}
+
+// plane payload variants of delta connections. each builds on the ones
+// before it, so they are sent as events rather than replaced while queued
+// like other plane packets.
+const (
+	variantFull  = "full"
+	variantDelta = "delta"
+)
+
+// sequencedVariants are the payload variants broadcastFunc never coalesces.
+var sequencedVariants = map[string]bool{variantFull: true, variantDelta: true}
+
+// deadbandFlag is a flag.Value that takes comma separated field=amount pairs.
+type deadbandFlag map[string]float64
+
+func (f deadbandFlag) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + strconv.FormatFloat(f[k], 'g', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f deadbandFlag) clone() deadbandFlag {
+	c := make(deadbandFlag, len(f))
+	for k, v := range f {
+		c[k] = v
+	}
+	return c
+}
+
+func (f deadbandFlag) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("expected field=amount, got %q", part)
+		}
+		amount, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil || amount < 0 {
+			return fmt.Errorf("invalid deadband %q for %s", kv[1], kv[0])
+		}
+		field := strings.TrimSpace(kv[0])
+		if err := checkDeadbandField(field); err != nil {
+			return err
+		}
+		f[field] = amount
+	}
+	return nil
+}
+
+// checkDeadbandField fails for names that aren't numeric plane values.
+func checkDeadbandField(field string) error {
+	for _, name := range planeValueNames() {
+		if name == field {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown plane value %q, use %s", field, strings.Join(planeValueNames(), ", "))
+}
+
+// planeDeltas tracks the plane values delta connections were last sent.
+// Every connection shares it: a delta carries the new value of each field
+// that moved past its deadband, so clients only need to overwrite them.
+type planeDeltas struct {
+	seq          uint64
+	sent         map[string]float64
+	lastKeyframe time.Time
+}
+
+// reset makes the next sample a keyframe.
+func (d *planeDeltas) reset() {
+	d.sent = nil
+}
+
+// next compares values to what was last sent. It returns the values that
+// changed and whether a keyframe is due instead, and advances the sequence
+// number when either has something to send. A keyframe is due every
+// keyframeInterval; fields move once past their deadband.
+func (d *planeDeltas) next(values map[string]float64, now time.Time, keyframeInterval time.Duration, deadbands deadbandFlag) (changed map[string]float64, keyframe bool) {
+	if d.sent == nil || now.Sub(d.lastKeyframe) >= keyframeInterval {
+		d.sent = make(map[string]float64, len(values))
+		for k, v := range values {
+			d.sent[k] = v
+		}
+		d.lastKeyframe = now
+		d.seq++
+		return nil, true
+	}
+
+	changed = map[string]float64{}
+	for k, v := range values {
+		last, ok := d.sent[k]
+		if ok && math.Abs(v-last) <= deadbands[k] {
+			continue
+		}
+		changed[k] = v
+		d.sent[k] = v
+	}
+	if len(changed) > 0 {
+		d.seq++
+	}
+	return changed, false
+}
+
+// fullPlanePayload is the snapshot a delta connection starts from.
+func fullPlanePayload(typed map[string]interface{}, seq uint64) map[string]interface{} {
+	payload := make(map[string]interface{}, len(typed)+1)
+	for k, v := range typed {
+		payload[k] = v
+	}
+	payload["seq"] = seq
+	payload["delta"] = false
+	return payload
+}
+
+// deltaPlanePayload carries the values that changed since the previous seq.
+func deltaPlanePayload(typed map[string]interface{}, seq uint64, changed map[string]float64) map[string]interface{} {
+	return map[string]interface{}{
+		"seq":       seq,
+		"delta":     true,
+		"sim_time":  typed["sim_time"],
+		"wall_time": typed["wall_time"],
+		"values":    changed,
+	}
+}
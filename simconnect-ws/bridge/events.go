@@ -0,0 +1,229 @@
+package bridge
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+// eventCheckTimeout is how long the sim gets to confirm a new event name.
+const eventCheckTimeout = 5 * time.Second
+
+// clientEvents maps sim event names to the client event IDs mapped to them
+// in the current simconnect session. The sim only says so when it doesn't
+// know a name, with an exception, so a new name is mapped and followed by a
+// system state request: the exception for the mapping comes before the
+// answer, and an answer without one means the name is good.
+type clientEvents struct {
+	mu      sync.Mutex
+	ids     map[string]simconnect.DWORD
+	pending map[simconnect.DWORD]*eventCheck // by the request ID of the check
+}
+
+// eventCheck is a new event name waiting for the sim to accept it, with the
+// transmits waiting on it.
+type eventCheck struct {
+	name    string
+	id      simconnect.DWORD
+	sendID  simconnect.DWORD // of the mapping
+	started time.Time
+	waiting []eventTransmit
+}
+
+type eventTransmit struct {
+	data simconnect.DWORD
+	done func(error)
+}
+
+// reset fails the checks of the previous simconnect session and forgets its
+// mappings.
+func (e *clientEvents) reset() {
+	e.mu.Lock()
+	pending := e.pending
+	e.ids, e.pending = nil, nil
+	e.mu.Unlock()
+
+	for _, c := range pending {
+		c.fail(newProtocolError(errSimUnavailable, "simulator disconnected"))
+	}
+}
+
+// transmit sends the sim event name with data to the user aircraft and calls
+// done from the simconnect loop once it was sent or failed. Names the session
+// hasn't seen are checked with the sim first.
+func (e *clientEvents) transmit(s *simconnect.SimConnect, name string, data simconnect.DWORD, done func(error)) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if id, ok := e.ids[name]; ok {
+		done(transmitEvent(s, id, data))
+		return nil
+	}
+	for _, c := range e.pending {
+		if c.name == name {
+			c.waiting = append(c.waiting, eventTransmit{data, done})
+			return nil
+		}
+	}
+
+	id := s.GetEventID()
+	if err := s.MapClientEventToSimEvent(id, name); err != nil {
+		return err
+	}
+	sendID, err := s.GetLastSentPacketID()
+	if err != nil {
+		return err
+	}
+	requestID := s.GetRequestID()
+	if err := s.RequestSystemState(requestID, "Sim"); err != nil {
+		return err
+	}
+
+	if e.pending == nil {
+		e.pending = map[simconnect.DWORD]*eventCheck{}
+	}
+	e.pending[requestID] = &eventCheck{
+		name:    name,
+		id:      id,
+		sendID:  sendID,
+		started: time.Now(),
+		waiting: []eventTransmit{{data, done}},
+	}
+	return nil
+}
+
+// transmitKnown sends an event the bridge itself uses, whose name the sim
+// knows, right away.
+func (e *clientEvents) transmitKnown(s *simconnect.SimConnect, name string, data simconnect.DWORD) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id, ok := e.ids[name]
+	if !ok {
+		id = s.GetEventID()
+		if err := s.MapClientEventToSimEvent(id, name); err != nil {
+			return err
+		}
+		if e.ids == nil {
+			e.ids = map[string]simconnect.DWORD{}
+		}
+		e.ids[name] = id
+	}
+	return transmitEvent(s, id, data)
+}
+
+// exception fails the check whose mapping the sim refused and reports
+// whether there was one.
+func (e *clientEvents) exception(ex simconnect.RecvException) bool {
+	e.mu.Lock()
+	var failed *eventCheck
+	for requestID, c := range e.pending {
+		if c.sendID == ex.SendID {
+			failed = c
+			delete(e.pending, requestID)
+			break
+		}
+	}
+	e.mu.Unlock()
+
+	if failed == nil {
+		return false
+	}
+	failed.fail(newProtocolError(errBadRequest, "the simulator doesn't know the event %s", failed.name))
+	return true
+}
+
+// confirmed sends the transmits waiting on the check with requestID, whose
+// name the sim took, and reports whether there was one.
+func (e *clientEvents) confirmed(s *simconnect.SimConnect, requestID simconnect.DWORD) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, ok := e.pending[requestID]
+	if !ok {
+		return false
+	}
+	delete(e.pending, requestID)
+
+	if e.ids == nil {
+		e.ids = map[string]simconnect.DWORD{}
+	}
+	e.ids[c.name] = c.id
+	for _, t := range c.waiting {
+		t.done(transmitEvent(s, c.id, t.data))
+	}
+	return true
+}
+
+// expire fails the checks the sim never answered.
+func (e *clientEvents) expire(now time.Time) {
+	e.mu.Lock()
+	var expired []*eventCheck
+	for requestID, c := range e.pending {
+		if now.Sub(c.started) > eventCheckTimeout {
+			expired = append(expired, c)
+			delete(e.pending, requestID)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, c := range expired {
+		c.fail(newProtocolError(errUnavailable, "the simulator did not answer for %s", c.name))
+	}
+}
+
+func (c *eventCheck) fail(err error) {
+	for _, t := range c.waiting {
+		t.done(err)
+	}
+}
+
+func transmitEvent(s *simconnect.SimConnect, id, data simconnect.DWORD) error {
+	return s.TransmitClientEvent(
+		simconnect.OBJECT_ID_USER, id, data,
+		simconnect.GROUP_PRIORITY_HIGHEST, simconnect.EVENT_FLAG_GROUPID_IS_PRIORITY,
+	)
+}
+
+// eventRequest is the payload of an "event" packet: a sim event such as
+// PARKING_BRAKES with its optional data value.
+type eventRequest struct {
+	Name string `json:"name"`
+	Data int64  `json:"data"`
+}
+
+func handleEvent(r *request) (string, interface{}, error) {
+	if r.b.settings.disableEvents {
+		return "", nil, newProtocolError(errForbidden, "client events are disabled")
+	}
+
+	var req eventRequest
+	if err := decodePayload(r.payload, &req); err != nil {
+		return "", nil, err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 || strings.ContainsAny(req.Name, " \t\r\n\x00") {
+		return "", nil, newProtocolError(errBadRequest, "invalid event name %q", req.Name)
+	}
+	if !r.b.settings.isCopilotEvent(req.Name) && r.client.Role < RoleInstructor {
+		return "", nil, newProtocolError(errForbidden, "%s needs the %s role, %s has %s", req.Name, RoleInstructor, r.client.Name, r.client.Role)
+	}
+	if req.Data < -1<<31 || req.Data > 1<<32-1 {
+		return "", nil, newProtocolError(errBadRequest, "event data %d out of range", req.Data)
+	}
+
+	// negative values go to the sim as their two's complement DWORD
+	err := r.b.events.transmit(r.s, req.Name, simconnect.DWORD(uint32(req.Data)), func(err error) {
+		if err != nil {
+			r.respond("", nil, err)
+			return
+		}
+		r.respond("ack", struct{}{}, nil)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return "", nil, errDeferred
+}
@@ -0,0 +1,344 @@
+package bridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+const (
+	facilityTimeout = 10 * time.Second
+	metersToFeet    = 3.28084
+	nmToMeters      = 1852.0
+	earthRadius     = 6371000.0 // meters
+)
+
+// airportDefinition is the facility definition for airports with their
+// runways and parking spots. The decoders below read the fields in this
+// order.
+var airportDefinition = []string{
+	"OPEN AIRPORT",
+	"LATITUDE", "LONGITUDE", "ALTITUDE",
+	"OPEN RUNWAY",
+	"LATITUDE", "LONGITUDE", "ALTITUDE", "HEADING", "LENGTH",
+	"PRIMARY_NUMBER", "PRIMARY_DESIGNATOR", "SECONDARY_NUMBER", "SECONDARY_DESIGNATOR",
+	"CLOSE RUNWAY",
+	"OPEN TAXI_PARKING",
+	"NAME", "NUMBER", "HEADING", "BIAS_X", "BIAS_Z",
+	"CLOSE TAXI_PARKING",
+	"CLOSE AIRPORT",
+}
+
+// waypointDefinition is the facility definition for named fixes.
+var waypointDefinition = []string{
+	"OPEN WAYPOINT",
+	"LATITUDE", "LONGITUDE", "ALTITUDE",
+	"CLOSE WAYPOINT",
+}
+
+// facility is what a lookup found. Altitudes are in meters, as the sim
+// reports them.
+type facility struct {
+	found     bool
+	latitude  float64
+	longitude float64
+	altitude  float64
+	runways   []runway
+	parking   []parkingSpot
+}
+
+type runway struct {
+	latitude, longitude, altitude float64 // center
+	heading                       float64 // true, of the primary end
+	length                        float64 // meters
+
+	primaryNumber, primaryDesignator     int32
+	secondaryNumber, secondaryDesignator int32
+}
+
+type parkingSpot struct {
+	name         int32
+	number       uint32
+	heading      float64
+	biasX, biasZ float64 // meters east and north of the airport
+}
+
+// facilityLookup is one RequestFacilityData in flight.
+type facilityLookup struct {
+	icao    string
+	kind    simconnect.DWORD // FACILITY_DATA_AIRPORT or FACILITY_DATA_WAYPOINT
+	result  facility
+	started time.Time
+	done    func(f facility, err error)
+}
+
+// facilityLookups tracks facility data requests of the current simconnect
+// session and the definitions they use.
+type facilityLookups struct {
+	mu       sync.Mutex
+	airport  facilityDefinition
+	waypoint facilityDefinition
+	pending  map[simconnect.DWORD]*facilityLookup
+}
+
+// facilityDefinition is a facility definition of the current session.
+type facilityDefinition struct {
+	id      simconnect.DWORD
+	defined bool
+}
+
+// reset fails the lookups of the previous simconnect session.
+func (l *facilityLookups) reset() {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.airport, l.waypoint = facilityDefinition{}, facilityDefinition{}
+	l.mu.Unlock()
+
+	for _, p := range pending {
+		p.done(facility{}, newProtocolError(errSimUnavailable, "simulator disconnected"))
+	}
+}
+
+// lookup requests kind data for icao and calls done with it from the
+// simconnect loop.
+func (l *facilityLookups) lookup(s *simconnect.SimConnect, kind simconnect.DWORD, icao, region string, done func(facility, error)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	defineID, err := l.definition(s, kind)
+	if err != nil {
+		return err
+	}
+
+	requestID := s.GetRequestID()
+	if err := s.RequestFacilityData(defineID, requestID, icao, region); err != nil {
+		return err
+	}
+
+	if l.pending == nil {
+		l.pending = map[simconnect.DWORD]*facilityLookup{}
+	}
+	l.pending[requestID] = &facilityLookup{icao: icao, kind: kind, started: time.Now(), done: done}
+	return nil
+}
+
+// definition returns the facility definition for kind, defining it first
+// if needed; l.mu is held.
+func (l *facilityLookups) definition(s *simconnect.SimConnect, kind simconnect.DWORD) (simconnect.DWORD, error) {
+	def, fields := &l.airport, airportDefinition
+	if kind == simconnect.FACILITY_DATA_WAYPOINT {
+		def, fields = &l.waypoint, waypointDefinition
+	}
+	if def.defined {
+		return def.id, nil
+	}
+
+	defineID := s.NewDefineID()
+	for _, f := range fields {
+		if err := s.AddToFacilityDefinition(defineID, f); err != nil {
+			return 0, err
+		}
+	}
+	*def = facilityDefinition{id: defineID, defined: true}
+	return defineID, nil
+}
+
+// data handles a RECV_ID_FACILITY_DATA message.
+func (l *facilityLookups) data(msg []byte) {
+	var hdr simconnect.RecvFacilityData
+	if err := hdr.UnmarshalBinary(msg); err != nil {
+		return
+	}
+	data := msg[simconnect.SizeofRecvFacilityData:]
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p, ok := l.pending[hdr.UserRequestID]
+	if !ok {
+		return
+	}
+
+	r := facilityReader{b: data}
+	switch hdr.Type {
+	case simconnect.FACILITY_DATA_AIRPORT, simconnect.FACILITY_DATA_WAYPOINT:
+		p.result.found = true
+		p.result.latitude = r.float64()
+		p.result.longitude = r.float64()
+		p.result.altitude = r.float64()
+
+	case simconnect.FACILITY_DATA_RUNWAY:
+		rw := runway{
+			latitude:  r.float64(),
+			longitude: r.float64(),
+			altitude:  r.float64(),
+			heading:   float64(r.float32()),
+			length:    float64(r.float32()),
+		}
+		rw.primaryNumber = r.int32()
+		rw.primaryDesignator = r.int32()
+		rw.secondaryNumber = r.int32()
+		rw.secondaryDesignator = r.int32()
+		if r.ok() {
+			p.result.runways = append(p.result.runways, rw)
+		}
+
+	case simconnect.FACILITY_DATA_TAXI_PARKING:
+		spot := parkingSpot{
+			name:    r.int32(),
+			number:  uint32(r.int32()),
+			heading: float64(r.float32()),
+			biasX:   float64(r.float32()),
+			biasZ:   float64(r.float32()),
+		}
+		if r.ok() {
+			p.result.parking = append(p.result.parking, spot)
+		}
+	}
+}
+
+// end handles a RECV_ID_FACILITY_DATA_END message.
+func (l *facilityLookups) end(msg []byte) {
+	var end simconnect.RecvFacilityDataEnd
+	if err := end.UnmarshalBinary(msg); err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	p, ok := l.pending[end.RequestID]
+	delete(l.pending, end.RequestID)
+	l.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if !p.result.found {
+		p.done(facility{}, newProtocolError(errNotFound, "no facility %q", p.icao))
+		return
+	}
+	p.done(p.result, nil)
+}
+
+// expire fails lookups the sim never finished.
+func (l *facilityLookups) expire(now time.Time) {
+	l.mu.Lock()
+	var expired []*facilityLookup
+	for id, p := range l.pending {
+		if now.Sub(p.started) > facilityTimeout {
+			expired = append(expired, p)
+			delete(l.pending, id)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, p := range expired {
+		p.done(facility{}, newProtocolError(errNotFound, "no answer for facility %q", p.icao))
+	}
+}
+
+// facilityReader reads packed little-endian facility fields.
+type facilityReader struct {
+	b   []byte
+	off int
+	bad bool
+}
+
+func (r *facilityReader) next(n int) []byte {
+	if r.off+n > len(r.b) {
+		r.bad = true
+		return make([]byte, n)
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *facilityReader) float64() float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(r.next(8)))
+}
+
+func (r *facilityReader) float32() float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(r.next(4)))
+}
+
+func (r *facilityReader) int32() int32 {
+	return int32(binary.LittleEndian.Uint32(r.next(4)))
+}
+
+func (r *facilityReader) ok() bool {
+	return !r.bad
+}
+
+// runway designators as the sim numbers them
+var runwayDesignators = map[string]int32{"": 0, "L": 1, "R": 2, "C": 3, "W": 4, "A": 5, "B": 6}
+
+// parseRunway splits a runway name like "01L" into its number and
+// designator.
+func parseRunway(name string) (number, designator int32, err error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	i := 0
+	for i < len(name) && name[i] >= '0' && name[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(name[:i])
+	if err != nil || n < 1 || n > 36 {
+		return 0, 0, fmt.Errorf("invalid runway %q", name)
+	}
+	d, ok := runwayDesignators[name[i:]]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid runway %q", name)
+	}
+	return int32(n), d, nil
+}
+
+// threshold returns the threshold, landing heading and elevation in meters
+// of the runway end named number and designator.
+func (f facility) threshold(number, designator int32) (lat, lng, heading, elevation float64, ok bool) {
+	for _, rw := range f.runways {
+		switch {
+		case rw.primaryNumber == number && rw.primaryDesignator == designator:
+			heading = rw.heading
+		case rw.secondaryNumber == number && rw.secondaryDesignator == designator:
+			heading = math.Mod(rw.heading+180, 360)
+		default:
+			continue
+		}
+		lat, lng = destination(rw.latitude, rw.longitude, heading+180, rw.length/2)
+		return lat, lng, heading, rw.altitude, true
+	}
+	return 0, 0, 0, 0, false
+}
+
+// parkingPosition returns where the parking spot with number is, or the
+// first spot if number is 0.
+func (f facility) parkingPosition(number uint32) (lat, lng, heading float64, ok bool) {
+	for _, p := range f.parking {
+		if number != 0 && p.number != number {
+			continue
+		}
+		lat = f.latitude + p.biasZ/earthRadius*180/math.Pi
+		lng = f.longitude + p.biasX/(earthRadius*math.Cos(f.latitude*math.Pi/180))*180/math.Pi
+		return lat, lng, p.heading, true
+	}
+	return 0, 0, 0, false
+}
+
+// destination moves distance meters from lat, lng along the true bearing.
+func destination(lat, lng, bearing, distance float64) (float64, float64) {
+	φ1 := lat * math.Pi / 180
+	λ1 := lng * math.Pi / 180
+	θ := bearing * math.Pi / 180
+	δ := distance / earthRadius
+
+	φ2 := math.Asin(math.Sin(φ1)*math.Cos(δ) + math.Cos(φ1)*math.Sin(δ)*math.Cos(θ))
+	λ2 := λ1 + math.Atan2(math.Sin(θ)*math.Sin(δ)*math.Cos(φ1), math.Cos(δ)-math.Sin(φ1)*math.Sin(φ2))
+
+	return φ2 * 180 / math.Pi, math.Mod(λ2*180/math.Pi+540, 360) - 180
+}
@@ -0,0 +1,171 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+)
+
+const maxRecentEvents = 100
+
+// trackPoint is one sample of the flown path.
+type trackPoint struct {
+	Time        int64   `json:"time"` // ms since the unix epoch
+	SimTime     float64 `json:"sim_time"`
+	Latitude    float64 `json:"lat"`
+	Longitude   float64 `json:"lng"`
+	Altitude    float64 `json:"altitude"`
+	Heading     float64 `json:"heading"`
+	GroundSpeed float64 `json:"ground_speed"`
+}
+
+// recentEvent is an event packet kept for clients that connect later.
+type recentEvent struct {
+	Time    int64                  `json:"time"` // ms since the unix epoch
+	Type    string                 `json:"type"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// history keeps the latest plane sample, the recent track, recent events and
+// the traffic around the user so clients that connect mid-flight can catch
+// up.
+type history struct {
+	mu sync.Mutex
+
+	// latest plane sample and its payloads by variant: legacy, typed and full
+	report    Report
+	hasReport bool
+	plane     map[string]map[string]interface{}
+
+	track      []trackPoint // ring buffer
+	trackStart int
+	trackLen   int
+	lastPoint  time.Time
+	// the track covers span with a point per interval
+	span     time.Duration
+	interval time.Duration
+
+	events []recentEvent
+
+	traffic *trafficTracker
+}
+
+func newHistory(span, interval time.Duration) *history {
+	n := 1
+	if interval > 0 {
+		n = int(span/interval) + 1
+	}
+	return &history{track: make([]trackPoint, n), span: span, interval: interval, traffic: newTrafficTracker()}
+}
+
+// addPlane records a plane sample.
+func (h *history) addPlane(r *Report, legacy, typed map[string]interface{}, deltaSeq uint64, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.report = *r
+	h.hasReport = true
+	h.plane = map[string]map[string]interface{}{
+		"legacy": legacy,
+		"typed":  typed,
+		"full":   fullPlanePayload(typed, deltaSeq),
+	}
+
+	if now.Sub(h.lastPoint) < h.interval {
+		return
+	}
+	h.lastPoint = now
+
+	p := trackPoint{
+		Time:        now.UnixNano() / int64(time.Millisecond),
+		SimTime:     r.SimTime,
+		Latitude:    r.Latitude,
+		Longitude:   r.Longitude,
+		Altitude:    r.Altitude,
+		Heading:     r.Heading,
+		GroundSpeed: r.GroundSpeed,
+	}
+	if h.trackLen < len(h.track) {
+		h.track[(h.trackStart+h.trackLen)%len(h.track)] = p
+		h.trackLen++
+	} else {
+		h.track[h.trackStart] = p
+		h.trackStart = (h.trackStart + 1) % len(h.track)
+	}
+}
+
+// addEvent records an event packet.
+func (h *history) addEvent(typ string, payload map[string]interface{}, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.events) == maxRecentEvents {
+		copy(h.events, h.events[1:])
+		h.events = h.events[:maxRecentEvents-1]
+	}
+	h.events = append(h.events, recentEvent{
+		Time:    now.UnixNano() / int64(time.Millisecond),
+		Type:    typ,
+		Payload: payload,
+	})
+}
+
+// latestReport returns the last plane sample, if there was one.
+func (h *history) latestReport() (Report, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.report, h.hasReport
+}
+
+// latestPlane returns the last plane payload in the form pe gets them, or
+// nil before the first sample.
+func (h *history) latestPlane(pe peer) map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case pe.caps[capDelta]:
+		return h.plane["full"]
+	case pe.caps[capTypedTelemetry]:
+		return h.plane["typed"]
+	}
+	return h.plane["legacy"]
+}
+
+// trackSince returns the track points newer than since, oldest first, within
+// the span of h.
+func (h *history) trackSince(since, now time.Time) []trackPoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-h.span)
+	if since.After(cutoff) {
+		cutoff = since
+	}
+	cutoffMs := cutoff.UnixNano() / int64(time.Millisecond)
+
+	points := []trackPoint{}
+	for i := 0; i < h.trackLen; i++ {
+		p := h.track[(h.trackStart+i)%len(h.track)]
+		if p.Time > cutoffMs {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// recentEvents returns the kept events, oldest first.
+func (h *history) recentEvents() []recentEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]recentEvent{}, h.events...)
+}
+
+// snapshot is what a client gets when it asks to catch up.
+func (h *history) snapshot(pe peer, since, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"plane":   h.latestPlane(pe),
+		"track":   h.trackSince(since, now),
+		"events":  h.recentEvents(),
+		"traffic": h.traffic.list(),
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package bridge
 
 import (
 	"encoding/json"
@@ -231,7 +231,11 @@ func certificateInfoHandler(assets *TLSAssets, httpListen, httpsListen string) h
 	}
 }
 
-func statusHandler(httpListen, httpsListen string) http.HandlerFunc {
+// statusHandler reports the health of the server and the sim connection.
+// Clients, subscriptions and exceptions are only listed to this machine and
+// paired clients.
+func (b *Bridge) statusHandler() http.HandlerFunc {
+	httpListen, httpsListen, auth := b.settings.httpListen, b.settings.httpsListen, b.auth
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -246,16 +250,21 @@ func statusHandler(httpListen, httpsListen string) http.HandlerFunc {
 		httpHostPort := hostWithPort(hostOnly, httpPort)
 		httpsHostPort := hostWithPort(hostOnly, httpsPort)
 
-		jsonPayload := map[string]interface{}{
-			"status":    "ok",
-			"message":   "no embedded map UI; connect over websockets",
-			"ws_path":   "/ws",
-			"ws_url":    (&url.URL{Scheme: "ws", Host: httpHostPort, Path: "/ws"}).String(),
-			"wss_url":   (&url.URL{Scheme: "wss", Host: httpsHostPort, Path: "/ws"}).String(),
-			"cert_pem":  "/cert.pem",
-			"cert_der":  "/cert.der",
-			"cert_page": "/",
+		jsonPayload := b.serverStatus()
+		if !auth.isLocal(r) {
+			if _, ok := auth.check(requestToken(r)); !ok {
+				delete(jsonPayload, "clients")
+				delete(jsonPayload, "subscriptions")
+				delete(jsonPayload, "exceptions")
+			}
 		}
+		jsonPayload["message"] = "no embedded map UI; connect over websockets"
+		jsonPayload["ws_path"] = "/ws"
+		jsonPayload["ws_url"] = (&url.URL{Scheme: "ws", Host: httpHostPort, Path: "/ws"}).String()
+		jsonPayload["wss_url"] = (&url.URL{Scheme: "wss", Host: httpsHostPort, Path: "/ws"}).String()
+		jsonPayload["cert_pem"] = "/cert.pem"
+		jsonPayload["cert_der"] = "/cert.der"
+		jsonPayload["cert_page"] = "/"
 		buf, _ := json.Marshal(jsonPayload)
 		w.Write(buf)
 	}
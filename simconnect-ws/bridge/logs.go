@@ -0,0 +1,149 @@
+package bridge
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect-ws/logging"
+)
+
+// loggers of the subsystems that -log-level can tune; the websocket
+// package has its own
+var (
+	simLog    = logging.For("simconnect")
+	wsLog     = logging.For("websocket")
+	httpLog   = logging.For("http")
+	tlsLog    = logging.For("tls")
+	authLog   = logging.For("auth")
+	configLog = logging.For("config")
+)
+
+// applyLogLevels puts logLevels into effect; -verbose makes debug the
+// default level, which the levels may still override.
+func applyLogLevels(st *Settings) error {
+	spec := st.logLevels
+	if st.verbose {
+		spec = "debug," + spec
+	}
+	if err := logging.SetLevels(spec); err != nil {
+		return fmt.Errorf("log levels: %w", err)
+	}
+	return nil
+}
+
+// checkLogFormat reports whether name is a format -log-format takes.
+func checkLogFormat(name string) error {
+	for _, f := range logging.Formats {
+		if strings.EqualFold(name, f) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown log format %q, use one of %s", name, strings.Join(logging.Formats, ", "))
+}
+
+// setupLogging applies the log flags and opens the log file, if any.
+func setupLogging(st *Settings) (io.Closer, error) {
+	if err := applyLogLevels(st); err != nil {
+		return nil, err
+	}
+	if err := logging.SetFormat(st.logFormat); err != nil {
+		return nil, err
+	}
+	if st.logFilePath == "" {
+		return nil, nil
+	}
+
+	path := st.logFilePath
+	if !filepath.IsAbs(path) {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("locate executable: %w", err)
+		}
+		path = filepath.Join(filepath.Dir(exe), path)
+	}
+	f, err := logging.OpenRotating(path, int64(st.logMaxSize)<<20, int(st.logMaxBackups))
+	if err != nil {
+		return nil, err
+	}
+	logging.SetOutput(io.MultiWriter(os.Stdout, f))
+	return f, nil
+}
+
+// httpErrorLog takes what http.Server logs, which is mostly failed TLS
+// handshakes from browsers that don't trust the certificate yet.
+func httpErrorLog() *log.Logger {
+	return log.New(httpErrorWriter{}, "", 0)
+}
+
+type httpErrorWriter struct{}
+
+func (httpErrorWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+	if strings.Contains(msg, "TLS handshake error") {
+		tlsLog.Debug(msg)
+	} else {
+		httpLog.Warn(msg)
+	}
+	return len(p), nil
+}
+
+var lastRequestID uint64
+
+// withRequestIDs gives every request an ID, sent back in X-Request-ID and
+// added to its log entries, and logs it once it is done.
+func withRequestIDs(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strconv.FormatUint(atomic.AddUint64(&lastRequestID, 1), 10)
+		w.Header().Set("X-Request-ID", id)
+		r = r.WithContext(logging.WithRequestID(r.Context(), id))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+
+		l := httpLog.Ctx(r.Context())
+		kv := []interface{}{"method", r.Method, "path", r.URL.Path, "status", rec.status, "remote", r.RemoteAddr, "duration", time.Since(start)}
+		if rec.status >= 500 {
+			l.Warn("request failed", kv...)
+		} else {
+			l.Debug("request", kv...)
+		}
+	})
+}
+
+// statusRecorder notes the status of a response and passes on the
+// flushing SSE needs and the hijacking websockets need.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection can't be hijacked")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
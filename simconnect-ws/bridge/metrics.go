@@ -0,0 +1,229 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+// dispatchBuckets are the upper bounds, in seconds, of the dispatch latency
+// histogram.
+var dispatchBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// histogram counts observations into cumulative buckets, the way Prometheus
+// expects them.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // per bound, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	h.count++
+	h.sum += v
+	if i := sort.SearchFloat64s(h.bounds, v); i < len(h.bounds) {
+		h.counts[i]++
+	}
+}
+
+// serverMetrics holds the counters of the simconnect loop. The websocket
+// counters live in the hub and its connections.
+type serverMetrics struct {
+	mu         sync.Mutex
+	dispatch   histogram
+	messages   map[simconnect.DWORD]uint64 // by RECV_ID
+	exceptions map[simconnect.DWORD]uint64 // by SIMCONNECT_EXCEPTION
+	connects   uint64
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		dispatch:   newHistogram(dispatchBuckets),
+		messages:   map[simconnect.DWORD]uint64{},
+		exceptions: map[simconnect.DWORD]uint64{},
+	}
+}
+
+// dispatched records a drain of the simconnect queue for a tick at tick.
+func (m *serverMetrics) dispatched(tick time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dispatch.observe(time.Since(tick).Seconds())
+}
+
+func (m *serverMetrics) received(id simconnect.DWORD) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[id]++
+}
+
+func (m *serverMetrics) exception(code simconnect.DWORD) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exceptions[code]++
+}
+
+func (m *serverMetrics) connected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connects++
+}
+
+// metricsWriter renders the Prometheus text format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *metricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes one value; labels are name, value pairs.
+func (w *metricsWriter) sample(name string, v float64, labels ...string) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&w.buf, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	w.buf.WriteByte('\n')
+}
+
+func (w *metricsWriter) metric(name, typ, help string, v float64) {
+	w.header(name, typ, help)
+	w.sample(name, v)
+}
+
+func (w *metricsWriter) histogram(name, help string, h histogram) {
+	w.header(name, "histogram", help)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		w.sample(name+"_bucket", float64(cumulative), "le", strconv.FormatFloat(bound, 'g', -1, 64))
+	}
+	w.sample(name+"_bucket", float64(h.count), "le", "+Inf")
+	w.sample(name+"_sum", h.sum)
+	w.sample(name+"_count", float64(h.count))
+}
+
+// counts writes one sample per code, labelled with its name from names.
+func (w *metricsWriter) counts(name, typ, help, label string, counts map[simconnect.DWORD]uint64, names map[simconnect.DWORD]string) {
+	w.header(name, typ, help)
+	codes := make([]simconnect.DWORD, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	for _, code := range codes {
+		codeName, ok := names[code]
+		if !ok {
+			codeName = strconv.FormatUint(uint64(code), 10)
+		}
+		w.sample(name, float64(counts[code]), label, codeName)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// metricsHandler serves the counters in the Prometheus text format.
+func (b *Bridge) metricsHandler() http.HandlerFunc {
+	ws, peers, subs, metrics := b.ws, b.peers, b.subs, b.metrics
+	return func(w http.ResponseWriter, r *http.Request) {
+		out := &metricsWriter{}
+
+		metrics.mu.Lock()
+		dispatch := metrics.dispatch
+		dispatch.counts = append([]uint64(nil), dispatch.counts...)
+		messages := make(map[simconnect.DWORD]uint64, len(metrics.messages))
+		for id, n := range metrics.messages {
+			messages[id] = n
+		}
+		exceptions := make(map[simconnect.DWORD]uint64, len(metrics.exceptions))
+		for code, n := range metrics.exceptions {
+			exceptions[code] = n
+		}
+		connects := metrics.connects
+		metrics.mu.Unlock()
+
+		connected := 0.0
+		if b.sim.isConnected() {
+			connected = 1
+		}
+		out.metric("simconnect_ws_simconnect_connected", "gauge", "Whether a simulator is connected.", connected)
+		out.metric("simconnect_ws_simconnect_connects_total", "counter", "SimConnect sessions opened; more than one means the sim reconnected.", float64(connects))
+		out.histogram("simconnect_ws_dispatch_seconds", "Time from a dispatch tick until the SimConnect queue was drained.", dispatch)
+		out.counts("simconnect_ws_simconnect_messages_total", "counter", "SimConnect messages received by RECV_ID.", "id", messages, simconnect.RecvIDNames)
+		out.counts("simconnect_ws_simconnect_exceptions_total", "counter", "SimConnect exceptions by code.", "exception", exceptions, simconnect.ExceptionNames)
+
+		stats := ws.Stats()
+		out.metric("simconnect_ws_broadcasts_total", "counter", "Broadcasts fanned out to the websocket connections.", float64(stats.Broadcasts))
+		out.metric("simconnect_ws_broadcast_seconds_total", "counter", "Time spent fanning out broadcasts.", stats.BroadcastTime.Seconds())
+		out.metric("simconnect_ws_sent_bytes_total", "counter", "Websocket payload bytes sent, closed connections included.", float64(stats.BytesSent))
+
+		conns := ws.Connections()
+		sort.Slice(conns, func(i, j int) bool { return conns[i].ID() < conns[j].ID() })
+		out.metric("simconnect_ws_connections", "gauge", "Open websocket connections.", float64(len(conns)))
+
+		type connSample struct {
+			labels []string
+			stats  websockets.ConnectionStats
+		}
+		samples := make([]connSample, len(conns))
+		for i, c := range conns {
+			samples[i] = connSample{
+				labels: []string{"conn", strconv.FormatUint(c.ID(), 10), "client", peers.get(c).client, "remote", c.RemoteAddr()},
+				stats:  c.Stats(),
+			}
+		}
+		perConn := func(name, typ, help string, value func(websockets.ConnectionStats) float64) {
+			out.header(name, typ, help)
+			for _, s := range samples {
+				out.sample(name, value(s.stats), s.labels...)
+			}
+		}
+		perConn("simconnect_ws_connection_queued_frames", "gauge", "Frames waiting to be written to a connection.",
+			func(s websockets.ConnectionStats) float64 { return float64(s.Queued) })
+		perConn("simconnect_ws_connection_dropped_frames_total", "counter", "Frames a lagging connection skipped or was refused.",
+			func(s websockets.ConnectionStats) float64 { return float64(s.Dropped) })
+		perConn("simconnect_ws_connection_sent_frames_total", "counter", "Frames written to a connection.",
+			func(s websockets.ConnectionStats) float64 { return float64(s.FramesSent) })
+		perConn("simconnect_ws_connection_sent_bytes_total", "counter", "Payload bytes written to a connection.",
+			func(s websockets.ConnectionStats) float64 { return float64(s.BytesSent) })
+
+		groups := subs.list()
+		subscribers := 0
+		for _, g := range groups {
+			subscribers += g.Connections
+		}
+		out.metric("simconnect_ws_subscriptions", "gauge", "Active simvar subscription groups.", float64(len(groups)))
+		out.metric("simconnect_ws_subscribers", "gauge", "Connections summed over the subscription groups.", float64(subscribers))
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		w.Write(out.buf.Bytes())
+	}
+}
@@ -0,0 +1,141 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Packet is one broadcast, in the payload forms clients can ask for.
+type Packet struct {
+	Type string
+	// Stream is set for packets that carry the latest state of a stream,
+	// like "plane", which a lagging output may skip to the newest of.
+	Stream string
+	// Legacy is the v0 payload and Typed the typed-telemetry one; packets
+	// that aren't telemetry have the same payload in both.
+	Legacy map[string]interface{}
+	Typed  map[string]interface{}
+	Time   time.Time
+}
+
+// Output gets every packet the bridge broadcasts, besides the websocket
+// hub, which renders packets per connection. SSE is an Output, and UDP
+// protocols or recorders can be added with Bridge.AddOutput.
+type Output interface {
+	// Publish is called on the bridge goroutine and must not block.
+	Publish(p Packet)
+}
+
+// PacketWriter is an Output that writes each packet as a line of JSON with
+// its time, type and typed payload.
+type PacketWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+func NewPacketWriter(w io.Writer) *PacketWriter {
+	return &PacketWriter{w: w}
+}
+
+func (pw *PacketWriter) Publish(p Packet) {
+	payload := p.Typed
+	if payload == nil {
+		payload = p.Legacy
+	}
+	buf, err := json.Marshal(struct {
+		Time    time.Time              `json:"time"`
+		Type    string                 `json:"type"`
+		Payload map[string]interface{} `json:"payload"`
+	}{p.Time.UTC(), p.Type, payload})
+	if err != nil {
+		return
+	}
+
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if pw.err != nil {
+		return
+	}
+	if _, err := pw.w.Write(append(buf, '\n')); err != nil {
+		pw.err = err
+	}
+}
+
+// Err returns the first error writing failed with; packets after it are
+// dropped.
+func (pw *PacketWriter) Err() error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	return pw.err
+}
+
+// outputConfig is one entry of "outputs" in the config file: a "file" that
+// gets a line of JSON per packet, relative to the executable's directory,
+// or a "udp" address that gets a datagram per packet.
+type outputConfig struct {
+	Type    string `json:"type"`
+	Path    string `json:"path,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+func (o outputConfig) check() error {
+	switch o.Type {
+	case "file":
+		if o.Path == "" {
+			return fmt.Errorf("file output needs a path")
+		}
+	case "udp":
+		if o.Address == "" {
+			return fmt.Errorf("udp output needs an address")
+		}
+	default:
+		return fmt.Errorf("unknown output type %q, use file or udp", o.Type)
+	}
+	return nil
+}
+
+// open starts the output; the closer ends it.
+func (o outputConfig) open() (Output, io.Closer, error) {
+	switch o.Type {
+	case "file":
+		path := o.Path
+		if !filepath.IsAbs(path) {
+			exe, err := os.Executable()
+			if err != nil {
+				return nil, nil, fmt.Errorf("locate executable: %w", err)
+			}
+			path = filepath.Join(filepath.Dir(exe), path)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open output: %w", err)
+		}
+		return NewPacketWriter(f), f, nil
+
+	case "udp":
+		conn, err := net.Dial("udp", o.Address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open output: %w", err)
+		}
+		return NewPacketWriter(datagramWriter{conn}), conn, nil
+	}
+	return nil, nil, o.check()
+}
+
+// datagramWriter sends each write as a datagram and ignores failures, so a
+// receiver that isn't listening yet doesn't end the output.
+type datagramWriter struct {
+	conn net.Conn
+}
+
+func (d datagramWriter) Write(b []byte) (int, error) {
+	d.conn.Write(b)
+	return len(b), nil
+}
@@ -0,0 +1,341 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect-ws/codec"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+// protocolVersion is the newest envelope version the server speaks.
+//
+// v0 is the original protocol: packets are flat JSON objects with a "type"
+// field and no replies. v1 wraps every packet in an envelope
+//
+//	{"v": 1, "id": "...", "type": "...", "payload": {...}}
+//
+// and answers each request with an "ack" or "error" carrying the same id.
+// A connection switches to v1 by sending a v1 "hello", which can also pick
+// a binary encoding for the packets the server sends.
+const protocolVersion = 1
+
+// droppedReportInterval is how often lagging connections are told how many
+// stream updates they missed.
+const droppedReportInterval = 5 * time.Second
+
+// serverCaps are the optional features a client can ask for in its hello.
+var serverCaps = []string{"subscribe", "teleport", capTypedTelemetry, capDelta}
+
+// envelope is an outbound v1 packet.
+type envelope struct {
+	V       int         `json:"v"`
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+// protocolError is the payload of an "error" reply.
+type protocolError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *protocolError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// error codes
+const (
+	errBadRequest         = "bad_request"
+	errUnknownType        = "unknown_type"
+	errUnsupportedVersion = "unsupported_version"
+	errForbidden          = "forbidden"
+	errUnauthorized       = "unauthorized"
+	errNotFound           = "not_found"
+	errSimUnavailable     = "sim_unavailable"
+	errRateLimited        = "rate_limited"
+)
+
+func newProtocolError(code, format string, args ...interface{}) *protocolError {
+	return &protocolError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// helloRequest is the payload of a client "hello".
+type helloRequest struct {
+	Client   string   `json:"client"`
+	Caps     []string `json:"caps"`
+	Encoding string   `json:"encoding"`
+}
+
+// helloReply is the payload of the server's "hello".
+type helloReply struct {
+	Server    string   `json:"server"`
+	Version   string   `json:"version"`
+	Protocol  []int    `json:"protocol"`
+	Caps      []string `json:"caps"`
+	Encoding  string   `json:"encoding"`
+	Encodings []string `json:"encodings"`
+}
+
+// peer is the protocol state of one connection.
+type peer struct {
+	version int
+	client  string
+	caps    map[string]bool
+	codec   codec.Codec // nil means JSON
+
+	// the next plane packet must be a full snapshot
+	needSnapshot bool
+}
+
+func (pe peer) encoding() codec.Codec {
+	if pe.codec == nil {
+		return codec.JSON
+	}
+	return pe.codec
+}
+
+// frame renders a packet the way pe expects it. v0 packets are the payload
+// with its type added, v1 packets are envelopes.
+func (pe peer) frame(id, typ string, payload interface{}) websockets.Frame {
+	var pkt interface{}
+	if pe.version == 0 {
+		flat := map[string]interface{}{}
+		if m, ok := payload.(map[string]interface{}); ok {
+			for k, v := range m {
+				flat[k] = v
+			}
+		}
+		flat["type"] = typ
+		pkt = flat
+	} else {
+		pkt = envelope{V: protocolVersion, ID: id, Type: typ, Payload: payload}
+	}
+
+	enc := pe.encoding()
+	buf, err := enc.Marshal(pkt)
+	if err != nil {
+		wsLog.Warn("encoding packet", "type", typ, "encoding", enc.Name(), "err", err)
+		return websockets.Frame{}
+	}
+	return websockets.Frame{Binary: enc.Binary(), Data: buf}
+}
+
+// peers holds the protocol state of every connection that said hello.
+type peers struct {
+	mu sync.Mutex
+	m  map[*websockets.Connection]*peer
+
+	// every broadcast also goes to the outputs, the first of which keeps
+	// them for SSE clients
+	events  *eventLog
+	outputs []Output
+}
+
+func newPeers() *peers {
+	events := newEventLog(eventLogSize)
+	return &peers{
+		m:       map[*websockets.Connection]*peer{},
+		events:  events,
+		outputs: []Output{events},
+	}
+}
+
+// get returns the state of c; connections that never said hello speak v0.
+func (p *peers) get(c *websockets.Connection) peer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pe, ok := p.m[c]; ok {
+		return *pe
+	}
+	return peer{}
+}
+
+// hello switches c to v1 with the encoding and the capabilities in req that
+// the server supports, and returns those capabilities.
+func (p *peers) hello(c *websockets.Connection, req helloRequest) ([]string, error) {
+	pe := &peer{version: protocolVersion, client: req.Client, caps: map[string]bool{}}
+
+	if req.Encoding != "" {
+		enc, ok := codec.Lookup(req.Encoding)
+		if !ok {
+			return nil, newProtocolError(errBadRequest, "unknown encoding %q, use one of %s", req.Encoding, strings.Join(codec.Names(), ", "))
+		}
+		pe.codec = enc
+	}
+
+	var accepted []string
+	for _, want := range req.Caps {
+		for _, have := range serverCaps {
+			if want == have && !pe.caps[want] {
+				pe.caps[want] = true
+				accepted = append(accepted, want)
+			}
+		}
+	}
+
+	pe.needSnapshot = pe.caps[capDelta]
+
+	p.mu.Lock()
+	p.m[c] = pe
+	p.mu.Unlock()
+
+	return accepted, nil
+}
+
+// resync makes the next plane packet to c a full snapshot.
+func (p *peers) resync(c *websockets.Connection) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pe, ok := p.m[c]; ok {
+		pe.needSnapshot = true
+	}
+}
+
+// takeSnapshot reports whether c needs a snapshot and clears the request.
+func (p *peers) takeSnapshot(c *websockets.Connection) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pe, ok := p.m[c]
+	if !ok || !pe.needSnapshot {
+		return false
+	}
+	pe.needSnapshot = false
+	return true
+}
+
+// Drop forgets c.
+func (p *peers) Drop(c *websockets.Connection) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.m, c)
+}
+
+// encode renders a server packet for c in the protocol version and encoding
+// it speaks.
+func (p *peers) encode(c *websockets.Connection, typ string, payload map[string]interface{}) websockets.Frame {
+	return p.get(c).frame("", typ, payload)
+}
+
+// broadcast sends the event typ with payload to every connection in its own
+// protocol.
+func (p *peers) broadcast(ws *websockets.Websocket, typ string, payload map[string]interface{}) {
+	p.broadcastFunc(ws, "", typ, func(*websockets.Connection, peer) (string, map[string]interface{}) {
+		return "", payload
+	})
+}
+
+// broadcastFunc sends typ to every connection with the payload pick returns
+// for it. Payloads are told apart by the variant name pick returns with them
+// and each is encoded once per protocol version and encoding; connections
+// that share both get the same bytes. A nil payload skips the connection.
+// Packets of a non-empty stream only carry the latest state, so a lagging
+// connection skips to the newest one, except for sequencedVariants.
+func (p *peers) broadcastFunc(ws *websockets.Websocket, stream, typ string, pick func(c *websockets.Connection, pe peer) (variant string, payload map[string]interface{})) {
+	_, legacy := pick(nil, peer{})
+	_, typed := pick(nil, peer{version: protocolVersion, caps: map[string]bool{capTypedTelemetry: true}})
+	pkt := Packet{Type: typ, Stream: stream, Legacy: legacy, Typed: typed, Time: time.Now()}
+	for _, o := range p.outputs {
+		o.Publish(pkt)
+	}
+
+	encoded := map[string]websockets.Frame{}
+	ws.BroadcastFunc(func(c *websockets.Connection) websockets.Frame {
+		pe := p.get(c)
+		variant, payload := pick(c, pe)
+		if payload == nil {
+			return websockets.Frame{}
+		}
+
+		key := fmt.Sprintf("%d/%s/%s", pe.version, pe.encoding().Name(), variant)
+		f, ok := encoded[key]
+		if !ok {
+			f = pe.frame("", typ, payload)
+			if !sequencedVariants[variant] {
+				f.Stream = stream
+			}
+			encoded[key] = f
+		}
+		return f
+	})
+}
+
+// reportDropped tells every connection that missed stream updates how many
+// it missed, once per interval, until ctx ends.
+func (p *peers) reportDropped(ctx context.Context, ws *websockets.Websocket, interval time.Duration) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return
+		}
+		ws.BroadcastFunc(func(c *websockets.Connection) websockets.Frame {
+			dropped := c.TakeDropped()
+			if len(dropped) == 0 {
+				return websockets.Frame{}
+			}
+			c.Logger().Debug("connection dropped frames", "streams", dropped)
+			return p.encode(c, "dropped", map[string]interface{}{"streams": dropped})
+		})
+	}
+}
+
+// reply sends a v1 reply to the request with the given id in the encoding
+// pe had when the request arrived.
+func reply(c *websockets.Connection, pe peer, id, typ string, payload interface{}) {
+	pe.version = protocolVersion
+	if f := pe.frame(id, typ, payload); f.Data != nil {
+		c.TrySend(f)
+	}
+}
+
+// decodePacket splits an inbound packet into its version, id, type and
+// payload. A v0 packet is its own payload.
+func decodePacket(message []byte) (version int, id, typ string, payload json.RawMessage, err error) {
+	var pkt struct {
+		V       *int            `json:"v"`
+		ID      string          `json:"id"`
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(message, &pkt); err != nil {
+		return 0, "", "", nil, newProtocolError(errBadRequest, "invalid JSON: %s", err)
+	}
+
+	if pkt.V == nil || *pkt.V == 0 {
+		if pkt.Type == "" {
+			return 0, "", "", nil, newProtocolError(errBadRequest, "missing type")
+		}
+		return 0, "", pkt.Type, json.RawMessage(message), nil
+	}
+
+	if *pkt.V > protocolVersion || *pkt.V < 0 {
+		return *pkt.V, pkt.ID, pkt.Type, nil, newProtocolError(errUnsupportedVersion, "protocol version %d not supported, use at most %d", *pkt.V, protocolVersion)
+	}
+	if pkt.Type == "" {
+		return *pkt.V, pkt.ID, "", nil, newProtocolError(errBadRequest, "missing type")
+	}
+	if len(pkt.Payload) == 0 {
+		pkt.Payload = json.RawMessage("{}")
+	}
+	return *pkt.V, pkt.ID, pkt.Type, pkt.Payload, nil
+}
+
+// decodePayload unmarshals a request payload into v.
+func decodePayload(payload json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return newProtocolError(errBadRequest, "invalid payload: %s", err)
+	}
+	return nil
+}
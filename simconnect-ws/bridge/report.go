@@ -0,0 +1,94 @@
+package bridge
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+type Report struct {
+	simconnect.RecvSimobjectDataByType
+	Title         [256]byte `name:"TITLE"`
+	Altitude      float64   `name:"INDICATED ALTITUDE" unit:"feet" json:"altitude"` // PLANE ALTITUDE or PLANE ALT ABOVE GROUND
+	Latitude      float64   `name:"PLANE LATITUDE" unit:"degrees" json:"latitude"`
+	Longitude     float64   `name:"PLANE LONGITUDE" unit:"degrees" json:"longitude"`
+	Heading       float64   `name:"PLANE HEADING DEGREES TRUE" unit:"degrees" json:"heading"`
+	GroundCourse  float64   `name:"GPS GROUND TRUE TRACK" unit:"degrees" json:"ground_course"`
+	GroundHeading float64   `name:"GPS GROUND TRUE HEADING" unit:"degrees" json:"ground_heading"`
+	GroundSpeed   float64   `name:"GPS GROUND SPEED" unit:"knot" json:"ground_speed"`
+	Airspeed      float64   `name:"AIRSPEED INDICATED" unit:"knot" json:"airspeed"`
+	AirspeedTrue  float64   `name:"AIRSPEED TRUE" unit:"knot" json:"airspeed_true"`
+	VerticalSpeed float64   `name:"VERTICAL SPEED" unit:"ft/min" json:"vertical_speed"`
+	Flaps         float64   `name:"TRAILING EDGE FLAPS LEFT ANGLE" unit:"degrees" json:"flaps"`
+	Trim          float64   `name:"ELEVATOR TRIM PCT" unit:"percent" json:"trim"`
+	RudderTrim    float64   `name:"RUDDER TRIM PCT" unit:"percent" json:"rudder_trim"`
+	SimTime       float64   `name:"ABSOLUTE TIME" unit:"seconds"`
+}
+
+func (r *Report) RequestData(s *simconnect.SimConnect) {
+	defineID := s.GetDefineID(r)
+	requestID := defineID
+	s.RequestDataOnSimObjectType(requestID, defineID, 0, simconnect.SIMOBJECT_TYPE_USER)
+}
+
+type TrafficReport struct {
+	simconnect.RecvSimobjectDataByType
+	AtcID           [64]byte `name:"ATC ID"`
+	AtcFlightNumber [8]byte  `name:"ATC FLIGHT NUMBER"`
+	Altitude        float64  `name:"PLANE ALTITUDE" unit:"feet"`
+	Latitude        float64  `name:"PLANE LATITUDE" unit:"degrees"`
+	Longitude       float64  `name:"PLANE LONGITUDE" unit:"degrees"`
+	Heading         float64  `name:"PLANE HEADING DEGREES TRUE" unit:"degrees"`
+	GroundSpeed     float64  `name:"GROUND VELOCITY" unit:"knots"`
+	OnGround        float64  `name:"SIM ON GROUND" unit:"bool"`
+	IsUser          float64  `name:"IS USER SIM" unit:"bool"`
+}
+
+func (r *TrafficReport) RequestData(s *simconnect.SimConnect, radius simconnect.DWORD) {
+	defineID := s.GetDefineID(r)
+	requestID := defineID
+	s.RequestDataOnSimObjectType(requestID, defineID, radius, simconnect.SIMOBJECT_TYPE_AIRCRAFT)
+}
+
+// Aircraft returns r as traffic, or nil for the user's own aircraft.
+func (r *TrafficReport) Aircraft() *trafficAircraft {
+	if r.IsUser != 0 {
+		return nil
+	}
+	return &trafficAircraft{
+		ID:           r.ObjectID,
+		AtcID:        cString(r.AtcID[:]),
+		FlightNumber: cString(r.AtcFlightNumber[:]),
+		Latitude:     r.Latitude,
+		Longitude:    r.Longitude,
+		Altitude:     r.Altitude,
+		Heading:      r.Heading,
+		GroundSpeed:  r.GroundSpeed,
+		OnGround:     r.OnGround != 0,
+	}
+}
+
+func (r *TrafficReport) Inspect() string {
+	return fmt.Sprintf(
+		"%s GPS %.6f %.6f @ %.0f feet %.0f°",
+		r.AtcID,
+		r.Latitude,
+		r.Longitude,
+		r.Altitude,
+		r.Heading,
+	)
+}
+
+type TeleportRequest struct {
+	simconnect.RecvSimobjectDataByType
+	Position simconnect.DataInitPosition `name:"Initial Position"`
+}
+
+func (r *TeleportRequest) SetData(s *simconnect.SimConnect) error {
+	defineID := s.GetDefineID(r)
+
+	buf, _ := r.Position.MarshalBinary()
+	size := simconnect.DWORD(len(buf))
+	return s.SetDataOnSimObject(defineID, simconnect.OBJECT_ID_USER, 0, 0, size, unsafe.Pointer(&buf[0]))
+}
@@ -0,0 +1,223 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+	"github.com/kivle/msfs2020-go/simconnect-ws/codec"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+// request is an inbound packet, decoded from either protocol version.
+type request struct {
+	conn    *websockets.Connection
+	version int
+	id      string
+	typ     string
+	payload json.RawMessage
+	client  Client
+
+	s *simconnect.SimConnect
+	b *Bridge
+
+	// respond sends the reply; handlers that return errDeferred call it
+	// themselves once they are done
+	respond func(replyType string, result interface{}, err error)
+}
+
+// requestHandler handles one request type. It returns the reply type and
+// payload for v1 clients; v0 clients only get what the handler sends itself.
+type requestHandler func(r *request) (replyType string, result interface{}, err error)
+
+// errDeferred is returned by handlers that reply later through r.respond.
+var errDeferred = errors.New("reply deferred")
+
+// requestHandlers are the built-in handlers; each Bridge starts with a copy.
+var requestHandlers = map[string]requestHandler{
+	"hello":       handleHello,
+	"teleport":    handleTeleport,
+	"subscribe":   handleSubscribe,
+	"unsubscribe": handleUnsubscribe,
+	"resync":      handleResync,
+	"snapshot":    handleSnapshot,
+	"event":       handleEvent,
+}
+
+// simRequests are the request types that need a simulator; while none is
+// connected they fail with sim_unavailable.
+var simRequests = map[string]bool{
+	"teleport": true,
+	"event":    true,
+}
+
+// dispatch runs the handler for r once r is allowed and can be served.
+func (b *Bridge) dispatch(r *request) (string, interface{}, error) {
+	handler, ok := b.handlers[r.typ]
+	if !ok {
+		return "", nil, newProtocolError(errUnknownType, "unknown type %q", r.typ)
+	}
+	if err := b.authorize(r); err != nil {
+		return "", nil, err
+	}
+	if r.s == nil && simRequests[r.typ] {
+		return "", nil, newProtocolError(errSimUnavailable, "not connected to the simulator")
+	}
+	return handler(r)
+}
+
+// handleClientMessage answers a websocket packet. s is nil while no
+// simulator is connected.
+func (b *Bridge) handleClientMessage(m websockets.ReceiveMessage, s *simconnect.SimConnect) {
+	version, id, typ, payload, err := decodePacket(m.Message)
+
+	// replies go out in the encoding the request was sent under, so the
+	// reply to a hello switching encodings is still readable as JSON
+	pe := b.peers.get(m.Connection)
+
+	r := &request{
+		conn:    m.Connection,
+		version: version,
+		id:      id,
+		typ:     typ,
+		payload: payload,
+		s:       s,
+		b:       b,
+		client:  clientFromContext(m.Connection.Context()),
+	}
+	r.respond = func(replyType string, result interface{}, err error) {
+		if err != nil {
+			// v0 clients get no error reply, so this is all there is
+			l := r.conn.Logger().With("type", typ, "id", id)
+			if version == 0 {
+				l.Warn("invalid websocket packet", "err", err)
+			} else {
+				l.Debug("request failed", "err", err)
+			}
+			if version > 0 {
+				perr, ok := err.(*protocolError)
+				if !ok {
+					perr = newProtocolError("internal", "%s", err)
+				}
+				reply(r.conn, pe, id, "error", perr)
+			}
+			return
+		}
+
+		b.audit.record(r, "websocket")
+		if version > 0 {
+			reply(r.conn, pe, id, replyType, result)
+		}
+	}
+
+	replyType, result := "", interface{}(nil)
+	if err == nil {
+		replyType, result, err = b.dispatch(r)
+	}
+	if err != errDeferred {
+		r.respond(replyType, result, err)
+	}
+}
+
+func handleHello(r *request) (string, interface{}, error) {
+	if r.version == 0 {
+		return "", nil, newProtocolError(errUnsupportedVersion, "hello needs protocol version 1")
+	}
+
+	var req helloRequest
+	if err := decodePayload(r.payload, &req); err != nil {
+		return "", nil, err
+	}
+
+	caps, err := r.b.peers.hello(r.conn, req)
+	if err != nil {
+		return "", nil, err
+	}
+	if caps == nil {
+		caps = []string{}
+	}
+
+	return "hello", helloReply{
+		Server:    "simconnect-ws",
+		Version:   r.b.opts.Version,
+		Protocol:  []int{0, protocolVersion},
+		Caps:      caps,
+		Encoding:  r.b.peers.get(r.conn).encoding().Name(),
+		Encodings: codec.Names(),
+	}, nil
+}
+
+// subscribeRequest is the payload of a "subscribe" packet.
+type subscribeRequest struct {
+	Vars   []simvar `json:"vars"`
+	RateMs int      `json:"rate_ms"`
+}
+
+func handleSubscribe(r *request) (string, interface{}, error) {
+	if !r.b.settings.streamEnabled("subscriptions") {
+		return "", nil, newProtocolError(errForbidden, "subscriptions are disabled")
+	}
+
+	var req subscribeRequest
+	if err := decodePayload(r.payload, &req); err != nil {
+		return "", nil, err
+	}
+
+	g, err := r.b.subs.Subscribe(r.conn, req.Vars, time.Duration(req.RateMs)*time.Millisecond)
+	if err != nil {
+		return "", nil, newProtocolError(errBadRequest, "%s", err)
+	}
+
+	result := map[string]interface{}{
+		"id":      g.id,
+		"vars":    g.vars,
+		"rate_ms": g.rate.Milliseconds(),
+	}
+	if r.version == 0 {
+		r.conn.TrySend(r.b.peers.encode(r.conn, "subscribed", result))
+	}
+	return "ack", result, nil
+}
+
+func handleUnsubscribe(r *request) (string, interface{}, error) {
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := decodePayload(r.payload, &req); err != nil {
+		return "", nil, err
+	}
+
+	if !r.b.subs.Unsubscribe(r.conn, req.ID) {
+		return "", nil, newProtocolError(errNotFound, "no subscription %q", req.ID)
+	}
+	return "ack", struct{}{}, nil
+}
+
+func handleResync(r *request) (string, interface{}, error) {
+	if !r.b.peers.get(r.conn).caps[capDelta] {
+		return "", nil, newProtocolError(errBadRequest, "resync needs the %s capability", capDelta)
+	}
+	r.b.peers.resync(r.conn)
+	return "ack", struct{}{}, nil
+}
+
+// snapshotRequest is the payload of a "snapshot" packet. Since limits the
+// track to points after that time, in ms since the unix epoch.
+type snapshotRequest struct {
+	Since int64 `json:"since"`
+}
+
+func handleSnapshot(r *request) (string, interface{}, error) {
+	var req snapshotRequest
+	if err := decodePayload(r.payload, &req); err != nil {
+		return "", nil, err
+	}
+
+	since := time.Unix(0, req.Since*int64(time.Millisecond))
+	result := r.b.hist.snapshot(r.b.peers.get(r.conn), since, time.Now())
+	if r.version == 0 {
+		r.conn.TrySend(r.b.peers.encode(r.conn, "snapshot", result))
+	}
+	return "snapshot", result, nil
+}
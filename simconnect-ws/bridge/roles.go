@@ -0,0 +1,274 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Role is what a client may do. Each role can do everything the ones below
+// it can.
+type Role int
+
+const (
+	// RoleViewer reads telemetry, traffic and events.
+	RoleViewer Role = iota
+	// RoleCopilot also sends the cockpit sim events, like switches and radios.
+	RoleCopilot
+	// RoleInstructor also teleports and sends any other sim event.
+	RoleInstructor
+)
+
+var roleNames = []string{"viewer", "copilot", "instructor"}
+
+func (r Role) String() string {
+	if r < 0 || int(r) >= len(roleNames) {
+		return "unknown"
+	}
+	return roleNames[r]
+}
+
+func parseRole(s string) (Role, error) {
+	for i, name := range roleNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return Role(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown role %q, use one of %s", s, strings.Join(roleNames, ", "))
+}
+
+// roleFlag is a flag.Value that takes a role name.
+type roleFlag struct{ r *Role }
+
+func (f roleFlag) String() string {
+	if f.r == nil {
+		return ""
+	}
+	return f.r.String()
+}
+
+func (f roleFlag) Set(s string) error {
+	r, err := parseRole(s)
+	if err != nil {
+		return err
+	}
+	*f.r = r
+	return nil
+}
+
+// originRoleFlag is a flag.Value that takes comma separated origin=role
+// pairs.
+type originRoleFlag map[string]Role
+
+func (f originRoleFlag) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + f[k].String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f originRoleFlag) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		i := strings.LastIndex(part, "=")
+		if i <= 0 {
+			return fmt.Errorf("expected origin=role, got %q", part)
+		}
+		r, err := parseRole(part[i+1:])
+		if err != nil {
+			return err
+		}
+		f[strings.ToLower(strings.TrimRight(strings.TrimSpace(part[:i]), "/"))] = r
+	}
+	return nil
+}
+
+// requestRoles is the role each built-in request type needs. Types not
+// listed are left to their handler.
+var requestRoles = map[string]Role{
+	"hello":       RoleViewer,
+	"subscribe":   RoleViewer,
+	"unsubscribe": RoleViewer,
+	"resync":      RoleViewer,
+	"snapshot":    RoleViewer,
+	"event":       RoleCopilot,
+	"teleport":    RoleInstructor,
+}
+
+// controlRequests are the request types that change the sim; accepted ones
+// go to the audit log.
+var controlRequests = map[string]bool{
+	"event":    true,
+	"teleport": true,
+}
+
+// defaultCopilotEvents are the sim events a copilot may send: switches,
+// radios, autopilot and the like. Every other event needs an instructor, so
+// events the list doesn't know stay with the instructor. -copilot-events
+// adds more.
+var defaultCopilotEvents = []string{
+	// lights
+	"ALL_LIGHTS_TOGGLE", "LANDING_LIGHTS_TOGGLE", "LANDING_LIGHTS_ON", "LANDING_LIGHTS_OFF",
+	"TOGGLE_BEACON_LIGHTS", "TOGGLE_TAXI_LIGHTS", "TOGGLE_NAV_LIGHTS", "TOGGLE_LOGO_LIGHTS",
+	"TOGGLE_WING_LIGHTS", "TOGGLE_CABIN_LIGHTS", "TOGGLE_RECOGNITION_LIGHTS",
+	"STROBES_TOGGLE", "STROBES_ON", "STROBES_OFF", "PANEL_LIGHTS_TOGGLE",
+	// gear, brakes, flaps, spoilers
+	"GEAR_TOGGLE", "GEAR_UP", "GEAR_DOWN", "PARKING_BRAKES", "BRAKES",
+	"FLAPS_UP", "FLAPS_DOWN", "FLAPS_INCR", "FLAPS_DECR", "FLAPS_SET",
+	"SPOILERS_TOGGLE", "SPOILERS_ON", "SPOILERS_OFF", "SPOILERS_ARM_TOGGLE",
+	// trim
+	"ELEV_TRIM_UP", "ELEV_TRIM_DN", "ELEVATOR_TRIM_SET",
+	"AILERON_TRIM_LEFT", "AILERON_TRIM_RIGHT", "RUDDER_TRIM_LEFT", "RUDDER_TRIM_RIGHT",
+	// radios and transponder
+	"COM_RADIO_SET", "COM_RADIO_SET_HZ", "COM_STBY_RADIO_SET", "COM_STBY_RADIO_SET_HZ", "COM_STBY_RADIO_SWAP",
+	"COM2_RADIO_SET", "COM2_RADIO_SET_HZ", "COM2_STBY_RADIO_SET", "COM2_STBY_RADIO_SET_HZ", "COM2_RADIO_SWAP",
+	"NAV1_RADIO_SET", "NAV1_RADIO_SET_HZ", "NAV1_STBY_SET", "NAV1_STBY_SET_HZ", "NAV1_RADIO_SWAP",
+	"NAV2_RADIO_SET", "NAV2_RADIO_SET_HZ", "NAV2_STBY_SET", "NAV2_STBY_SET_HZ", "NAV2_RADIO_SWAP",
+	"ADF_COMPLETE_SET", "ADF1_RADIO_SWAP", "XPNDR_SET", "XPNDR_IDENT_ON",
+	"KOHLSMAN_SET", "KOHLSMAN_INC", "KOHLSMAN_DEC", "BAROMETRIC",
+	// autopilot
+	"AP_MASTER", "AUTOPILOT_ON", "AUTOPILOT_OFF", "AP_HDG_HOLD", "AP_ALT_HOLD", "AP_NAV1_HOLD",
+	"AP_APR_HOLD", "AP_BC_HOLD", "AP_VS_HOLD", "AP_AIRSPEED_HOLD", "AP_PANEL_HEADING_HOLD",
+	"AP_PANEL_ALTITUDE_HOLD", "AP_ALT_VAR_SET_ENGLISH", "AP_ALT_VAR_INC", "AP_ALT_VAR_DEC",
+	"AP_VS_VAR_SET_ENGLISH", "AP_VS_VAR_INC", "AP_VS_VAR_DEC", "AP_SPD_VAR_SET",
+	"HEADING_BUG_SET", "HEADING_BUG_INC", "HEADING_BUG_DEC", "VOR1_SET", "VOR2_SET",
+	"YAW_DAMPER_TOGGLE", "FLIGHT_LEVEL_CHANGE", "AUTO_THROTTLE_ARM", "TOGGLE_FLIGHT_DIRECTOR",
+	"TOGGLE_GPS_DRIVES_NAV1",
+	// systems
+	"TOGGLE_MASTER_BATTERY", "TOGGLE_MASTER_ALTERNATOR", "TOGGLE_AVIONICS_MASTER",
+	"TOGGLE_ELECT_FUEL_PUMP", "PITOT_HEAT_TOGGLE", "ANTI_ICE_TOGGLE",
+	"CABIN_SEATBELTS_ALERT_SWITCH_TOGGLE",
+}
+
+// eventSet is a set of sim event names, kept upper case. As a flag.Value it
+// takes comma separated names, which it adds.
+type eventSet map[string]bool
+
+func (s eventSet) add(names ...string) {
+	for _, name := range names {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			s[name] = true
+		}
+	}
+}
+
+func (s eventSet) String() string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (s eventSet) Set(v string) error {
+	s.add(strings.Split(v, ",")...)
+	return nil
+}
+
+// authorize checks that the client of r may send it.
+func (b *Bridge) authorize(r *request) error {
+	need, ok := b.roles[r.typ]
+	if !ok || r.client.Role >= need {
+		return nil
+	}
+	return newProtocolError(errForbidden, "%s needs the %s role, %s has %s", r.typ, need, r.client.Name, r.client.Role)
+}
+
+// auditLog appends one JSON line per accepted control command.
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// open starts logging to path, relative to the executable's directory.
+func (l *auditLog) open(path string) error {
+	if path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(path) {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locate executable: %w", err)
+		}
+		path = filepath.Join(filepath.Dir(exe), path)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.file = f
+	return nil
+}
+
+// close stops logging.
+func (l *auditLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// auditEntry is one line of the audit log.
+type auditEntry struct {
+	Time    time.Time       `json:"time"`
+	Client  string          `json:"client"`
+	Name    string          `json:"name,omitempty"`
+	Role    string          `json:"role"`
+	Remote  string          `json:"remote,omitempty"`
+	Via     string          `json:"via"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// record logs r if it is a control command.
+func (l *auditLog) record(r *request, via string) {
+	if !r.b.controls[r.typ] {
+		return
+	}
+
+	payload := r.payload
+	if !json.Valid(payload) {
+		payload = json.RawMessage("null")
+	}
+	buf, err := json.Marshal(auditEntry{
+		Time:    time.Now().UTC(),
+		Client:  r.client.ID,
+		Name:    r.client.Name,
+		Role:    r.client.Role.String(),
+		Remote:  r.client.Remote,
+		Via:     via,
+		Type:    r.typ,
+		Payload: payload,
+	})
+	if err != nil {
+		return
+	}
+	authLog.Debug("audit", "entry", string(buf))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	if _, err := l.file.Write(append(buf, '\n')); err != nil {
+		authLog.Error("writing audit log", "err", err)
+	}
+}
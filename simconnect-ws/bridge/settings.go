@@ -0,0 +1,171 @@
+package bridge
+
+import (
+	"flag"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+// Settings are what the flags and the config file of simconnect-ws set.
+// The main goroutine of the bridge changes them on config reloads.
+type Settings struct {
+	// mu guards the settings a reload changes that HTTP handlers read:
+	// noAuth, pairRole, originRoles and disabledStreams. The rest is only
+	// read on the main goroutine, where reloads are applied.
+	mu sync.RWMutex
+
+	// configPath is the config file; relative paths are next to the exe.
+	configPath string
+	// flags is where RegisterFlags put the flags, if anywhere.
+	flags *flag.FlagSet
+
+	httpListen      string
+	httpsListen     string
+	tlsCertPath     string
+	tlsKeyPath      string
+	allowAllOrigins bool
+	allowedOrigins  stringList
+
+	planeInterval    time.Duration
+	trafficInterval  time.Duration
+	trafficRadius    uint
+	trackHistory     time.Duration
+	trackInterval    time.Duration
+	keyframeInterval time.Duration
+	deadbands        deadbandFlag
+	disabledStreams  streamSet
+	outputs          []outputConfig
+
+	noAuth          bool
+	pairRole        Role
+	originRoles     originRoleFlag
+	disableTeleport bool
+	disableEvents   bool
+	// copilotEvents are the sim events a copilot may send, the built-in
+	// ones with those of -copilot-events; configCopilotEvents are the ones
+	// the config file adds.
+	copilotEvents       eventSet
+	configCopilotEvents eventSet
+	// auditLogPath is where accepted control commands are logged; empty
+	// turns the log off.
+	auditLogPath string
+
+	recordDir   string
+	replayPath  string
+	replaySpeed float64
+
+	verbose       bool
+	logLevels     string
+	logFormat     string
+	logFilePath   string
+	logMaxSize    uint
+	logMaxBackups uint
+}
+
+// NewSettings returns the default settings.
+func NewSettings() *Settings {
+	s := &Settings{
+		configPath:       "simconnect-ws.json",
+		httpListen:       "0.0.0.0:9000",
+		httpsListen:      "0.0.0.0:9443",
+		allowedOrigins:   stringList(websockets.DefaultOrigins),
+		planeInterval:    200 * time.Millisecond,
+		trafficInterval:  2 * time.Second,
+		trafficRadius:    50000,
+		trackHistory:     10 * time.Minute,
+		trackInterval:    2 * time.Second,
+		keyframeInterval: 10 * time.Second,
+		deadbands:        defaultDeadbands.clone(),
+		disabledStreams:  streamSet{},
+
+		pairRole:            RoleCopilot,
+		originRoles:         originRoleFlag{},
+		copilotEvents:       eventSet{},
+		configCopilotEvents: eventSet{},
+		auditLogPath:        "simconnect-ws-audit.log",
+
+		replaySpeed: 1,
+
+		logFormat:     "logfmt",
+		logMaxSize:    10,
+		logMaxBackups: 5,
+	}
+	s.copilotEvents.add(defaultCopilotEvents...)
+	return s
+}
+
+// RegisterFlags adds the settings to fs, with their current values as
+// defaults. Flags set on the command line win over the config file.
+func (s *Settings) RegisterFlags(fs *flag.FlagSet) {
+	s.flags = fs
+	fs.BoolVar(&s.verbose, "verbose", s.verbose, "verbose output, short for a default log level of debug")
+	fs.StringVar(&s.logLevels, "log-level", s.logLevels, "log levels as level,subsystem=level,... for simconnect, websocket, http, tls, auth and config (default info)")
+	fs.StringVar(&s.logFormat, "log-format", s.logFormat, "log format: logfmt or json")
+	fs.StringVar(&s.logFilePath, "log-file", s.logFilePath, "also log to this file, relative to the exe (empty disables)")
+	fs.UintVar(&s.logMaxSize, "log-max-size", s.logMaxSize, "size in MB at which the log file is rotated")
+	fs.UintVar(&s.logMaxBackups, "log-max-backups", s.logMaxBackups, "rotated log files to keep")
+	fs.StringVar(&s.httpListen, "listen-http", s.httpListen, "http listen address (plain HTTP)")
+	fs.StringVar(&s.httpsListen, "listen-https", s.httpsListen, "https listen address (TLS)")
+	fs.StringVar(&s.configPath, "config", s.configPath, "config file, relative to the exe; flags override it")
+	fs.StringVar(&s.tlsCertPath, "tls-cert", s.tlsCertPath, "TLS certificate file (default: generated next to the exe)")
+	fs.StringVar(&s.tlsKeyPath, "tls-key", s.tlsKeyPath, "TLS key file (default: generated next to the exe)")
+	fs.BoolVar(&s.allowAllOrigins, "allow-all-origins", s.allowAllOrigins, "allow all websocket origins (not recommended)")
+	fs.Var(&s.allowedOrigins, "origins", "comma separated remote origins allowed to open websockets, besides local ones")
+	fs.BoolVar(&s.noAuth, "no-auth", s.noAuth, "accept clients from the network without a token (not recommended)")
+	fs.Var(roleFlag{&s.pairRole}, "pair-role", "role of newly paired clients (viewer, copilot or instructor)")
+	fs.Var(s.originRoles, "origin-role", "highest role of browser pages per origin as origin=role,...")
+	fs.StringVar(&s.auditLogPath, "audit-log", s.auditLogPath, "file to log accepted control commands to, relative to the exe (empty disables)")
+	fs.BoolVar(&s.disableTeleport, "disable-teleport", s.disableTeleport, "disable teleport")
+	fs.BoolVar(&s.disableEvents, "disable-events", s.disableEvents, "disable sending sim events from clients")
+	fs.Var(s.copilotEvents, "copilot-events", "comma separated sim events copilots may send besides the built-in ones")
+	fs.StringVar(&s.recordDir, "record-dir", s.recordDir, "record each simconnect session to a file in this directory")
+	fs.StringVar(&s.replayPath, "replay", s.replayPath, "replay a recorded session instead of connecting to the simulator")
+	fs.Float64Var(&s.replaySpeed, "replay-speed", s.replaySpeed, "replay speed multiplier (0 = as fast as possible)")
+	fs.Var(s.deadbands, "deadband", "delta deadbands as field=amount,... (added to the defaults)")
+	fs.DurationVar(&s.planeInterval, "plane-interval", s.planeInterval, "how often the user aircraft is polled")
+	fs.Var(s.disabledStreams, "disable-streams", "comma separated streams to turn off: traffic, subscriptions, sse")
+	fs.UintVar(&s.trafficRadius, "traffic-radius", s.trafficRadius, "radius around the user aircraft to report traffic in, in meters (at most 200000)")
+	fs.DurationVar(&s.trafficInterval, "traffic-interval", s.trafficInterval, "how often traffic is refreshed (0 disables traffic)")
+	fs.DurationVar(&s.trackHistory, "track-history", s.trackHistory, "how much of the flown track new clients can ask for")
+	fs.DurationVar(&s.trackInterval, "track-interval", s.trackInterval, "time between kept track points")
+	fs.DurationVar(&s.keyframeInterval, "keyframe-interval", s.keyframeInterval, "how often delta clients get a full plane snapshot")
+}
+
+// explicitFlags returns the names of the flags given on the command line.
+func (s *Settings) explicitFlags() map[string]bool {
+	explicit := map[string]bool{}
+	if s.flags == nil {
+		return explicit
+	}
+	s.flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
+
+// streamEnabled reports whether the stream name is on.
+func (s *Settings) streamEnabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.disabledStreams[name]
+}
+
+// isCopilotEvent reports whether a copilot may send the sim event name.
+func (s *Settings) isCopilotEvent(name string) bool {
+	name = strings.ToUpper(name)
+	return s.copilotEvents[name] || s.configCopilotEvents[name]
+}
+
+// capForOrigin lowers r to the role bound to origin, if any.
+func (s *Settings) capForOrigin(r Role, origin string) Role {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if bound, ok := s.originRoles[strings.ToLower(strings.TrimRight(origin, "/"))]; ok && bound < r {
+		return bound
+	}
+	return r
+}
@@ -0,0 +1,108 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+// simCallTimeout is how long the sim gets to answer for sent calls.
+const simCallTimeout = 5 * time.Second
+
+// sentCalls tells callers whether the sim took calls it only reports
+// failures of. After the calls a system state is requested: an exception
+// for one of them comes before the answer, and an answer without one means
+// they went through.
+type sentCalls struct {
+	mu      sync.Mutex
+	pending map[simconnect.DWORD]*sentCall // by the request ID of the state
+}
+
+type sentCall struct {
+	sendIDs []simconnect.DWORD
+	started time.Time
+	done    func(error)
+}
+
+// reset fails the calls of the previous simconnect session.
+func (c *sentCalls) reset() {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	for _, p := range pending {
+		p.done(newProtocolError(errSimUnavailable, "simulator disconnected"))
+	}
+}
+
+// check calls done from the simconnect loop with the error the sim had for
+// the calls sent as sendIDs, or nil once it answers without one.
+func (c *sentCalls) check(s *simconnect.SimConnect, sendIDs []simconnect.DWORD, done func(error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	requestID := s.GetRequestID()
+	if err := s.RequestSystemState(requestID, "Sim"); err != nil {
+		return err
+	}
+	if c.pending == nil {
+		c.pending = map[simconnect.DWORD]*sentCall{}
+	}
+	c.pending[requestID] = &sentCall{sendIDs: sendIDs, started: time.Now(), done: done}
+	return nil
+}
+
+// exception fails the call the sim refused and reports whether there was one.
+func (c *sentCalls) exception(ex simconnect.RecvException) bool {
+	c.mu.Lock()
+	var failed *sentCall
+	for requestID, p := range c.pending {
+		for _, id := range p.sendIDs {
+			if id == ex.SendID {
+				failed = p
+				delete(c.pending, requestID)
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if failed == nil {
+		return false
+	}
+	failed.done(newProtocolError(errBadRequest, "the simulator refused it: %s", simconnect.ExceptionNames[ex.Exception]))
+	return true
+}
+
+// confirmed completes the calls checked with requestID and reports whether
+// there were any.
+func (c *sentCalls) confirmed(requestID simconnect.DWORD) bool {
+	c.mu.Lock()
+	p, ok := c.pending[requestID]
+	delete(c.pending, requestID)
+	c.mu.Unlock()
+
+	if ok {
+		p.done(nil)
+	}
+	return ok
+}
+
+// expire fails the checks the sim never answered.
+func (c *sentCalls) expire(now time.Time) {
+	c.mu.Lock()
+	var expired []*sentCall
+	for requestID, p := range c.pending {
+		if now.Sub(p.started) > simCallTimeout {
+			expired = append(expired, p)
+			delete(c.pending, requestID)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, p := range expired {
+		p.done(newProtocolError(errUnavailable, "the simulator did not answer"))
+	}
+}
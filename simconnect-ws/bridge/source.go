@@ -0,0 +1,155 @@
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+// Source is where the bridge gets its SimConnect messages from: the
+// simulator, a recording, or anything else behind a simconnect.Backend.
+type Source interface {
+	// Open starts the backend for a new session.
+	Open() (*Feed, error)
+}
+
+// Feed is a backend a Source opened.
+type Feed struct {
+	Backend simconnect.Backend
+
+	// Done is closed when the source runs out of messages; nil for one that
+	// doesn't, like the simulator.
+	Done <-chan struct{}
+
+	// Close releases what backs the feed once the session is over; opened
+	// tells whether a session ever ran on it. May be nil.
+	Close func(opened bool)
+}
+
+func (f *Feed) close(opened bool) {
+	if f.Close != nil {
+		f.Close(opened)
+	}
+}
+
+// SimulatorSource connects to the running simulator through SimConnect.dll.
+type SimulatorSource struct{}
+
+func (SimulatorSource) Open() (*Feed, error) {
+	b, err := simconnect.NewDLLBackend()
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{Backend: b}, nil
+}
+
+// ReplaySource plays back a session recorded by RecordingSource.
+type ReplaySource struct {
+	Path string
+	// Speed multiplies the recorded pace; 0 replays as fast as possible.
+	Speed float64
+}
+
+func (src ReplaySource) Open() (*Feed, error) {
+	f, err := os.Open(src.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	replayer, err := simconnect.NewReplayer(f, src.Speed)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", src.Path, err)
+	}
+	return &Feed{
+		Backend: replayer,
+		Done:    replayer.Done(),
+		Close:   func(bool) { f.Close() },
+	}, nil
+}
+
+// RecordingSource records every session of Source to a file in Dir.
+// Sessions that never opened leave no file behind.
+type RecordingSource struct {
+	Source Source
+	Dir    string
+}
+
+func (src RecordingSource) Open() (*Feed, error) {
+	feed, err := src.Source.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(src.Dir, fmt.Sprintf("simconnect-ws-%s.screc", time.Now().Format("20060102-150405")))
+	f, err := os.Create(path)
+	if err != nil {
+		feed.close(false)
+		return nil, err
+	}
+
+	recorder, err := simconnect.NewRecorder(feed.Backend, f)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		feed.close(false)
+		return nil, err
+	}
+	return &Feed{
+		Backend: recorder,
+		Done:    feed.Done,
+		Close: func(opened bool) {
+			f.Close()
+			if !opened {
+				os.Remove(path)
+			}
+			feed.close(opened)
+		},
+	}, nil
+}
+
+// defaultSource is the source the settings ask for.
+func defaultSource(st *Settings) Source {
+	var src Source = SimulatorSource{}
+	if st.replayPath != "" {
+		src = ReplaySource{Path: st.replayPath, Speed: st.replaySpeed}
+	}
+	if st.recordDir != "" {
+		src = RecordingSource{Source: src, Dir: st.recordDir}
+	}
+	return src
+}
+
+// simSession is an open SimConnect connection on a feed.
+type simSession struct {
+	*simconnect.SimConnect
+
+	// Done is closed when a replay runs out of messages; nil for a live sim.
+	Done <-chan struct{}
+
+	feed *Feed
+}
+
+func (s *simSession) Close() error {
+	err := s.SimConnect.Close()
+	s.feed.close(true)
+	return err
+}
+
+// openSimSession opens a SimConnect session called name on a feed from src.
+func openSimSession(name string, src Source) (*simSession, error) {
+	feed, err := src.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := simconnect.NewWithBackend(name, feed.Backend)
+	if err != nil {
+		feed.close(false)
+		return nil, err
+	}
+	return &simSession{SimConnect: s, Done: feed.Done, feed: feed}, nil
+}
@@ -0,0 +1,214 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	eventLogSize     = 2048
+	sseKeepAlive     = 15 * time.Second
+	sseRetryMs       = 2000
+	sseVariantLegacy = "legacy"
+	sseVariantTyped  = "typed"
+)
+
+// logEntry is one broadcast packet as SSE clients get it, in each variant.
+type logEntry struct {
+	id   uint64
+	typ  string
+	data map[string][]byte // payload JSON by variant
+}
+
+// eventLog keeps the last broadcast packets for SSE clients, numbered so a
+// client that reconnects with Last-Event-ID can pick up where it left off.
+type eventLog struct {
+	mu      sync.Mutex
+	entries []logEntry // ring buffer
+	start   int
+	n       int
+	lastID  uint64
+	waiters map[chan struct{}]bool
+}
+
+func newEventLog(size int) *eventLog {
+	return &eventLog{
+		entries: make([]logEntry, size),
+		waiters: map[chan struct{}]bool{},
+	}
+}
+
+// Publish adds a broadcast packet to the log.
+func (l *eventLog) Publish(p Packet) {
+	l.add(p.Type, p.Legacy, p.Typed)
+}
+
+// add appends a packet with its legacy and typed payloads.
+func (l *eventLog) add(typ string, legacy, typed map[string]interface{}) {
+	data := map[string][]byte{}
+	if legacy != nil {
+		data[sseVariantLegacy], _ = json.Marshal(legacy)
+	}
+	if typed != nil {
+		data[sseVariantTyped], _ = json.Marshal(typed)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastID++
+	e := logEntry{id: l.lastID, typ: typ, data: data}
+	if l.n < len(l.entries) {
+		l.entries[(l.start+l.n)%len(l.entries)] = e
+		l.n++
+	} else {
+		l.entries[l.start] = e
+		l.start = (l.start + 1) % len(l.entries)
+	}
+
+	for w := range l.waiters {
+		select {
+		case w <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// since returns the kept entries after id, oldest first, and whether some
+// entries after id were already dropped from the log.
+func (l *eventLog) since(id uint64) (entries []logEntry, gap bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := 0; i < l.n; i++ {
+		e := l.entries[(l.start+i)%len(l.entries)]
+		if e.id > id {
+			if len(entries) == 0 && e.id > id+1 {
+				gap = true
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, gap
+}
+
+// head returns the newest id.
+func (l *eventLog) head() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastID
+}
+
+func (l *eventLog) wait() chan struct{} {
+	w := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.waiters[w] = true
+	l.mu.Unlock()
+	return w
+}
+
+func (l *eventLog) unwait(w chan struct{}) {
+	l.mu.Lock()
+	delete(l.waiters, w)
+	l.mu.Unlock()
+}
+
+// sseHandler serves the event log as Server-Sent Events. Query parameters:
+// types=plane,mark limits the packet types, format=typed sends plane values
+// as typed telemetry. A Last-Event-ID header (or last_event_id parameter)
+// resumes after that packet; without it only new packets are sent.
+func sseHandler(l *eventLog, st *Settings) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !st.streamEnabled("sse") {
+			writeAPIError(w, 0, newProtocolError(errForbidden, "the sse stream is disabled"))
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		q := r.URL.Query()
+		var types map[string]bool
+		if v := q.Get("types"); v != "" {
+			types = map[string]bool{}
+			for _, t := range strings.Split(v, ",") {
+				types[strings.TrimSpace(t)] = true
+			}
+		}
+		variant := sseVariantLegacy
+		if q.Get("format") == "typed" {
+			variant = sseVariantTyped
+		}
+
+		lastID := l.head()
+		resume := r.Header.Get("Last-Event-ID")
+		if resume == "" {
+			resume = q.Get("last_event_id")
+		}
+		if resume != "" {
+			id, err := strconv.ParseUint(resume, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+			if id < lastID {
+				lastID = id
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetryMs)
+		flusher.Flush()
+
+		wake := l.wait()
+		defer l.unwait(wake)
+
+		keepAlive := time.NewTicker(sseKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			entries, gap := l.since(lastID)
+			if gap {
+				// the client missed packets that are no longer kept
+				fmt.Fprintf(w, "event: gap\ndata: {\"after\":%d}\n\n", lastID)
+			}
+
+			for _, e := range entries {
+				lastID = e.id
+				if types != nil && !types[e.typ] {
+					continue
+				}
+				data, ok := e.data[variant]
+				if !ok {
+					data = e.data[sseVariantLegacy]
+				}
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.id, e.typ, data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+
+			select {
+			case <-wake:
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
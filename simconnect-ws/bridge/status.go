@@ -0,0 +1,211 @@
+package bridge
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+const (
+	// staleDataAfter is how long a connected sim may go without sending
+	// data before the status says so.
+	staleDataAfter = 5 * time.Second
+
+	maxRecentExceptions = 20
+)
+
+// sim states reported by /status and sim_status packets
+const (
+	simStateWaiting   = "waiting_for_simulator"
+	simStateConnected = "connected"
+	simStateStale     = "no_data"
+)
+
+// simStatus is what the server knows about the simulator it is connected to.
+type simStatus struct {
+	mu          sync.Mutex
+	connected   bool
+	connectedAt time.Time
+	open        *simconnect.RecvOpen
+	lastData    time.Time
+	exceptions  []simException // oldest first
+}
+
+// simException is a RECV_ID_EXCEPTION the sim sent.
+type simException struct {
+	Time      time.Time `json:"time"`
+	Exception uint32    `json:"exception"`
+	Name      string    `json:"name"`
+	SendID    uint32    `json:"send_id"`
+	Index     uint32    `json:"index"`
+}
+
+func (st *simStatus) setConnected(connected bool, now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.connected = connected
+	st.connectedAt = now
+	st.open = nil
+	st.lastData = time.Time{}
+}
+
+func (st *simStatus) setOpen(open simconnect.RecvOpen) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.open = &open
+}
+
+func (st *simStatus) isConnected() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.connected
+}
+
+// dataReceived notes that the sim sent something at now.
+func (st *simStatus) dataReceived(now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.lastData = now
+}
+
+// addException keeps e among the recent exceptions.
+func (st *simStatus) addException(e simconnect.RecvException, now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.exceptions = append(st.exceptions, simException{
+		Time:      now.UTC(),
+		Exception: uint32(e.Exception),
+		Name:      simconnect.ExceptionNames[e.Exception],
+		SendID:    uint32(e.SendID),
+		Index:     uint32(e.Index),
+	})
+	if len(st.exceptions) > maxRecentExceptions {
+		st.exceptions = st.exceptions[len(st.exceptions)-maxRecentExceptions:]
+	}
+}
+
+// state sums up the connection at now; st.mu is held.
+func (st *simStatus) state(now time.Time) string {
+	last := st.lastData
+	if last.IsZero() {
+		last = st.connectedAt
+	}
+	switch {
+	case !st.connected:
+		return simStateWaiting
+	case now.Sub(last) > staleDataAfter:
+		return simStateStale
+	default:
+		return simStateConnected
+	}
+}
+
+// info describes the simulator from its RECV_ID_OPEN message.
+func (st *simStatus) info() map[string]interface{} {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	info := map[string]interface{}{
+		"connected": st.connected,
+		"state":     st.state(now),
+	}
+	if st.connected {
+		info["connected_at"] = st.connectedAt.UTC().Format(time.RFC3339)
+	}
+	if !st.lastData.IsZero() {
+		info["last_data_ms_ago"] = now.Sub(st.lastData).Milliseconds()
+	}
+	if st.open != nil {
+		info["application"] = cString(st.open.ApplicationName[:])
+		info["application_version"] = fmt.Sprintf("%d.%d", st.open.ApplicationVersionMajor, st.open.ApplicationVersionMinor)
+		info["application_build"] = fmt.Sprintf("%d.%d", st.open.ApplicationBuildMajor, st.open.ApplicationBuildMinor)
+		info["simconnect_version"] = fmt.Sprintf("%d.%d", st.open.SimConnectVersionMajor, st.open.SimConnectVersionMinor)
+		info["simconnect_build"] = fmt.Sprintf("%d.%d", st.open.SimConnectBuildMajor, st.open.SimConnectBuildMinor)
+	}
+	return info
+}
+
+// recentExceptions returns the last exceptions the sim sent, oldest first.
+func (st *simStatus) recentExceptions() []simException {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return append([]simException{}, st.exceptions...)
+}
+
+// publishSimStatus tells every client how the sim connection is doing.
+func (b *Bridge) publishSimStatus() {
+	b.peers.broadcast(b.ws, "sim_status", b.sim.info())
+}
+
+// clientStatus describes one websocket connection for /status.
+type clientStatus struct {
+	Origin      string   `json:"origin"`
+	Remote      string   `json:"remote"`
+	Client      string   `json:"client,omitempty"`
+	Auth        string   `json:"auth"`
+	Role        string   `json:"role"`
+	Protocol    int      `json:"protocol"`
+	Encoding    string   `json:"encoding"`
+	Caps        []string `json:"caps"`
+	ConnectedAt string   `json:"connected_at"`
+	Queued      int      `json:"queued"`
+	LagMs       int64    `json:"lag_ms"`
+}
+
+// clientStatuses describes the open websocket connections, oldest first.
+func clientStatuses(ws *websockets.Websocket, peers *peers, now time.Time) []clientStatus {
+	conns := ws.Connections()
+	sort.Slice(conns, func(i, j int) bool { return conns[i].ConnectedAt().Before(conns[j].ConnectedAt()) })
+
+	clients := make([]clientStatus, 0, len(conns))
+	for _, c := range conns {
+		pe := peers.get(c)
+		who := clientFromContext(c.Context())
+		caps := make([]string, 0, len(pe.caps))
+		for capName := range pe.caps {
+			caps = append(caps, capName)
+		}
+		sort.Strings(caps)
+
+		clients = append(clients, clientStatus{
+			Origin:      c.Origin(),
+			Remote:      c.RemoteAddr(),
+			Client:      pe.client,
+			Auth:        who.Name,
+			Role:        who.Role.String(),
+			Protocol:    pe.version,
+			Encoding:    pe.encoding().Name(),
+			Caps:        caps,
+			ConnectedAt: c.ConnectedAt().UTC().Format(time.RFC3339),
+			Queued:      c.QueueLen(),
+			LagMs:       c.Lag(now).Milliseconds(),
+		})
+	}
+	return clients
+}
+
+// serverStatus is the health report served on /status. The bridge is
+// degraded while the sim is gone or sends nothing.
+func (b *Bridge) serverStatus() map[string]interface{} {
+	simInfo := b.sim.info()
+	status := "ok"
+	if simInfo["state"] != simStateConnected {
+		status = "degraded"
+	}
+	return map[string]interface{}{
+		"status":        status,
+		"version":       b.opts.Version,
+		"sim":           simInfo["state"],
+		"sim_info":      simInfo,
+		"exceptions":    b.sim.recentExceptions(),
+		"clients":       clientStatuses(b.ws, b.peers, time.Now()),
+		"subscriptions": b.subs.list(),
+	}
+}
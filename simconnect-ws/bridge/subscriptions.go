@@ -0,0 +1,411 @@
+package bridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+const (
+	minSubscriptionRate     = 50 * time.Millisecond
+	defaultSubscriptionRate = 200 * time.Millisecond
+	maxSubscriptionVars     = 100
+
+	// maxConnectionSubscriptions is how many subscriptions one connection
+	// may hold, which bounds the requests it makes the sim loop send
+	maxConnectionSubscriptions = 16
+)
+
+// simvar is one variable of a subscription. A unit of "string" reads the
+// variable as text, anything else as a float64 in that unit.
+type simvar struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+}
+
+func (v simvar) isString() bool {
+	return strings.EqualFold(v.Unit, "string")
+}
+
+func (v simvar) size() int {
+	if v.isString() {
+		return 256
+	}
+	return 8
+}
+
+// subscriptionGroup is a data definition shared by every connection that
+// asked for the same variables at the same rate.
+type subscriptionGroup struct {
+	id    string
+	key   string
+	vars  []simvar
+	rate  time.Duration
+	conns map[*websockets.Connection]bool
+
+	// defined in the current simconnect session, zero otherwise
+	defined   bool
+	defineID  simconnect.DWORD
+	requestID simconnect.DWORD
+	next      time.Time
+}
+
+// subscriptions tracks the simvar subscriptions of all connections. Clients
+// subscribe and leave on the websocket goroutines; the simconnect loop polls
+// it to define, request and clear data definitions.
+type subscriptions struct {
+	peers *peers
+
+	mu        sync.Mutex
+	lastID    int
+	groups    map[string]*subscriptionGroup // by key
+	byID      map[string]*subscriptionGroup
+	byRequest map[simconnect.DWORD]*subscriptionGroup
+	sends     map[simconnect.DWORD]subscriptionSend // by send ID, for exceptions
+	stale     []simconnect.DWORD                    // define IDs to clear
+}
+
+// subscriptionSend is a packet sent for a group, which an exception from the
+// sim can point back to.
+type subscriptionSend struct {
+	g *subscriptionGroup
+	v string // the var it added, if any
+}
+
+func newSubscriptions(peers *peers) *subscriptions {
+	return &subscriptions{
+		peers:     peers,
+		groups:    map[string]*subscriptionGroup{},
+		byID:      map[string]*subscriptionGroup{},
+		byRequest: map[simconnect.DWORD]*subscriptionGroup{},
+		sends:     map[simconnect.DWORD]subscriptionSend{},
+	}
+}
+
+// Subscribe adds c to the group for vars at rate, creating it if needed, and
+// returns the group.
+func (s *subscriptions) Subscribe(c *websockets.Connection, vars []simvar, rate time.Duration) (*subscriptionGroup, error) {
+	if len(vars) == 0 {
+		return nil, fmt.Errorf("no vars")
+	}
+	if len(vars) > maxSubscriptionVars {
+		return nil, fmt.Errorf("too many vars, at most %d", maxSubscriptionVars)
+	}
+	for _, v := range vars {
+		if strings.TrimSpace(v.Name) == "" {
+			return nil, fmt.Errorf("var without name")
+		}
+	}
+	if rate == 0 {
+		rate = defaultSubscriptionRate
+	}
+	if rate < minSubscriptionRate {
+		rate = minSubscriptionRate
+	}
+
+	// the same variables in a different order share a definition
+	sorted := append([]simvar(nil), vars...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name != sorted[j].Name {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return sorted[i].Unit < sorted[j].Unit
+	})
+	keyParts := make([]string, 0, len(sorted)+1)
+	keyParts = append(keyParts, rate.String())
+	for _, v := range sorted {
+		keyParts = append(keyParts, strings.ToUpper(v.Name)+"|"+strings.ToLower(v.Unit))
+	}
+	key := strings.Join(keyParts, "\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.groups[key]
+	if !ok || !g.conns[c] {
+		held := 0
+		for _, other := range s.groups {
+			if other.conns[c] {
+				held++
+			}
+		}
+		if held >= maxConnectionSubscriptions {
+			return nil, fmt.Errorf("too many subscriptions, at most %d", maxConnectionSubscriptions)
+		}
+	}
+	if !ok {
+		s.lastID++
+		g = &subscriptionGroup{
+			id:    fmt.Sprintf("sub-%d", s.lastID),
+			key:   key,
+			vars:  sorted,
+			rate:  rate,
+			conns: map[*websockets.Connection]bool{},
+		}
+		s.groups[key] = g
+		s.byID[g.id] = g
+	}
+	g.conns[c] = true
+
+	return g, nil
+}
+
+// Unsubscribe removes c from the group with the given id.
+func (s *subscriptions) Unsubscribe(c *websockets.Connection, id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.byID[id]
+	if !ok || !g.conns[c] {
+		return false
+	}
+	s.leave(g, c)
+	return true
+}
+
+// Drop removes every subscription of c.
+func (s *subscriptions) Drop(c *websockets.Connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.groups {
+		if g.conns[c] {
+			s.leave(g, c)
+		}
+	}
+}
+
+// leave removes c from g and frees g once nobody uses it; s.mu is held.
+func (s *subscriptions) leave(g *subscriptionGroup, c *websockets.Connection) {
+	delete(g.conns, c)
+	if len(g.conns) > 0 {
+		return
+	}
+
+	s.remove(g)
+}
+
+// remove forgets g and has its definition cleared; s.mu is held.
+func (s *subscriptions) remove(g *subscriptionGroup) {
+	delete(s.groups, g.key)
+	delete(s.byID, g.id)
+	for id, send := range s.sends {
+		if send.g == g {
+			delete(s.sends, id)
+		}
+	}
+	if g.defined {
+		delete(s.byRequest, g.requestID)
+		s.stale = append(s.stale, g.defineID)
+	}
+}
+
+// Exception fails the group a simconnect exception points back to, such as
+// one for a simvar the sim doesn't know: its subscribers get a
+// subscription_error and the group is dropped. It reports whether the
+// exception belonged to a subscription.
+func (s *subscriptions) Exception(e simconnect.RecvException) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	send, ok := s.sends[e.SendID]
+	if !ok {
+		return false
+	}
+	g := send.g
+
+	name := simconnect.ExceptionNames[e.Exception]
+	if name == "" {
+		name = fmt.Sprint(e.Exception)
+	}
+	payload := map[string]interface{}{
+		"id":        g.id,
+		"code":      errBadRequest,
+		"exception": name,
+		"message":   fmt.Sprintf("the simulator refused the subscription: %s", name),
+	}
+	if send.v != "" {
+		payload["var"] = send.v
+		payload["message"] = fmt.Sprintf("the simulator refused %s: %s", send.v, name)
+	}
+	simLog.Info("dropping subscription", "subscription", g.id, "var", send.v, "exception", name)
+
+	for c := range g.conns {
+		c.TrySend(s.peers.encode(c, "subscription_error", payload))
+	}
+	s.remove(g)
+	return true
+}
+
+// Reset forgets the definitions of the previous simconnect session.
+func (s *subscriptions) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, g := range s.groups {
+		g.defined = false
+	}
+	s.byRequest = map[simconnect.DWORD]*subscriptionGroup{}
+	s.sends = map[simconnect.DWORD]subscriptionSend{}
+	s.stale = nil
+}
+
+// subscriptionStatus describes one subscription group for /status.
+type subscriptionStatus struct {
+	ID          string   `json:"id"`
+	Vars        []string `json:"vars"`
+	RateMs      int64    `json:"rate_ms"`
+	Defined     bool     `json:"defined"`
+	Connections int      `json:"connections"`
+}
+
+// list describes the active subscription groups, by id.
+func (s *subscriptions) list() []subscriptionStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]subscriptionStatus, 0, len(s.byID))
+	for _, g := range s.byID {
+		vars := make([]string, len(g.vars))
+		for i, v := range g.vars {
+			vars[i] = v.Name
+		}
+		list = append(list, subscriptionStatus{
+			ID:          g.id,
+			Vars:        vars,
+			RateMs:      g.rate.Milliseconds(),
+			Defined:     g.defined,
+			Connections: len(g.conns),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Poll clears unused definitions, defines new groups and requests data for
+// the groups that are due.
+func (s *subscriptions) Poll(sc *simconnect.SimConnect, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, defineID := range s.stale {
+		if err := sc.ClearDataDefinition(defineID); err != nil {
+			simLog.Debug("clearing subscription definition", "define_id", defineID, "err", err)
+		}
+	}
+	s.stale = nil
+
+	for _, g := range s.groups {
+		first := !g.defined
+		if !g.defined {
+			if err := s.define(sc, g); err != nil {
+				simLog.Warn("defining subscription", "subscription", g.id, "err", err)
+				continue
+			}
+		}
+		if now.Before(g.next) {
+			continue
+		}
+		g.next = now.Add(g.rate)
+		err := sc.RequestDataOnSimObjectType(g.requestID, g.defineID, 0, simconnect.SIMOBJECT_TYPE_USER)
+		if err != nil {
+			simLog.Debug("requesting subscription data", "subscription", g.id, "err", err)
+		} else if first {
+			s.track(sc, g, "")
+		}
+	}
+}
+
+func (s *subscriptions) define(sc *simconnect.SimConnect, g *subscriptionGroup) error {
+	defineID := sc.NewDefineID()
+	for _, v := range g.vars {
+		dataType, unit := simconnect.DATATYPE_FLOAT64, v.Unit
+		if v.isString() {
+			dataType, unit = simconnect.DATATYPE_STRING256, ""
+		}
+		if err := sc.AddToDataDefinition(defineID, v.Name, unit, dataType, 0); err != nil {
+			sc.ClearDataDefinition(defineID)
+			return err
+		}
+		s.track(sc, g, v.Name)
+	}
+
+	g.defined = true
+	g.defineID = defineID
+	g.requestID = sc.GetRequestID()
+	s.byRequest[g.requestID] = g
+	return nil
+}
+
+// track remembers the packet just sent for g, adding v if not empty; s.mu is
+// held.
+func (s *subscriptions) track(sc *simconnect.SimConnect, g *subscriptionGroup, v string) {
+	sendID, err := sc.GetLastSentPacketID()
+	if err != nil {
+		simLog.Debug("getting send ID", "subscription", g.id, "err", err)
+		return
+	}
+	s.sends[sendID] = subscriptionSend{g: g, v: v}
+}
+
+// Deliver sends a SIMOBJECT_DATA message to the subscribers of its request
+// and reports whether the request belonged to a subscription.
+func (s *subscriptions) Deliver(msg []byte) bool {
+	var hdr simconnect.RecvSimobjectData
+	if err := hdr.UnmarshalBinary(msg); err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.byRequest[hdr.RequestID]
+	if !ok {
+		return false
+	}
+
+	values, err := decodeSimvars(g.vars, msg[simconnect.SizeofRecvSimobjectData:])
+	if err != nil {
+		simLog.Debug("decoding subscription data", "subscription", g.id, "err", err)
+		return true
+	}
+
+	payload := map[string]interface{}{
+		"id":     g.id,
+		"values": values,
+	}
+	for c := range g.conns {
+		f := s.peers.encode(c, "subscription", payload)
+		f.Stream = "subscription/" + g.id
+		c.TrySend(f)
+	}
+	return true
+}
+
+func decodeSimvars(vars []simvar, data []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(vars))
+	off := 0
+	for _, v := range vars {
+		if off+v.size() > len(data) {
+			return nil, fmt.Errorf("short data for %s", v.Name)
+		}
+		if v.isString() {
+			b := data[off : off+v.size()]
+			if i := strings.IndexByte(string(b), 0); i >= 0 {
+				b = b[:i]
+			}
+			values[v.Name] = string(b)
+		} else {
+			values[v.Name] = math.Float64frombits(binary.LittleEndian.Uint64(data[off:]))
+		}
+		off += v.size()
+	}
+	return values, nil
+}
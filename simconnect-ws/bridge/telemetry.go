@@ -0,0 +1,74 @@
+package bridge
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// capTypedTelemetry is the hello capability that switches a connection's
+// plane packets from the legacy strings to full precision numbers.
+const capTypedTelemetry = "typed-telemetry"
+
+// legacyPlanePayload is the plane packet as the original msfs-map client
+// expects it, with most values rounded into strings.
+func legacyPlanePayload(r *Report) map[string]interface{} {
+	return map[string]interface{}{
+		"latitude":       r.Latitude,
+		"longitude":      r.Longitude,
+		"altitude":       fmt.Sprintf("%.0f", r.Altitude),
+		"heading":        int(r.Heading),
+		"ground_course":  int(r.GroundCourse),
+		"ground_heading": int(r.GroundHeading),
+		"ground_speed":   fmt.Sprintf("%.0f", r.GroundSpeed),
+		"airspeed":       fmt.Sprintf("%.0f", r.Airspeed),
+		"airspeed_true":  fmt.Sprintf("%.0f", r.AirspeedTrue),
+		"vertical_speed": fmt.Sprintf("%.0f", r.VerticalSpeed),
+		"flaps":          fmt.Sprintf("%.0f", r.Flaps),
+		"trim":           fmt.Sprintf("%.1f", r.Trim),
+		"rudder_trim":    fmt.Sprintf("%.1f", r.RudderTrim),
+	}
+}
+
+// planeValueNames are the keys of the values of typed plane packets.
+func planeValueNames() []string {
+	var names []string
+	t := reflect.TypeOf(Report{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key := field.Tag.Get("json"); key != "" && field.Type.Kind() == reflect.Float64 {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// typedPlanePayload is the plane packet for typed-telemetry connections:
+// every Report field with a json tag as a number, its unit, a sequence
+// number, the sim's absolute time in seconds and the wall clock in
+// milliseconds since the unix epoch.
+func typedPlanePayload(r *Report, seq uint64, now time.Time) map[string]interface{} {
+	values := map[string]float64{}
+	units := map[string]string{}
+
+	v := reflect.ValueOf(r).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := field.Tag.Get("json")
+		if key == "" || field.Type.Kind() != reflect.Float64 {
+			continue
+		}
+		values[key] = v.Field(i).Float()
+		units[key] = field.Tag.Get("unit")
+	}
+
+	return map[string]interface{}{
+		"seq":       seq,
+		"sim_time":  r.SimTime,
+		"wall_time": now.UnixNano() / int64(time.Millisecond),
+		"values":    values,
+		"units":     units,
+	}
+}
@@ -0,0 +1,349 @@
+package bridge
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+)
+
+const (
+	maxTeleportAirspeed = 1000 // knots
+	maxFinalDistance    = 50   // nm
+
+	glideslope        = 3.0  // degrees
+	thresholdCrossing = 50   // feet above the threshold
+	runwayLineUp      = 30.0 // meters past the threshold
+)
+
+// teleportRequest is the payload of a "teleport" packet. Only lat and lng
+// are required; altitude too unless on_ground is set. Without a heading the
+// aircraft keeps its current one, without an airspeed its current speed.
+// Pause pauses the sim before moving the aircraft, and leaves it paused.
+//
+// Instead of lat and lng a request can name an airport, with a runway to
+// line up on or to approach from distance_nm out, or a parking spot, or a
+// fix to fly over at altitude.
+type teleportRequest struct {
+	Lat      *float64 `json:"lat"`
+	Lng      *float64 `json:"lng"`
+	Altitude *float64 `json:"altitude"`
+	Heading  *float64 `json:"heading"`
+	Pitch    float64  `json:"pitch"`
+	Bank     float64  `json:"bank"`
+	Airspeed *float64 `json:"airspeed"`
+	OnGround bool     `json:"on_ground"`
+	Pause    bool     `json:"pause"`
+
+	Airport    string  `json:"airport"`
+	Runway     string  `json:"runway"`
+	DistanceNM float64 `json:"distance_nm"`
+	Parking    *uint32 `json:"parking"`
+	Fix        string  `json:"fix"`
+	Region     string  `json:"region"`
+}
+
+// facilityForm checks a request naming an airport or fix and returns the
+// facility type to look up.
+func (req *teleportRequest) facilityForm() (kind simconnect.DWORD, icao string, err error) {
+	req.Airport = strings.ToUpper(strings.TrimSpace(req.Airport))
+	req.Fix = strings.ToUpper(strings.TrimSpace(req.Fix))
+
+	if req.Lat != nil || req.Lng != nil {
+		return 0, "", newProtocolError(errBadRequest, "teleport takes either lat and lng, an airport or a fix")
+	}
+	if req.Airport == "" && req.Fix == "" {
+		return 0, "", newProtocolError(errBadRequest, "teleport needs an airport or fix name")
+	}
+	if req.Airport == "" {
+		if req.Runway != "" || req.Parking != nil || req.DistanceNM != 0 {
+			return 0, "", newProtocolError(errBadRequest, "runway, parking and distance_nm need an airport")
+		}
+		// the altitude planes report is indicated, not true, so there is
+		// nothing to default to
+		if req.Altitude == nil {
+			return 0, "", newProtocolError(errBadRequest, "teleport to a fix needs an altitude")
+		}
+		return simconnect.FACILITY_DATA_WAYPOINT, req.Fix, nil
+	}
+
+	if req.Fix != "" {
+		return 0, "", newProtocolError(errBadRequest, "teleport takes either an airport or a fix")
+	}
+	if req.Runway != "" && req.Parking != nil {
+		return 0, "", newProtocolError(errBadRequest, "teleport takes either a runway or a parking spot")
+	}
+	if err := checkRange("distance_nm", req.DistanceNM, 0, maxFinalDistance); err != nil {
+		return 0, "", err
+	}
+	if req.DistanceNM > 0 && req.Runway == "" {
+		return 0, "", newProtocolError(errBadRequest, "distance_nm needs a runway")
+	}
+	if req.Runway != "" {
+		if _, _, err := parseRunway(req.Runway); err != nil {
+			return 0, "", newProtocolError(errBadRequest, "%s", err)
+		}
+	}
+	return simconnect.FACILITY_DATA_AIRPORT, req.Airport, nil
+}
+
+// facilityPosition turns the airport or fix f into coordinates and fills in
+// the rest of the request for the placement it asks for.
+func (req *teleportRequest) facilityPosition(f facility, hist *history) (simconnect.DataInitPosition, map[string]interface{}, error) {
+	info := map[string]interface{}{}
+	var lat, lng, heading float64
+	onGround := true
+
+	switch {
+	case req.Fix != "":
+		info["fix"] = req.Fix
+		lat, lng = f.latitude, f.longitude
+		onGround = false
+
+	case req.Runway != "":
+		number, designator, _ := parseRunway(req.Runway)
+		thrLat, thrLng, rwHeading, thrAltitude, ok := f.threshold(number, designator)
+		if !ok {
+			return simconnect.DataInitPosition{}, nil, newProtocolError(errNotFound, "no runway %s at %s", req.Runway, req.Airport)
+		}
+		info["airport"] = req.Airport
+		info["runway"] = strings.ToUpper(req.Runway)
+		heading = rwHeading
+
+		elevation := thrAltitude * metersToFeet
+		if req.DistanceNM > 0 {
+			info["distance_nm"] = req.DistanceNM
+			lat, lng = destination(thrLat, thrLng, heading+180, req.DistanceNM*nmToMeters)
+			alt := elevation + req.DistanceNM*nmToMeters*metersToFeet*math.Tan(glideslope*math.Pi/180) + thresholdCrossing
+			req.Altitude = &alt
+			onGround = false
+		} else {
+			lat, lng = destination(thrLat, thrLng, heading, runwayLineUp)
+			req.Altitude = &elevation
+			zero := 0.0
+			req.Airspeed = &zero
+		}
+
+	default:
+		var number uint32
+		if req.Parking != nil {
+			number = *req.Parking
+		}
+		var ok bool
+		lat, lng, heading, ok = f.parkingPosition(number)
+		if !ok {
+			if number != 0 {
+				return simconnect.DataInitPosition{}, nil, newProtocolError(errNotFound, "no parking spot %d at %s", number, req.Airport)
+			}
+			return simconnect.DataInitPosition{}, nil, newProtocolError(errNotFound, "no parking at %s", req.Airport)
+		}
+		info["airport"] = req.Airport
+		info["parking"] = number
+		elevation := f.altitude * metersToFeet
+		req.Altitude = &elevation
+		zero := 0.0
+		req.Airspeed = &zero
+	}
+
+	req.Lat, req.Lng = &lat, &lng
+	if req.Fix == "" {
+		heading = math.Mod(heading+360, 360)
+		req.Heading = &heading
+	}
+	req.OnGround = onGround
+
+	pos, err := req.position(hist)
+	return pos, info, err
+}
+
+// position validates req and fills in what it leaves out from the last
+// plane sample.
+func (req *teleportRequest) position(hist *history) (simconnect.DataInitPosition, error) {
+	var pos simconnect.DataInitPosition
+
+	if req.Lat == nil || req.Lng == nil {
+		return pos, newProtocolError(errBadRequest, "teleport needs lat and lng")
+	}
+	if req.Altitude == nil && !req.OnGround {
+		return pos, newProtocolError(errBadRequest, "teleport needs an altitude unless on_ground is set")
+	}
+	if err := checkRange("lat", *req.Lat, -90, 90); err != nil {
+		return pos, err
+	}
+	if err := checkRange("lng", *req.Lng, -180, 180); err != nil {
+		return pos, err
+	}
+	if err := checkRange("pitch", req.Pitch, -90, 90); err != nil {
+		return pos, err
+	}
+	if err := checkRange("bank", req.Bank, -180, 180); err != nil {
+		return pos, err
+	}
+
+	pos.Latitude = *req.Lat
+	pos.Longitude = *req.Lng
+	pos.Pitch = req.Pitch
+	pos.Bank = req.Bank
+
+	if req.Altitude != nil {
+		if err := checkRange("altitude", *req.Altitude, -1500, 100000); err != nil {
+			return pos, err
+		}
+		pos.Altitude = *req.Altitude
+	}
+
+	if req.Heading != nil {
+		if err := checkRange("heading", *req.Heading, 0, 360); err != nil {
+			return pos, err
+		}
+		pos.Heading = *req.Heading
+	} else if last, ok := hist.latestReport(); ok {
+		pos.Heading = last.Heading
+	}
+
+	pos.Airspeed = simconnect.INITPOSITION_AIRSPEED_KEEP
+	if req.Airspeed != nil {
+		if err := checkRange("airspeed", *req.Airspeed, 0, maxTeleportAirspeed); err != nil {
+			return pos, err
+		}
+		pos.Airspeed = simconnect.DWORD(math.Round(*req.Airspeed))
+	}
+
+	if req.OnGround {
+		pos.OnGround = 1
+	}
+	return pos, nil
+}
+
+func checkRange(name string, v, min, max float64) error {
+	if math.IsNaN(v) || v < min || v > max {
+		return newProtocolError(errBadRequest, "%s %v out of range [%v, %v]", name, v, min, max)
+	}
+	return nil
+}
+
+func handleTeleport(r *request) (string, interface{}, error) {
+	finish := func(result map[string]interface{}, err error) (string, interface{}, error) {
+		if r.version == 0 && r.conn != nil {
+			if err != nil {
+				r.conn.SendError("teleport", err.Error())
+			} else {
+				r.conn.TrySend(r.b.peers.encode(r.conn, "teleported", result))
+			}
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return "ack", result, nil
+	}
+
+	if r.b.settings.disableTeleport {
+		return finish(nil, newProtocolError(errForbidden, "teleport is disabled"))
+	}
+
+	var req teleportRequest
+	if err := decodePayload(r.payload, &req); err != nil {
+		return finish(nil, err)
+	}
+
+	if req.Airport == "" && req.Fix == "" {
+		pos, err := req.position(r.b.hist)
+		if err != nil {
+			return finish(nil, err)
+		}
+		err = r.b.moveUser(r.s, pos, req.Pause, func(result map[string]interface{}, err error) {
+			r.respond(finish(result, err))
+		})
+		if err != nil {
+			return finish(nil, err)
+		}
+		return "", nil, errDeferred
+	}
+
+	kind, icao, err := req.facilityForm()
+	if err != nil {
+		return finish(nil, err)
+	}
+	err = r.b.lookups.lookup(r.s, kind, icao, strings.ToUpper(req.Region), func(f facility, err error) {
+		teleportToFacility(r, &req, f, err, func(result map[string]interface{}, err error) {
+			r.respond(finish(result, err))
+		})
+	})
+	if err != nil {
+		return finish(nil, err)
+	}
+	return "", nil, errDeferred
+}
+
+// teleportToFacility moves the user aircraft to the facility a lookup for
+// req found and calls done with where it went.
+func teleportToFacility(r *request, req *teleportRequest, f facility, err error, done func(map[string]interface{}, error)) {
+	if err != nil {
+		done(nil, err)
+		return
+	}
+	pos, info, err := req.facilityPosition(f, r.b.hist)
+	if err != nil {
+		done(nil, err)
+		return
+	}
+	err = r.b.moveUser(r.s, pos, req.Pause, func(result map[string]interface{}, err error) {
+		if err == nil {
+			for k, v := range info {
+				result[k] = v
+			}
+		}
+		done(result, err)
+	})
+	if err != nil {
+		done(nil, err)
+	}
+}
+
+// moveUser puts the user aircraft at pos and, once the sim took it, calls
+// done from the simconnect loop with where it went.
+func (b *Bridge) moveUser(s *simconnect.SimConnect, pos simconnect.DataInitPosition, pause bool, done func(map[string]interface{}, error)) error {
+	var sendIDs []simconnect.DWORD
+	if pause {
+		if err := b.events.transmitKnown(s, "PAUSE_ON", 0); err != nil {
+			return fmt.Errorf("pausing: %w", err)
+		}
+		id, err := s.GetLastSentPacketID()
+		if err != nil {
+			return err
+		}
+		sendIDs = append(sendIDs, id)
+	}
+
+	t := &TeleportRequest{Position: pos}
+	if err := t.SetData(s); err != nil {
+		return err
+	}
+	id, err := s.GetLastSentPacketID()
+	if err != nil {
+		return err
+	}
+	sendIDs = append(sendIDs, id)
+
+	result := map[string]interface{}{
+		"lat":       pos.Latitude,
+		"lng":       pos.Longitude,
+		"altitude":  pos.Altitude,
+		"heading":   pos.Heading,
+		"pitch":     pos.Pitch,
+		"bank":      pos.Bank,
+		"on_ground": pos.OnGround != 0,
+		"paused":    pause,
+	}
+	if pos.Airspeed != simconnect.INITPOSITION_AIRSPEED_KEEP {
+		result["airspeed"] = pos.Airspeed
+	}
+	return b.teleports.check(s, sendIDs, func(err error) {
+		if err != nil {
+			done(nil, err)
+			return
+		}
+		done(result, nil)
+	})
+}
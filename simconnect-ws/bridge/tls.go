@@ -1,4 +1,4 @@
-package main
+package bridge
 
 import (
 	"bytes"
@@ -24,7 +24,7 @@ type TLSAssets struct {
 	CertDER  []byte
 }
 
-func ensureTLSAssets(listenAddr string) (*TLSAssets, error) {
+func ensureTLSAssets(tlsCertPath, tlsKeyPath, listenAddr string) (*TLSAssets, error) {
 	exe, err := os.Executable()
 	if err != nil {
 		return nil, fmt.Errorf("locate executable: %w", err)
@@ -33,6 +33,12 @@ func ensureTLSAssets(listenAddr string) (*TLSAssets, error) {
 
 	certPath := filepath.Join(exeDir, "simconnect-ws-cert.pem")
 	keyPath := filepath.Join(exeDir, "simconnect-ws-key.pem")
+	if tlsCertPath != "" {
+		certPath = tlsCertPath
+	}
+	if tlsKeyPath != "" {
+		keyPath = tlsKeyPath
+	}
 
 	certPEM, keyPEM, certDER, err := loadExistingCert(certPath, keyPath)
 	if err != nil {
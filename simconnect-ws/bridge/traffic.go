@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kivle/msfs2020-go/simconnect"
+	"github.com/kivle/msfs2020-go/simconnect-ws/websockets"
+)
+
+// maxTrafficRadius is the largest radius SimConnect accepts, in meters.
+const maxTrafficRadius = 200000
+
+// trafficAircraft is one AI or multiplayer aircraft as clients get it.
+type trafficAircraft struct {
+	ID           simconnect.DWORD `json:"id"`
+	AtcID        string           `json:"atc_id"`
+	FlightNumber string           `json:"flight_number"`
+	Latitude     float64          `json:"lat"`
+	Longitude    float64          `json:"lng"`
+	Altitude     float64          `json:"altitude"`
+	Heading      float64          `json:"heading"`
+	GroundSpeed  float64          `json:"ground_speed"`
+	OnGround     bool             `json:"on_ground"`
+}
+
+func (a trafficAircraft) payload(action string) map[string]interface{} {
+	return map[string]interface{}{
+		"action":        action,
+		"id":            a.ID,
+		"atc_id":        a.AtcID,
+		"flight_number": a.FlightNumber,
+		"lat":           a.Latitude,
+		"lng":           a.Longitude,
+		"altitude":      a.Altitude,
+		"heading":       a.Heading,
+		"ground_speed":  a.GroundSpeed,
+		"on_ground":     a.OnGround,
+	}
+}
+
+// trafficChange is an add, update or remove of one aircraft.
+type trafficChange struct {
+	action   string
+	aircraft trafficAircraft
+}
+
+// trafficTracker keeps the aircraft around the user. Each refresh requests
+// every aircraft in range; aircraft missing from a finished sweep are gone.
+type trafficTracker struct {
+	mu       sync.Mutex
+	aircraft map[simconnect.DWORD]trafficAircraft
+	seen     map[simconnect.DWORD]bool // in the current sweep
+	sweeping bool
+}
+
+func newTrafficTracker() *trafficTracker {
+	return &trafficTracker{aircraft: map[simconnect.DWORD]trafficAircraft{}}
+}
+
+// startSweep ends the previous sweep, if the sim never finished it, and
+// starts a new one.
+func (t *trafficTracker) startSweep() []trafficChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changes []trafficChange
+	if t.sweeping {
+		changes = t.endSweep()
+	}
+	t.sweeping = true
+	t.seen = map[simconnect.DWORD]bool{}
+	return changes
+}
+
+// update records entry of outOf in the current sweep and returns what
+// changed. a is nil for entries that are not tracked, like the user's own
+// aircraft. The sweep ends with the last entry.
+func (t *trafficTracker) update(a *trafficAircraft, entry, outOf simconnect.DWORD) []trafficChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changes []trafficChange
+	if a != nil {
+		if t.seen != nil {
+			t.seen[a.ID] = true
+		}
+
+		old, ok := t.aircraft[a.ID]
+		switch {
+		case !ok:
+			changes = append(changes, trafficChange{"add", *a})
+		case old != *a:
+			changes = append(changes, trafficChange{"update", *a})
+		}
+		t.aircraft[a.ID] = *a
+	}
+
+	if t.sweeping && entry >= outOf {
+		changes = append(changes, t.endSweep()...)
+	}
+	return changes
+}
+
+// endSweep removes the aircraft the sweep did not see; t.mu is held.
+func (t *trafficTracker) endSweep() []trafficChange {
+	var changes []trafficChange
+	for id, a := range t.aircraft {
+		if !t.seen[id] {
+			delete(t.aircraft, id)
+			changes = append(changes, trafficChange{"remove", a})
+		}
+	}
+	t.sweeping = false
+	return changes
+}
+
+// reset removes every aircraft; object IDs don't survive a simconnect
+// session.
+func (t *trafficTracker) reset() []trafficChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changes []trafficChange
+	for id, a := range t.aircraft {
+		delete(t.aircraft, id)
+		changes = append(changes, trafficChange{"remove", a})
+	}
+	t.sweeping = false
+	t.seen = nil
+	return changes
+}
+
+// list returns the tracked aircraft by ID.
+func (t *trafficTracker) list() []trafficAircraft {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]trafficAircraft, 0, len(t.aircraft))
+	for _, a := range t.aircraft {
+		list = append(list, a)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// publishTraffic broadcasts changes. Adds and removes are events every
+// client gets; updates are the latest state of each aircraft's stream.
+func publishTraffic(ws *websockets.Websocket, peers *peers, changes []trafficChange) {
+	for _, c := range changes {
+		payload := c.aircraft.payload(c.action)
+		stream := ""
+		if c.action == "update" {
+			stream = fmt.Sprintf("traffic/%d", c.aircraft.ID)
+		}
+		peers.broadcastFunc(ws, stream, "traffic", func(*websockets.Connection, peer) (string, map[string]interface{}) {
+			return "", payload
+		})
+	}
+}
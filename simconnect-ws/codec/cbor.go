@@ -0,0 +1,103 @@
+package codec
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// CBOR major types
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborString = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+)
+
+// cborWriter writes definite-length CBOR items.
+type cborWriter struct {
+	buf []byte
+}
+
+// head writes the initial byte of an item of the given major type followed
+// by n in the shortest form.
+func (w *cborWriter) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, major|25)
+		w.buf = appendUint16(w.buf, uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = append(w.buf, major|26)
+		w.buf = appendUint32(w.buf, uint32(n))
+	default:
+		w.buf = append(w.buf, major|27)
+		w.buf = appendUint64(w.buf, n)
+	}
+}
+
+func (w *cborWriter) writeNil() { w.buf = append(w.buf, 0xf6) }
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xf5)
+	} else {
+		w.buf = append(w.buf, 0xf4)
+	}
+}
+
+func (w *cborWriter) writeInt(i int64) {
+	if i < 0 {
+		w.head(cborNegInt, uint64(-1-i))
+		return
+	}
+	w.head(cborUint, uint64(i))
+}
+
+func (w *cborWriter) writeUint(u uint64) { w.head(cborUint, u) }
+
+func (w *cborWriter) writeFloat32(f float32) {
+	w.buf = append(w.buf, 0xfa)
+	w.buf = appendUint32(w.buf, math.Float32bits(f))
+}
+
+func (w *cborWriter) writeFloat64(f float64) {
+	w.buf = append(w.buf, 0xfb)
+	w.buf = appendUint64(w.buf, math.Float64bits(f))
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.head(cborString, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) writeBytes(b []byte) {
+	w.head(cborBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *cborWriter) writeArrayHeader(n int) { w.head(cborArray, uint64(n)) }
+func (w *cborWriter) writeMapHeader(n int)   { w.head(cborMap, uint64(n)) }
+func (w *cborWriter) bytes() []byte          { return w.buf }
+
+func appendUint16(b []byte, v uint16) []byte {
+	var tmp [2]byte
+	binary.BigEndian.PutUint16(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	return append(b, tmp[:]...)
+}
@@ -0,0 +1,375 @@
+// Package codec encodes websocket packets as JSON, CBOR (RFC 8949) or
+// MessagePack. The binary encoders take the same values encoding/json does:
+// maps with string keys, slices, structs with json tags, strings, numbers,
+// booleans and nil.
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Codec turns a value into the bytes of one websocket message.
+type Codec interface {
+	// Name is what clients ask for in their hello.
+	Name() string
+
+	// Binary reports whether messages go out as binary frames.
+	Binary() bool
+
+	Marshal(v interface{}) ([]byte, error)
+}
+
+var (
+	JSON    Codec = jsonCodec{}
+	CBOR    Codec = binaryCodec{name: "cbor", newWriter: func() writer { return &cborWriter{} }}
+	MsgPack Codec = binaryCodec{name: "msgpack", newWriter: func() writer { return &msgpackWriter{} }}
+)
+
+// All lists the available codecs, JSON first.
+var All = []Codec{JSON, CBOR, MsgPack}
+
+// Lookup returns the codec with the given name.
+func Lookup(name string) (Codec, bool) {
+	for _, c := range All {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of All.
+func Names() []string {
+	names := make([]string, len(All))
+	for i, c := range All {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                          { return "json" }
+func (jsonCodec) Binary() bool                          { return false }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// writer is the part of an encoding that differs between CBOR and
+// MessagePack; encode walks values and calls it.
+type writer interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(i int64)
+	writeUint(u uint64)
+	writeFloat32(f float32)
+	writeFloat64(f float64)
+	writeString(s string)
+	writeBytes(b []byte)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+	bytes() []byte
+}
+
+type binaryCodec struct {
+	name      string
+	newWriter func() writer
+}
+
+func (c binaryCodec) Name() string { return c.name }
+func (c binaryCodec) Binary() bool { return true }
+
+func (c binaryCodec) Marshal(v interface{}) ([]byte, error) {
+	w := c.newWriter()
+	if err := encode(w, reflect.ValueOf(v)); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	return w.bytes(), nil
+}
+
+func encode(w writer, v reflect.Value) error {
+	if !v.IsValid() {
+		w.writeNil()
+		return nil
+	}
+
+	// types with their own JSON form, like json.RawMessage, are encoded
+	// through it
+	if m, ok := v.Interface().(json.Marshaler); ok && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+		raw, err := m.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			return err
+		}
+		return encode(w, reflect.ValueOf(decoded))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		return encode(w, v.Elem())
+
+	case reflect.Bool:
+		w.writeBool(v.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.writeInt(v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.writeUint(v.Uint())
+
+	case reflect.Float32:
+		w.writeFloat32(float32(v.Float()))
+
+	case reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("unsupported value %v", f)
+		}
+		w.writeFloat64(f)
+
+	case reflect.String:
+		w.writeString(v.String())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.writeBytes(v.Bytes())
+			return nil
+		}
+		return encodeArray(w, v)
+
+	case reflect.Array:
+		return encodeArray(w, v)
+
+	case reflect.Map:
+		if v.IsNil() {
+			w.writeNil()
+			return nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		w.writeMapHeader(len(keys))
+		for _, k := range keys {
+			w.writeString(k.String())
+			if err := encode(w, v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		fields := structFields(v)
+		w.writeMapHeader(len(fields))
+		for _, f := range fields {
+			w.writeString(f.name)
+			if err := encode(w, f.value); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func encodeArray(w writer, v reflect.Value) error {
+	w.writeArrayHeader(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := encode(w, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type structField struct {
+	name  string
+	value reflect.Value
+}
+
+// structFields returns the exported fields of v named and filtered by their
+// json tags, with the fields of embedded structs promoted the way
+// encoding/json does.
+func structFields(v reflect.Value) []structField {
+	infos := cachedFields(v.Type())
+	fields := make([]structField, 0, len(infos))
+	for _, info := range infos {
+		fv, ok := fieldByIndex(v, info.index)
+		if !ok || info.omitEmpty && isEmpty(fv) {
+			continue
+		}
+		fields = append(fields, structField{name: info.name, value: fv})
+	}
+	return fields
+}
+
+// fieldByIndex is v.FieldByIndex that reports a nil embedded pointer on the
+// way instead of panicking.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldInfo is where a struct type keeps a field it encodes.
+type fieldInfo struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []fieldInfo
+
+func cachedFields(t reflect.Type) []fieldInfo {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]fieldInfo)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]fieldInfo)
+}
+
+// typeFields lists the fields encoding/json encodes for t: embedded structs
+// are walked breadth first, a shallower field hides deeper ones of the same
+// name, a tagged one wins at the same depth and otherwise both are dropped.
+func typeFields(t reflect.Type) []fieldInfo {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []fieldInfo
+	next := []embedded{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if sf.PkgPath != "" && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				parts := strings.Split(tag, ",")
+				name := parts[0]
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+
+				info := fieldInfo{name: name, index: index, tagged: name != ""}
+				if name == "" {
+					info.name = sf.Name
+				}
+				for _, opt := range parts[1:] {
+					if opt == "omitempty" {
+						info.omitEmpty = true
+					}
+				}
+				fields = append(fields, info)
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		return a.tagged && !b.tagged
+	})
+
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		first := fields[i]
+		if j-i == 1 || len(fields[i+1].index) > len(first.index) || first.tagged && !fields[i+1].tagged {
+			dominant = append(dominant, first)
+		}
+		i = j
+	}
+
+	sort.Slice(dominant, func(i, j int) bool {
+		a, b := dominant[i].index, dominant[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return dominant
+}
+
+// isEmpty reports whether omitempty drops v, by the rules of encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
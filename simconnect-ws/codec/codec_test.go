@@ -0,0 +1,250 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type vector struct {
+	value interface{}
+	hex   string
+}
+
+func testVectors(t *testing.T, c Codec, vectors []vector) {
+	t.Helper()
+	for _, v := range vectors {
+		got, err := c.Marshal(v.value)
+		if err != nil {
+			t.Errorf("%#v: %v", v.value, err)
+			continue
+		}
+		want, err := hex.DecodeString(v.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%#v: got %x, want %x", v.value, got, want)
+		}
+	}
+}
+
+func count(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i + 1
+	}
+	return s
+}
+
+// the examples of RFC 8949 appendix A the encoder writes in the same form
+func TestCBORVectors(t *testing.T) {
+	testVectors(t, CBOR, []vector{
+		{0, "00"},
+		{1, "01"},
+		{10, "0a"},
+		{23, "17"},
+		{24, "1818"},
+		{25, "1819"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(18446744073709551615), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-10, "29"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{float32(100000.0), "fa47c35000"},
+		{float32(3.4028234663852886e+38), "fa7f7fffff"},
+		{1.0e+300, "fb7e37e43c8800759c"},
+		{-4.1, "fbc010666666666666"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{[]byte{}, "40"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{"", "60"},
+		{"a", "6161"},
+		{"IETF", "6449455446"},
+		{"\"\\", "62225c"},
+		{"ü", "62c3bc"},
+		{"水", "63e6b0b4"},
+		{[]int{}, "80"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]interface{}{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{count(25), "98190102030405060708090a0b0c0d0e0f101112131415161718181819"},
+		{map[string]int{}, "a0"},
+		{map[string]interface{}{"a": 1, "b": []int{2, 3}}, "a26161016162820203"},
+		{[]interface{}{"a", map[string]string{"b": "c"}}, "826161a161626163"},
+		{map[string]string{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}, "a56161614161626142616361436164614461656145"},
+	})
+}
+
+// one value of each format family of the MessagePack spec, at its bounds
+func TestMsgPackVectors(t *testing.T) {
+	testVectors(t, MsgPack, []vector{
+		{nil, "c0"},
+		{false, "c2"},
+		{true, "c3"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{255, "ccff"},
+		{256, "cd0100"},
+		{65535, "cdffff"},
+		{65536, "ce00010000"},
+		{int64(4294967296), "cf0000000100000000"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{-128, "d080"},
+		{-129, "d1ff7f"},
+		{-32768, "d18000"},
+		{-32769, "d2ffff7fff"},
+		{int64(-2147483649), "d3ffffffff7fffffff"},
+		{float32(1.5), "ca3fc00000"},
+		{1.5, "cb3ff8000000000000"},
+		{"", "a0"},
+		{"a", "a161"},
+		{strings.Repeat("x", 31), "bf" + strings.Repeat("78", 31)},
+		{strings.Repeat("x", 32), "d920" + strings.Repeat("78", 32)},
+		{strings.Repeat("x", 256), "da0100" + strings.Repeat("78", 256)},
+		{[]byte{1, 2, 3}, "c403010203"},
+		{[]int{}, "90"},
+		{[]int{1, 2}, "920102"},
+		{count(15), "9f0102030405060708090a0b0c0d0e0f"},
+		{count(16), "dc00100102030405060708090a0b0c0d0e0f10"},
+		{map[string]int{}, "80"},
+		{map[string]int{"a": 1}, "81a16101"},
+	})
+}
+
+func TestUnsupportedFloats(t *testing.T) {
+	for _, c := range []Codec{CBOR, MsgPack} {
+		for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			if _, err := c.Marshal(f); err == nil {
+				t.Errorf("%s encoded %v", c.Name(), f)
+			}
+		}
+	}
+}
+
+type inner struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Hidden    string  `json:"-"`
+}
+
+type Named struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+}
+
+type shadowing struct {
+	inner
+	*Named
+	ID    string `json:"id"`
+	Extra string `json:"extra,omitempty"`
+}
+
+type Left struct{ Value int }
+type Right struct{ Value int }
+
+type ambiguous struct {
+	Left
+	Right
+	Other int `json:"other"`
+}
+
+type taggedEmbed struct {
+	Named `json:"named"`
+	Seq   uint64 `json:"seq"`
+}
+
+// structs encode to the keys encoding/json gives them, in its order
+func TestStructFieldsMatchJSON(t *testing.T) {
+	for _, v := range []interface{}{
+		inner{Latitude: 47.5, Longitude: -122.25, Hidden: "x"},
+		shadowing{inner: inner{Latitude: 1}, Named: &Named{Name: "n", ID: 3}, ID: "outer"},
+		shadowing{ID: "nil embedded pointer"},
+		ambiguous{Left{1}, Right{2}, 3},
+		taggedEmbed{Named{"n", 1}, 2},
+	} {
+		want := jsonKeys(t, v)
+
+		fields := structFields(reflect.ValueOf(v))
+		got := make([]string, len(fields))
+		for i, f := range fields {
+			got[i] = f.name
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%T: got fields %v, want %v", v, got, want)
+		}
+	}
+}
+
+// a struct and the map encoding/json turns it into encode alike, keys aside
+func TestStructRoundTrip(t *testing.T) {
+	v := shadowing{inner: inner{Latitude: 47.5, Longitude: 8.5}, Named: &Named{Name: "n", ID: 3}, ID: "outer", Extra: "e"}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf, &m); err != nil {
+		t.Fatal(err)
+	}
+	sorted := struct {
+		Extra     string  `json:"extra"`
+		ID        string  `json:"id"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Name      string  `json:"name"`
+	}{"e", "outer", 47.5, 8.5, "n"}
+
+	for _, c := range []Codec{CBOR, MsgPack} {
+		fromMap, err := c.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromStruct, err := c.Marshal(sorted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(fromMap, fromStruct) {
+			t.Errorf("%s: map %x, struct %x", c.Name(), fromMap, fromStruct)
+		}
+	}
+}
+
+// jsonKeys returns the object keys json.Marshal writes for v, in order.
+func jsonKeys(t *testing.T, v interface{}) []string {
+	t.Helper()
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
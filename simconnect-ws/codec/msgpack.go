@@ -0,0 +1,128 @@
+package codec
+
+import (
+	"math"
+)
+
+// msgpackWriter writes MessagePack values in their shortest form.
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) writeNil() { w.buf = append(w.buf, 0xc0) }
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *msgpackWriter) writeInt(i int64) {
+	switch {
+	case i >= 0:
+		w.writeUint(uint64(i))
+	case i >= -32:
+		w.buf = append(w.buf, byte(i)) // negative fixint
+	case i >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		w.buf = append(w.buf, 0xd1)
+		w.buf = appendUint16(w.buf, uint16(i))
+	case i >= math.MinInt32:
+		w.buf = append(w.buf, 0xd2)
+		w.buf = appendUint32(w.buf, uint32(i))
+	default:
+		w.buf = append(w.buf, 0xd3)
+		w.buf = appendUint64(w.buf, uint64(i))
+	}
+}
+
+func (w *msgpackWriter) writeUint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		w.buf = append(w.buf, byte(u)) // positive fixint
+	case u <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		w.buf = append(w.buf, 0xcd)
+		w.buf = appendUint16(w.buf, uint16(u))
+	case u <= math.MaxUint32:
+		w.buf = append(w.buf, 0xce)
+		w.buf = appendUint32(w.buf, uint32(u))
+	default:
+		w.buf = append(w.buf, 0xcf)
+		w.buf = appendUint64(w.buf, u)
+	}
+}
+
+func (w *msgpackWriter) writeFloat32(f float32) {
+	w.buf = append(w.buf, 0xca)
+	w.buf = appendUint32(w.buf, math.Float32bits(f))
+}
+
+func (w *msgpackWriter) writeFloat64(f float64) {
+	w.buf = append(w.buf, 0xcb)
+	w.buf = appendUint64(w.buf, math.Float64bits(f))
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xda)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdb)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) writeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xc5)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xc6)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, b...)
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n <= 15:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xdc)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdd)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n <= 15:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xde)
+		w.buf = appendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdf)
+		w.buf = appendUint32(w.buf, uint32(n))
+	}
+}
+
+func (w *msgpackWriter) bytes() []byte { return w.buf }
@@ -0,0 +1,298 @@
+// Package logging writes levelled, structured log entries as logfmt or JSON
+// lines, with a level per subsystem.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Level is how important an entry is.
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < 0 || int(l) >= len(levelNames) {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, use one of %s", s, strings.Join(levelNames, ", "))
+}
+
+// Formats are the names SetFormat takes.
+var Formats = []string{"logfmt", "json"}
+
+var (
+	mu           sync.RWMutex
+	defaultLevel = Info
+	levels       = map[string]Level{}
+	jsonFormat   bool
+	out          io.Writer = os.Stdout
+)
+
+// parseLevels reads a spec of comma separated levels, where a bare level is
+// the default and subsystem=level sets one subsystem. Later entries win.
+func parseLevels(spec string) (Level, map[string]Level, error) {
+	def, bySubsystem := Info, map[string]Level{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, levelName := "", part
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name, levelName = strings.ToLower(strings.TrimSpace(part[:i])), part[i+1:]
+			if name == "" {
+				return 0, nil, fmt.Errorf("expected subsystem=level, got %q", part)
+			}
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return 0, nil, err
+		}
+		if name == "" {
+			def = level
+		} else {
+			bySubsystem[name] = level
+		}
+	}
+	return def, bySubsystem, nil
+}
+
+// CheckLevels reports whether SetLevels would take spec.
+func CheckLevels(spec string) error {
+	_, _, err := parseLevels(spec)
+	return err
+}
+
+// SetLevels sets the levels from a spec like "info,websocket=debug". A bare
+// level is the default for subsystems not named; later entries win.
+func SetLevels(spec string) error {
+	def, bySubsystem, err := parseLevels(spec)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	defaultLevel, levels = def, bySubsystem
+	return nil
+}
+
+// SetFormat picks logfmt or json lines.
+func SetFormat(name string) error {
+	switch strings.ToLower(name) {
+	case "logfmt", "":
+		setJSON(false)
+	case "json":
+		setJSON(true)
+	default:
+		return fmt.Errorf("unknown log format %q, use one of %s", name, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+func setJSON(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	jsonFormat = on
+}
+
+// SetOutput sends entries to w, stdout by default.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = w
+}
+
+// Logger writes the entries of one subsystem, with fields attached to each.
+type Logger struct {
+	subsystem string
+	fields    []interface{}
+}
+
+// For returns the logger of a subsystem.
+func For(subsystem string) *Logger {
+	return &Logger{subsystem: strings.ToLower(subsystem)}
+}
+
+// With returns a logger that adds the key, value pairs kv to every entry.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	return &Logger{subsystem: l.subsystem, fields: fields}
+}
+
+// Ctx returns l with the request ID of ctx, if it has one.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	if id := RequestID(ctx); id != "" {
+		return l.With("req", id)
+	}
+	return l
+}
+
+// Enabled reports whether entries at level are written, to skip work for
+// entries that would not be.
+func (l *Logger) Enabled(level Level) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	min, ok := levels[l.subsystem]
+	if !ok {
+		min = defaultLevel
+	}
+	return level >= min
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) { l.log(Debug, msg, kv) }
+func (l *Logger) Info(msg string, kv ...interface{})  { l.log(Info, msg, kv) }
+func (l *Logger) Warn(msg string, kv ...interface{})  { l.log(Warn, msg, kv) }
+func (l *Logger) Error(msg string, kv ...interface{}) { l.log(Error, msg, kv) }
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := make([]interface{}, 0, 8+len(l.fields)+len(kv))
+	fields = append(fields,
+		"time", time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
+		"level", level.String(),
+		"sys", l.subsystem,
+		"msg", msg,
+	)
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	if len(fields)%2 != 0 {
+		fields = append(fields[:len(fields)-1], "extra", fields[len(fields)-1])
+	}
+
+	mu.RLock()
+	asJSON, w := jsonFormat, out
+	mu.RUnlock()
+
+	var buf bytes.Buffer
+	if asJSON {
+		writeJSON(&buf, fields)
+	} else {
+		writeLogfmt(&buf, fields)
+	}
+	buf.WriteByte('\n')
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	w.Write(buf.Bytes())
+}
+
+// writeMu keeps entries from interleaving.
+var writeMu sync.Mutex
+
+// value turns v into something the format can write; JSON keeps maps,
+// slices and structs as they are.
+func value(v interface{}, asJSON bool) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	default:
+		if asJSON {
+			return v
+		}
+		return fmt.Sprintf("%+v", v)
+	}
+}
+
+func writeLogfmt(buf *bytes.Buffer, fields []interface{}) {
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(fmt.Sprint(fields[i]))
+		buf.WriteByte('=')
+
+		s := fmt.Sprint(value(fields[i+1], false))
+		if needsQuotes(s) {
+			s = strconv.Quote(s)
+		}
+		buf.WriteString(s)
+	}
+}
+
+func needsQuotes(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(buf *bytes.Buffer, fields []interface{}) {
+	buf.WriteByte('{')
+	for i := 0; i < len(fields); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(fmt.Sprint(fields[i]))
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		v, err := json.Marshal(value(fields[i+1], true))
+		if err != nil {
+			v, _ = json.Marshal(fmt.Sprint(fields[i+1]))
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying the request ID id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
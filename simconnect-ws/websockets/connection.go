@@ -2,14 +2,18 @@ package websockets
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/kivle/msfs2020-go/simconnect-ws/logging"
 )
 
 const (
@@ -24,6 +28,15 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 2048
+
+	// Queued frames at which a peer counts as falling behind.
+	slowBacklog = 64
+
+	// Queued frames at which new frames are refused.
+	maxBacklog = 1024
+
+	// Time a peer may stay behind before it is disconnected.
+	maxLag = 10 * time.Second
 )
 
 var (
@@ -31,32 +44,71 @@ var (
 	space   = []byte{' '}
 )
 
-func NewUpgrader(allowAllOrigins bool) websocket.Upgrader {
-	allowedOrigins := map[string]bool{
-		"https://kivle.github.io":           true,
-		"https://kivle.github.io/msfs-map":  true,
-		"https://github.com/kivle/msfs-map": true,
+// DefaultOrigins are the remote origins allowed besides local ones unless
+// SetOrigins says otherwise.
+var DefaultOrigins = []string{
+	"https://kivle.github.io",
+	"https://kivle.github.io/msfs-map",
+	"https://github.com/kivle/msfs-map",
+}
+
+// originPolicy decides which origins may open websockets. It can change
+// while the server runs.
+type originPolicy struct {
+	mu       sync.RWMutex
+	allowAll bool
+	allowed  map[string]bool
+}
+
+func newOriginPolicy(allowAll bool, origins []string) *originPolicy {
+	p := &originPolicy{}
+	p.set(allowAll, origins)
+	return p
+}
+
+func (p *originPolicy) set(allowAll bool, origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[strings.ToLower(strings.TrimRight(o, "/"))] = true
 	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.allowAll = allowAll
+	p.allowed = allowed
+}
+
+func (p *originPolicy) check(r *http.Request) bool {
+	return p.allows(r.Header.Get("Origin"))
+}
+
+// allows reports whether pages from origin may connect.
+func (p *originPolicy) allows(origin string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.allowAll {
+		return true
+	}
+	if origin == "" {
+		return false
+	}
+	originLower := strings.ToLower(origin)
+	if isAllowedLocalOrigin(originLower) {
+		return true
+	}
+	return p.allowed[originLower]
+}
+
+func NewUpgrader(allowAllOrigins bool) websocket.Upgrader {
+	return newUpgrader(newOriginPolicy(allowAllOrigins, DefaultOrigins))
+}
+
+func newUpgrader(p *originPolicy) websocket.Upgrader {
 	return websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
-		CheckOrigin: func(r *http.Request) bool {
-			if allowAllOrigins {
-				return true
-			}
-			origin := r.Header.Get("Origin")
-			if origin == "" {
-				return false
-			}
-			originLower := strings.ToLower(origin)
-			if isAllowedLocalOrigin(originLower) {
-				return true
-			}
-			if allowedOrigins[originLower] {
-				return true
-			}
-			return false
-		},
+		CheckOrigin:     p.check,
 	}
 }
 
@@ -92,28 +144,275 @@ type ReceiveMessage struct {
 	Connection *Connection
 }
 
+// Frame is one outbound websocket message.
+//
+// Frames with a Stream carry the latest state of that stream: while a
+// connection is behind, a newer frame of the same stream replaces the queued
+// one instead of adding to the backlog. Frames without a Stream are events
+// and are always delivered in order.
+type Frame struct {
+	Binary bool
+	Data   []byte
+	Stream string
+}
+
+// TextFrame wraps buf in a text Frame.
+func TextFrame(buf []byte) Frame {
+	return Frame{Data: buf}
+}
+
 type Connection struct {
-	socket    *Websocket
-	conn      *websocket.Conn
-	Send      chan []byte
-	SendQueue chan []byte
+	// written by writePump, read by Stats; first for 64-bit alignment
+	bytesSent  uint64
+	framesSent uint64
+
+	id     uint64
+	socket *Websocket
+	conn   *websocket.Conn
+	ctx    context.Context
+	log    *logging.Logger
+
+	origin      string
+	remoteAddr  string
+	connectedAt time.Time
+
+	mu          sync.Mutex
+	closed      bool
+	closing     string // close reason once the queue is flushed
+	queue       []Frame
+	streams     map[string]int // index of each stream's frame in queue
+	dropped     map[string]uint64
+	droppedAll  uint64 // every frame dropped since c opened
+	behindSince time.Time
+	wake        chan struct{}
+	done        chan struct{}
+}
+
+func newConnection(s *Websocket, conn *websocket.Conn, r *http.Request) *Connection {
+	id := atomic.AddUint64(&lastConnectionID, 1)
+	return &Connection{
+		id:          id,
+		socket:      s,
+		conn:        conn,
+		ctx:         r.Context(),
+		log:         logger.Ctx(r.Context()).With("conn", id),
+		origin:      r.Header.Get("Origin"),
+		remoteAddr:  r.RemoteAddr,
+		connectedAt: time.Now(),
+		streams:     map[string]int{},
+		dropped:     map[string]uint64{},
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+// lastConnectionID numbers connections in the order they open.
+var lastConnectionID uint64
+
+// ID tells c apart from every other connection of this process.
+func (c *Connection) ID() uint64 {
+	return c.id
+}
+
+// Logger is the websocket logger with the IDs of c and its request.
+func (c *Connection) Logger() *logging.Logger {
+	return c.log
+}
+
+// Context is the context of the request c was upgraded from.
+func (c *Connection) Context() context.Context {
+	return c.ctx
+}
+
+// Origin is the Origin header c was opened with.
+func (c *Connection) Origin() string {
+	return c.origin
+}
+
+// RemoteAddr is the address c comes from.
+func (c *Connection) RemoteAddr() string {
+	return c.remoteAddr
+}
+
+// ConnectedAt is when c was opened.
+func (c *Connection) ConnectedAt() time.Time {
+	return c.connectedAt
+}
+
+// QueueLen is how many frames wait to be written to c.
+func (c *Connection) QueueLen() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Lag is how long c has been falling behind, or 0.
+func (c *Connection) Lag(now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.behindSince.IsZero() {
+		return 0
+	}
+	return now.Sub(c.behindSince)
+}
+
+// ConnectionStats are the counters of one connection.
+type ConnectionStats struct {
+	Queued     int
+	Dropped    uint64
+	BytesSent  uint64
+	FramesSent uint64
+}
+
+// Stats returns the counters of c since it opened.
+func (c *Connection) Stats() ConnectionStats {
+	c.mu.Lock()
+	queued, dropped := len(c.queue), c.droppedAll
+	c.mu.Unlock()
+
+	return ConnectionStats{
+		Queued:     queued,
+		Dropped:    dropped,
+		BytesSent:  atomic.LoadUint64(&c.bytesSent),
+		FramesSent: atomic.LoadUint64(&c.framesSent),
+	}
 }
 
 func (c *Connection) Run() {
 	go c.readPump()
-	go c.writer()
 	c.writePump()
 }
 
 func (c *Connection) SendPacket(data map[string]interface{}) {
 	buf, _ := json.Marshal(data)
-	c.Send <- buf
+	c.TrySend(TextFrame(buf))
+}
+
+// TrySend queues f without blocking. It reports false if the connection is
+// closed or too far behind to take more.
+func (c *Connection) TrySend(f Frame) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.closing != "" || f.Data == nil {
+		return false
+	}
+
+	if i, ok := c.streams[f.Stream]; ok && f.Stream != "" {
+		c.queue[i] = f
+		c.dropped[f.Stream]++
+		c.droppedAll++
+	} else {
+		if len(c.queue) >= maxBacklog {
+			c.dropped[f.Stream]++
+			c.droppedAll++
+			c.markBehind()
+			return false
+		}
+		if f.Stream != "" {
+			c.streams[f.Stream] = len(c.queue)
+		}
+		c.queue = append(c.queue, f)
+	}
+	if len(c.queue) >= slowBacklog {
+		c.markBehind()
+	}
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// markBehind notes when c started lagging; c.mu is held.
+func (c *Connection) markBehind() {
+	if c.behindSince.IsZero() {
+		c.behindSince = time.Now()
+	}
+}
+
+// TakeDropped returns how many frames of each stream were replaced or
+// refused since the last call. Dropped events are counted under "".
+func (c *Connection) TakeDropped() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.dropped) == 0 {
+		return nil
+	}
+	d := c.dropped
+	c.dropped = map[string]uint64{}
+	return d
+}
+
+// lagging reports whether c has been behind since before now-max.
+func (c *Connection) lagging(now time.Time, max time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.behindSince.IsZero() && now.Sub(c.behindSince) > max
+}
+
+// take empties the queue and returns what was in it.
+func (c *Connection) take() []Frame {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	q := c.queue
+	c.queue = nil
+	c.streams = map[string]int{}
+	return q
+}
+
+// caughtUp clears the lag mark once everything queued has been written.
+func (c *Connection) caughtUp() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.queue) < slowBacklog {
+		c.behindSince = time.Time{}
+	}
+}
+
+// closeWith makes c send what it has queued, then a close frame with
+// reason.
+func (c *Connection) closeWith(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.closing != "" {
+		return
+	}
+	c.closing = reason
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// closeReason returns why c is closing, or "".
+func (c *Connection) closeReason() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closing
+}
+
+func (c *Connection) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.queue = nil
+	close(c.done)
 }
 
 func (c *Connection) SendError(target string, msg string) {
 	pkt := map[string]string{"target": target, "type": "error", "message": msg}
 	buf, _ := json.Marshal(pkt)
-	c.Send <- buf
+	c.TrySend(TextFrame(buf))
 }
 
 func (c *Connection) readPump() {
@@ -127,43 +426,18 @@ func (c *Connection) readPump() {
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
-				//log.Printf("error: %v", err)
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				c.log.Warn("connection closed unexpectedly", "err", err)
+			} else {
+				c.log.Debug("connection closed", "err", err)
 			}
-			//log.Printf("error: %v", err)
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		c.socket.ReceiveMessages <- ReceiveMessage{
-			Message:    message,
-			Connection: c,
-		}
-	}
-}
-
-func (c *Connection) writer() {
-	var buf bytes.Buffer
-	flushticker := time.NewTicker(time.Millisecond * 16)
-
-	defer func() {
-		flushticker.Stop()
-	}()
-
-	for {
 		select {
-		case message, ok := <-c.Send:
-			if !ok {
-				close(c.SendQueue)
-				return
-			}
-			buf.Write(message)
-			buf.WriteString("\n")
-
-		case <-flushticker.C:
-			message, err := buf.ReadBytes('\n')
-			if err == nil {
-				c.SendQueue <- message
-			}
+		case c.socket.ReceiveMessages <- ReceiveMessage{Message: message, Connection: c}:
+		case <-c.socket.stopping:
+			// nobody reads messages any more
 		}
 	}
 }
@@ -178,29 +452,48 @@ func (c *Connection) writePump() {
 
 	for {
 		select {
-		case message, ok := <-c.SendQueue:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
-				return
+		case <-c.wake:
+			for _, f := range c.take() {
+				if err := c.write(f); err != nil {
+					c.log.Debug("write failed", "err", err)
+					return
+				}
 			}
+			c.caughtUp()
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
+			if reason := c.closeReason(); reason != "" {
+				c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, reason))
 				return
 			}
-			w.Write(message)
 
-			if err := w.Close(); err != nil {
-				fmt.Println(err)
-				return
-			}
+		case <-c.done:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
-				fmt.Println(err)
+				c.log.Debug("ping failed", "err", err)
 				return
 			}
 		}
 	}
 }
+
+func (c *Connection) write(f Frame) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+	messageType := websocket.TextMessage
+	if f.Binary {
+		messageType = websocket.BinaryMessage
+	}
+	if err := c.conn.WriteMessage(messageType, f.Data); err != nil {
+		return err
+	}
+	atomic.AddUint64(&c.framesSent, 1)
+	atomic.AddUint64(&c.bytesSent, uint64(len(f.Data)))
+	atomic.AddUint64(&c.socket.stats.bytesSent, uint64(len(f.Data)))
+	return nil
+}
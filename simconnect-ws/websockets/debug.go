@@ -1,4 +0,0 @@
-package websockets
-
-// Debug enables verbose logging inside the websocket package.
-var Debug bool
@@ -1,33 +1,93 @@
 package websockets
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/kivle/msfs2020-go/simconnect-ws/logging"
 )
 
+var logger = logging.For("websocket")
+
 type Websocket struct {
+	stats hubStats // first for 64-bit alignment
+
 	connections     map[*Connection]bool
 	broadcast       chan []byte
+	broadcastFunc   chan func(*Connection) Frame
 	register        chan *Connection
+	list            chan chan []*Connection
 	unregister      chan *Connection
 	ReceiveMessages chan ReceiveMessage
 	NewConnection   chan ReceiveMessage
 	upgrader        websocket.Upgrader
+	origins         *originPolicy
+
+	hooksMu    sync.Mutex
+	openHooks  []func(*Connection)
+	closeHooks []func(*Connection)
+
+	shutdown    chan string
+	stopping    chan struct{}
+	stopOnce    sync.Once
+	closeReason string // set once shutting down, owned by Run
+	servers     sync.WaitGroup
+}
+
+// hubStats are the counters behind Stats, updated atomically.
+type hubStats struct {
+	bytesSent     uint64
+	broadcasts    uint64
+	broadcastTime uint64 // nanoseconds
+}
+
+// Stats are the counters of a Websocket since it was created.
+type Stats struct {
+	// BytesSent counts the payload bytes written to every connection,
+	// closed ones included.
+	BytesSent uint64
+	// Broadcasts counts the broadcasts fanned out to the connections and
+	// BroadcastTime the time spent queueing them.
+	Broadcasts    uint64
+	BroadcastTime time.Duration
+}
+
+// Stats returns the counters of s.
+func (s *Websocket) Stats() Stats {
+	return Stats{
+		BytesSent:     atomic.LoadUint64(&s.stats.bytesSent),
+		Broadcasts:    atomic.LoadUint64(&s.stats.broadcasts),
+		BroadcastTime: time.Duration(atomic.LoadUint64(&s.stats.broadcastTime)),
+	}
+}
+
+// fannedOut counts a broadcast that started at start.
+func (s *Websocket) fannedOut(start time.Time) {
+	atomic.AddUint64(&s.stats.broadcasts, 1)
+	atomic.AddUint64(&s.stats.broadcastTime, uint64(time.Since(start)))
 }
 
 func New(allowAllOrigins bool) *Websocket {
+	origins := newOriginPolicy(allowAllOrigins, DefaultOrigins)
 	ws := &Websocket{
 		broadcast:       make(chan []byte, 256),
+		broadcastFunc:   make(chan func(*Connection) Frame, 256),
 		register:        make(chan *Connection),
+		list:            make(chan chan []*Connection),
 		unregister:      make(chan *Connection),
 		connections:     make(map[*Connection]bool),
 		ReceiveMessages: make(chan ReceiveMessage, 256),
 		NewConnection:   make(chan ReceiveMessage, 256),
-		upgrader:        NewUpgrader(allowAllOrigins),
+		shutdown:        make(chan string),
+		stopping:        make(chan struct{}),
+		upgrader:        newUpgrader(origins),
+		origins:         origins,
 	}
 	go ws.Run()
 
@@ -35,70 +95,172 @@ func New(allowAllOrigins bool) *Websocket {
 }
 
 func (s *Websocket) ConnectionCount() int {
-	return len(s.connections)
+	return len(s.Connections())
+}
+
+// Connections returns the open connections.
+func (s *Websocket) Connections() []*Connection {
+	reply := make(chan []*Connection, 1)
+	s.list <- reply
+	return <-reply
 }
 
+// Serve upgrades r to a websocket connection and runs it until it closes or
+// the context of r ends.
 func (s *Websocket) Serve(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println(err)
+		// the upgrader already answered with an error status
+		logger.Ctx(r.Context()).Warn("websocket upgrade failed", "origin", r.Header.Get("Origin"), "remote", r.RemoteAddr, "err", err)
 		return
 	}
-	if Debug {
-		log.Printf("upgraded websocket from origin %s\n", r.Header.Get("Origin"))
-	}
 
-	c := &Connection{
-		socket:    s,
-		conn:      conn,
-		Send:      make(chan []byte, 256),
-		SendQueue: make(chan []byte),
-	}
+	s.servers.Add(1)
+	defer s.servers.Done()
+
+	c := newConnection(s, conn, r)
 	s.register <- c
 
+	go func() {
+		select {
+		case <-r.Context().Done():
+			// readPump fails and unregisters it
+			conn.Close()
+		case <-c.done:
+		}
+	}()
+
 	c.Run()
 }
 
+// Shutdown sends every connection what it has queued and a close frame with
+// reason, refuses new ones and waits for them to finish or ctx to end.
+func (s *Websocket) Shutdown(ctx context.Context, reason string) error {
+	s.stopOnce.Do(func() {
+		close(s.stopping)
+		s.shutdown <- reason
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.servers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetOrigins replaces the remote origins allowed to connect. Local origins
+// are always allowed.
+func (s *Websocket) SetOrigins(allowAll bool, origins []string) {
+	s.origins.set(allowAll, origins)
+}
+
+// AllowsOrigin reports whether pages from origin may open websockets.
+func (s *Websocket) AllowsOrigin(origin string) bool {
+	return s.origins.allows(origin)
+}
+
+// OnOpen registers f to be called when a connection opens, before anything
+// else is sent to it.
+func (s *Websocket) OnOpen(f func(*Connection)) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.openHooks = append(s.openHooks, f)
+}
+
+// OnClose registers f to be called when a connection goes away, before it
+// stops taking frames.
+func (s *Websocket) OnClose(f func(*Connection)) {
+	s.hooksMu.Lock()
+	defer s.hooksMu.Unlock()
+	s.closeHooks = append(s.closeHooks, f)
+}
+
 func (s *Websocket) Broadcast(pkt map[string]interface{}) {
 	buf, _ := json.Marshal(pkt)
 	s.broadcast <- buf
 }
 
+// BroadcastFunc sends each connection the frame render returns for it,
+// skipping connections it returns a frame without data for.
+func (s *Websocket) BroadcastFunc(render func(*Connection) Frame) {
+	s.broadcastFunc <- render
+}
+
 func (h *Websocket) Run() {
+	lagCheck := time.NewTicker(time.Second)
+	defer lagCheck.Stop()
+
 	for {
 		select {
 		case c := <-h.register:
-			if Debug {
-				log.Printf("new browser connection; total=%d\n", len(h.connections)+1)
-			} else {
-				fmt.Println("new browser connection")
-			}
 			h.connections[c] = true
+			c.log.Info("connection opened", "origin", c.origin, "remote", c.remoteAddr, "total", len(h.connections))
+			if h.closeReason != "" {
+				c.closeWith(h.closeReason)
+				continue
+			}
+
+			h.hooksMu.Lock()
+			hooks := h.openHooks
+			h.hooksMu.Unlock()
+			for _, f := range hooks {
+				f(c)
+			}
 			h.NewConnection <- ReceiveMessage{Connection: c}
 		case c := <-h.unregister:
-			if Debug {
-				log.Printf("remove browser connection; total=%d\n", len(h.connections)-1)
-			} else {
-				fmt.Println("remove browser connection")
-			}
 			if _, ok := h.connections[c]; ok {
 				delete(h.connections, c)
-				close(c.Send)
+				c.log.Info("connection closed", "remote", c.remoteAddr, "total", len(h.connections))
+
+				h.hooksMu.Lock()
+				hooks := h.closeHooks
+				h.hooksMu.Unlock()
+				for _, f := range hooks {
+					f(c)
+				}
+
+				c.close()
 			}
+		case reply := <-h.list:
+			conns := make([]*Connection, 0, len(h.connections))
+			for c := range h.connections {
+				conns = append(conns, c)
+			}
+			reply <- conns
+		case reason := <-h.shutdown:
+			h.closeReason = reason
+			for c := range h.connections {
+				c.closeWith(reason)
+			}
+		case render := <-h.broadcastFunc:
+			start := time.Now()
+			for c := range h.connections {
+				if frame := render(c); frame.Data != nil {
+					c.TrySend(frame)
+				}
+			}
+			h.fannedOut(start)
 		case packet := <-h.broadcast:
-			if Debug {
-				log.Printf("broadcast to %d connections, payload %d bytes\n", len(h.connections), len(packet))
+			logger.Debug("broadcast", "connections", len(h.connections), "bytes", len(packet))
+			start := time.Now()
+			for c := range h.connections {
+				c.TrySend(TextFrame(packet))
 			}
+			h.fannedOut(start)
+		case now := <-lagCheck.C:
 			for c := range h.connections {
-				c.Send <- packet
-				/*
-					select {
-					case c.Send <- packet:
-					default:
-						close(c.Send)
-						delete(h.connections, c)
-					}
-				*/
+				if c.lagging(now, maxLag) {
+					c.log.Warn("disconnecting lagging connection", "behind", c.Lag(now), "max", maxLag)
+					// readPump fails and unregisters it
+					c.conn.Close()
+				}
 			}
 		}
 	}
@@ -0,0 +1,47 @@
+package simconnect
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// marshalArgs converts Backend.Call arguments to the words passed to a
+// SimConnect function, after the handle. Buffers referenced by the result are
+// returned in keep and must stay reachable until the call returns.
+//
+// float32 and float64 are passed as their IEEE 754 bit patterns. The x64
+// calling convention takes the first four floating point arguments in XMM0-3
+// and the rest on the stack; syscall loads XMM0-3 from the same words as the
+// integer registers, so the bit pattern ends up where the callee reads it.
+// Converting the value with uintptr(v) would truncate it to an integer.
+func marshalArgs(args []interface{}) (words []uintptr, keep [][]byte, err error) {
+	words = make([]uintptr, 0, len(args))
+	keep = make([][]byte, 0, len(args))
+
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case DWORD:
+			words = append(words, uintptr(v))
+		case float32:
+			words = append(words, uintptr(math.Float32bits(v)))
+		case float64:
+			words = append(words, uintptr(math.Float64bits(v)))
+		case string:
+			buf := []byte(v + "\x00")
+			keep = append(keep, buf)
+			words = append(words, uintptr(unsafe.Pointer(&buf[0])))
+		case []byte:
+			if len(v) == 0 {
+				words = append(words, 0)
+				continue
+			}
+			keep = append(keep, v)
+			words = append(words, uintptr(unsafe.Pointer(&v[0])))
+		default:
+			return nil, nil, fmt.Errorf("argument %d: unsupported type %T", i, arg)
+		}
+	}
+
+	return words, keep, nil
+}
@@ -0,0 +1,149 @@
+package simconnect
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"unsafe"
+)
+
+// recordingBackend keeps the arguments of every call instead of making it.
+type recordingBackend struct {
+	calls map[string][]interface{}
+}
+
+func (b *recordingBackend) Open(name string) error { return nil }
+func (b *recordingBackend) Close() error           { return nil }
+
+func (b *recordingBackend) Call(proc string, args ...interface{}) error {
+	if b.calls == nil {
+		b.calls = map[string][]interface{}{}
+	}
+	b.calls[proc] = args
+	return nil
+}
+
+func (b *recordingBackend) GetNextDispatch() (unsafe.Pointer, int32, error) {
+	return nil, rFail, nil
+}
+
+func newRecording(t *testing.T) (*SimConnect, *recordingBackend) {
+	t.Helper()
+	b := &recordingBackend{}
+	s, err := NewWithBackend("test", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, b
+}
+
+func TestMarshalArgsFloat32Bits(t *testing.T) {
+	for _, v := range []float32{0, float32(math.Copysign(0, -1)), 1, -1, 0.001, -273.15, math.MaxFloat32, math.SmallestNonzeroFloat32} {
+		words, _, err := marshalArgs([]interface{}{v})
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+		if want := uintptr(math.Float32bits(v)); words[0] != want {
+			t.Errorf("%v: got word %#x, want %#x", v, words[0], want)
+		}
+	}
+}
+
+func TestMarshalArgsFloat64Bits(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) < 8 {
+		t.Skip("float64 arguments need 64-bit words")
+	}
+	for _, v := range []float64{0, math.Copysign(0, -1), 1, -1, 47.4612, -122.3118, 1e-300, -math.MaxFloat64} {
+		words, _, err := marshalArgs([]interface{}{v})
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+		if want := uintptr(math.Float64bits(v)); words[0] != want {
+			t.Errorf("%v: got word %#x, want %#x", v, words[0], want)
+		}
+	}
+}
+
+func TestAddToDataDefinitionEpsilon(t *testing.T) {
+	for _, epsilon := range []float32{0, 0.5, -0.25, 1e-6} {
+		s, b := newRecording(t)
+		if err := s.AddToDataDefinition(1, "PLANE ALTITUDE", "feet", DATATYPE_FLOAT64, epsilon); err != nil {
+			t.Fatal(err)
+		}
+
+		args := b.calls["AddToDataDefinition"]
+		words, _, err := marshalArgs(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// defineID, name, unit, data type, epsilon, datum ID
+		if len(words) != 6 {
+			t.Fatalf("got %d words, want 6", len(words))
+		}
+		if want := uintptr(math.Float32bits(epsilon)); words[4] != want {
+			t.Errorf("epsilon %v: got word %#x, want %#x", epsilon, words[4], want)
+		}
+		if words[3] != uintptr(DATATYPE_FLOAT64) || words[5] != uintptr(UNUSED) {
+			t.Errorf("epsilon %v: data type or datum ID moved: %#x", epsilon, words)
+		}
+	}
+}
+
+func TestSetDataOnSimObjectInitPosition(t *testing.T) {
+	s, b := newRecording(t)
+	pos := DataInitPosition{
+		Latitude:  -33.9461,
+		Longitude: -151.1772,
+		Altitude:  0,
+		Pitch:     -2.5,
+		Bank:      math.Copysign(0, -1),
+		Heading:   359.99,
+		OnGround:  1,
+		Airspeed:  0,
+	}
+	if err := s.SetDataOnSimObject(2, OBJECT_ID_USER, 0, 0, SizeofDataInitPosition, unsafe.Pointer(&pos)); err != nil {
+		t.Fatal(err)
+	}
+
+	args := b.calls["SetDataOnSimObject"]
+	words, keep, err := marshalArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 6 || len(keep) != 1 {
+		t.Fatalf("got %d words and %d buffers, want 6 and 1", len(words), len(keep))
+	}
+	if words[4] != SizeofDataInitPosition {
+		t.Errorf("got size %d, want %d", words[4], SizeofDataInitPosition)
+	}
+
+	data := keep[0]
+	if words[5] != uintptr(unsafe.Pointer(&data[0])) {
+		t.Errorf("data word does not point at the buffer")
+	}
+	for i, want := range []float64{pos.Latitude, pos.Longitude, pos.Altitude, pos.Pitch, pos.Bank, pos.Heading} {
+		if got := binary.LittleEndian.Uint64(data[i*8:]); got != math.Float64bits(want) {
+			t.Errorf("field %d: got bits %#x, want %#x (%v)", i, got, math.Float64bits(want), want)
+		}
+	}
+	if got := binary.LittleEndian.Uint32(data[48:]); got != 1 {
+		t.Errorf("on ground: got %d, want 1", got)
+	}
+}
+
+func TestShowTextDuration(t *testing.T) {
+	for _, duration := range []float64{0, 5, -1, 2.5} {
+		s, b := newRecording(t)
+		if err := s.ShowText(TEXT_TYPE_PRINT_WHITE, duration, 0, "hello"); err != nil {
+			t.Fatal(err)
+		}
+
+		words, _, err := marshalArgs(b.calls["Text"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := uintptr(math.Float32bits(float32(duration))); words[1] != want {
+			t.Errorf("duration %v: got word %#x, want %#x", duration, words[1], want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package simconnect
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// Backend carries SimConnect calls to a simulator, or to something standing
+// in for one such as a Replayer.
+type Backend interface {
+	// Open connects to the simulator under the given client name.
+	Open(name string) error
+
+	// Close disconnects from the simulator.
+	Close() error
+
+	// Call invokes SimConnect_<proc>. The connection handle is owned by the
+	// backend and is not part of args. Arguments are DWORD, float32, float64,
+	// string (passed as a NUL-terminated C string) or []byte (passed as a
+	// pointer to its data).
+	Call(proc string, args ...interface{}) error
+
+	// GetNextDispatch returns the next queued message. The data is only valid
+	// until the following call. When nothing is queued r1 is E_FAIL.
+	GetNextDispatch() (ppData unsafe.Pointer, r1 int32, err error)
+}
+
+// ErrNoDLL is returned by NewDLLBackend on platforms without SimConnect.dll.
+var ErrNoDLL = errors.New("SimConnect.dll is only available on windows")
+
+// MessageBytes returns a copy of the message at ppData, using the size from its
+// Recv header. The result can be decoded with the UnmarshalBinary methods of
+// the Recv types.
+func MessageBytes(ppData unsafe.Pointer) []byte {
+	if ppData == nil {
+		return nil
+	}
+	size := (*Recv)(ppData).Size
+	buf := make([]byte, size)
+	copy(buf, (*[1 << 30]byte)(ppData)[:size:size])
+	return buf
+}
@@ -0,0 +1,409 @@
+package simconnect
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// Exception is a SIMCONNECT_RECV_EXCEPTION returned for a request.
+type Exception struct {
+	Code   DWORD // see SIMCONNECT_EXCEPTION
+	SendID DWORD // packet that caused it
+	Index  DWORD // index of the offending parameter
+}
+
+func (e *Exception) Error() string {
+	name, ok := ExceptionNames[e.Code]
+	if !ok {
+		name = fmt.Sprint(e.Code)
+	}
+	return fmt.Sprintf("simconnect exception %s (send %d, parameter %d)", name, e.SendID, e.Index)
+}
+
+// Client runs the dispatch loop of a SimConnect connection and hands each
+// response to the goroutine waiting for it. All methods are safe for
+// concurrent use; the blocking ones only return a result while Run is active.
+type Client struct {
+	dropped uint64 // atomic, first for alignment on 32-bit platforms
+
+	*SimConnect
+
+	mu      sync.Mutex
+	waiters map[DWORD]*waiter // by request ID
+	sent    map[DWORD]*waiter // by send ID, for exceptions
+
+	messages chan []byte
+}
+
+type waiter struct {
+	requestID DWORD
+	sendID    DWORD
+	msgs      chan []byte
+	errs      chan error
+	gone      chan struct{}
+}
+
+// NewClient wraps an open connection. Call Run to start dispatching.
+func NewClient(s *SimConnect) *Client {
+	return &Client{
+		SimConnect: s,
+		waiters:    map[DWORD]*waiter{},
+		sent:       map[DWORD]*waiter{},
+		messages:   make(chan []byte, 256),
+	}
+}
+
+// Messages receives a copy of every message that is not the response to a
+// blocking call, such as events, exceptions and periodic data. It holds 256
+// messages; while it is full, further ones are dropped rather than holding up
+// the responses to blocking calls, and counted in Dropped.
+func (c *Client) Messages() <-chan []byte {
+	return c.messages
+}
+
+// Dropped returns how many messages did not fit in Messages.
+func (c *Client) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// Run polls for messages every interval until ctx is done, routing responses
+// to waiting calls and everything else to Messages.
+func (c *Client) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		for {
+			ppData, r1, _ := c.GetNextDispatch()
+			if r1 < 0 || ppData == nil {
+				break
+			}
+
+			msg := MessageBytes(ppData)
+			if c.route(msg) {
+				continue
+			}
+
+			select {
+			case c.messages <- msg:
+			default:
+				atomic.AddUint64(&c.dropped, 1)
+			}
+		}
+	}
+}
+
+// route hands msg to its waiter and reports whether there was one.
+func (c *Client) route(msg []byte) bool {
+	if len(msg) < 16 {
+		return false
+	}
+
+	var w *waiter
+	var err error
+
+	c.mu.Lock()
+	switch binary.LittleEndian.Uint32(msg[8:]) {
+	case uint32(RECV_ID_EXCEPTION):
+		if len(msg) >= 24 {
+			e := &Exception{
+				Code:   DWORD(binary.LittleEndian.Uint32(msg[12:])),
+				SendID: DWORD(binary.LittleEndian.Uint32(msg[16:])),
+				Index:  DWORD(binary.LittleEndian.Uint32(msg[20:])),
+			}
+			w, err = c.sent[e.SendID], e
+		}
+	case uint32(RECV_ID_SIMOBJECT_DATA), uint32(RECV_ID_SIMOBJECT_DATA_BYTYPE),
+		uint32(RECV_ID_AIRPORT_LIST), uint32(RECV_ID_VOR_LIST),
+		uint32(RECV_ID_NDB_LIST), uint32(RECV_ID_WAYPOINT_LIST),
+		uint32(RECV_ID_ASSIGNED_OBJECT_ID):
+		w = c.waiters[DWORD(binary.LittleEndian.Uint32(msg[12:]))]
+	}
+	c.mu.Unlock()
+
+	if w == nil {
+		return false
+	}
+
+	if err != nil {
+		select {
+		case w.errs <- err:
+		case <-w.gone:
+			return false
+		}
+		return true
+	}
+
+	select {
+	case w.msgs <- msg:
+	case <-w.gone:
+		return false
+	}
+	return true
+}
+
+// request registers a waiter for requestID, sends the request and feeds each
+// response to handle until it reports done, an exception arrives or ctx ends.
+func (c *Client) request(ctx context.Context, requestID DWORD, send func() (DWORD, error), handle func(msg []byte) (bool, error)) error {
+	w := &waiter{
+		requestID: requestID,
+		msgs:      make(chan []byte, 16),
+		errs:      make(chan error, 1),
+		gone:      make(chan struct{}),
+	}
+
+	// hold the lock while sending so a fast exception can't miss the waiter
+	c.mu.Lock()
+	c.waiters[requestID] = w
+	sendID, err := send()
+	if err == nil {
+		w.sendID = sendID
+		c.sent[sendID] = w
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.waiters, w.requestID)
+		if c.sent[w.sendID] == w {
+			delete(c.sent, w.sendID)
+		}
+		c.mu.Unlock()
+		close(w.gone)
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case msg := <-w.msgs:
+			done, err := handle(msg)
+			if err != nil || done {
+				return err
+			}
+		case err := <-w.errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// GetOnce fills v, a pointer to a struct embedding RecvSimobjectDataByType
+// with name and unit tags, with the current values for the user aircraft.
+// The data definition is registered on first use.
+func (c *Client) GetOnce(ctx context.Context, v interface{}) error {
+	if err := checkDataStruct(reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	if err := c.RegisterDataDefinition(v); err != nil {
+		return err
+	}
+
+	defineID := c.GetDefineID(v)
+	requestID := c.GetRequestID()
+
+	return c.request(ctx, requestID, func() (DWORD, error) {
+		return c.callTracked("RequestDataOnSimObjectType", requestID, defineID, DWORD(0), SIMOBJECT_TYPE_USER)
+	}, func(msg []byte) (bool, error) {
+		copyStruct(v, msg)
+		return true, nil
+	})
+}
+
+// GetByType fills out, a pointer to a slice of structs like the ones GetOnce
+// takes, with one entry per object of simobjectType within radius meters.
+// The simulator sends nothing when no object matches, so ctx should carry a
+// deadline.
+func (c *Client) GetByType(ctx context.Context, radius, simobjectType DWORD, out interface{}) error {
+	outType := reflect.TypeOf(out)
+	if outType == nil || outType.Kind() != reflect.Ptr || outType.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("GetByType: out must be a pointer to a slice, got %v", outType)
+	}
+	elemType := outType.Elem().Elem()
+	if err := checkDataStruct(reflect.PtrTo(elemType)); err != nil {
+		return err
+	}
+
+	proto := reflect.New(elemType).Interface()
+	if err := c.RegisterDataDefinition(proto); err != nil {
+		return err
+	}
+
+	defineID := c.GetDefineID(proto)
+	requestID := c.GetRequestID()
+	list := reflect.ValueOf(out).Elem()
+	list.Set(list.Slice(0, 0))
+
+	return c.request(ctx, requestID, func() (DWORD, error) {
+		return c.callTracked("RequestDataOnSimObjectType", requestID, defineID, radius, simobjectType)
+	}, func(msg []byte) (bool, error) {
+		item := reflect.New(elemType)
+		copyStruct(item.Interface(), msg)
+		list.Set(reflect.Append(list, item.Elem()))
+
+		hdr := (*RecvSimobjectData)(unsafe.Pointer(&msg[0]))
+		return hdr.EntryNumber >= hdr.OutOf, nil
+	})
+}
+
+// AirportList returns every airport in the reality bubble.
+func (c *Client) AirportList(ctx context.Context) ([]DataFacilityAirport, error) {
+	var list []DataFacilityAirport
+	err := c.facilitiesList(ctx, FACILITY_LIST_TYPE_AIRPORT, func(msg []byte) (*RecvFacilityList, error) {
+		var recv RecvFacilityAirportList
+		if err := recv.UnmarshalBinary(msg); err != nil {
+			return nil, err
+		}
+		list = append(list, recv.Data...)
+		return &recv.RecvFacilityList, nil
+	})
+	return list, err
+}
+
+// WaypointList returns every waypoint in the reality bubble.
+func (c *Client) WaypointList(ctx context.Context) ([]DataFacilityWaypoint, error) {
+	var list []DataFacilityWaypoint
+	err := c.facilitiesList(ctx, FACILITY_LIST_TYPE_WAYPOINT, func(msg []byte) (*RecvFacilityList, error) {
+		var recv RecvFacilityWaypointList
+		if err := recv.UnmarshalBinary(msg); err != nil {
+			return nil, err
+		}
+		list = append(list, recv.Data...)
+		return &recv.RecvFacilityList, nil
+	})
+	return list, err
+}
+
+// NDBList returns every NDB in the reality bubble.
+func (c *Client) NDBList(ctx context.Context) ([]DataFacilityNDB, error) {
+	var list []DataFacilityNDB
+	err := c.facilitiesList(ctx, FACILITY_LIST_TYPE_NDB, func(msg []byte) (*RecvFacilityList, error) {
+		var recv RecvFacilityNDBList
+		if err := recv.UnmarshalBinary(msg); err != nil {
+			return nil, err
+		}
+		list = append(list, recv.Data...)
+		return &recv.RecvFacilityList, nil
+	})
+	return list, err
+}
+
+// VORList returns every VOR in the reality bubble.
+func (c *Client) VORList(ctx context.Context) ([]DataFacilityVOR, error) {
+	var list []DataFacilityVOR
+	err := c.facilitiesList(ctx, FACILITY_LIST_TYPE_VOR, func(msg []byte) (*RecvFacilityList, error) {
+		var recv RecvFacilityVORList
+		if err := recv.UnmarshalBinary(msg); err != nil {
+			return nil, err
+		}
+		list = append(list, recv.Data...)
+		return &recv.RecvFacilityList, nil
+	})
+	return list, err
+}
+
+// facilitiesList requests a facility list and passes each packet of it to
+// decode, which returns the packet header.
+func (c *Client) facilitiesList(ctx context.Context, facilityType DWORD, decode func([]byte) (*RecvFacilityList, error)) error {
+	requestID := c.GetRequestID()
+
+	return c.request(ctx, requestID, func() (DWORD, error) {
+		return c.callTracked("RequestFacilitiesList", facilityType, requestID)
+	}, func(msg []byte) (bool, error) {
+		hdr, err := decode(msg)
+		if err != nil {
+			return false, fmt.Errorf("facility list: %w", err)
+		}
+		return hdr.EntryNumber+1 >= hdr.OutOf, nil
+	})
+}
+
+// AICreateNonATCAircraft creates an aircraft at pos and returns its object ID.
+func (c *Client) AICreateNonATCAircraft(ctx context.Context, containerTitle, tailNumber string, pos DataInitPosition) (DWORD, error) {
+	// SimConnect_AICreateNonATCAircraft(
+	//   HANDLE hSimConnect,
+	//   const char * szContainerTitle,
+	//   const char * szTailNumber,
+	//   SIMCONNECT_DATA_INITPOSITION InitPos,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	// structs larger than 8 bytes are passed by reference in the x64 calling convention
+	initPos, _ := pos.MarshalBinary()
+
+	requestID := c.GetRequestID()
+	var objectID DWORD
+
+	err := c.request(ctx, requestID, func() (DWORD, error) {
+		return c.callTracked("AICreateNonATCAircraft", containerTitle, tailNumber, initPos, requestID)
+	}, func(msg []byte) (bool, error) {
+		assigned := (*RecvAssignedObjectID)(unsafe.Pointer(&msg[0]))
+		objectID = assigned.ObjectID
+		return true, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("AICreateNonATCAircraft %s: %w", containerTitle, err)
+	}
+
+	return objectID, nil
+}
+
+// AIRemoveObject removes an object created by one of the AICreate calls.
+func (c *Client) AIRemoveObject(objectID DWORD) error {
+	// SimConnect_AIRemoveObject(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_OBJECT_ID ObjectID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID
+	// );
+
+	err := c.call("AIRemoveObject", objectID, c.GetRequestID())
+	if err != nil {
+		return fmt.Errorf("AIRemoveObject for objectID %d: %w", objectID, err)
+	}
+
+	return nil
+}
+
+func checkDataStruct(t reflect.Type) error {
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a pointer to a data struct, got %v", t)
+	}
+	if t.Elem().NumField() == 0 || t.Elem().Field(0).Type != reflect.TypeOf(RecvSimobjectDataByType{}) {
+		return fmt.Errorf("%s must embed simconnect.RecvSimobjectDataByType first", t.Elem().Name())
+	}
+	return nil
+}
+
+// copyStruct copies a received message into the struct v points to.
+func copyStruct(v interface{}, msg []byte) {
+	size := int(reflect.TypeOf(v).Elem().Size())
+	if len(msg) < size {
+		size = len(msg)
+	}
+	dst := (*[1 << 30]byte)(unsafe.Pointer(reflect.ValueOf(v).Pointer()))[:size:size]
+	copy(dst, msg)
+}
+
+// MessagePointer returns a pointer to a message from Messages, to be cast to
+// the Recv type matching its ID like the result of GetNextDispatch.
+func MessagePointer(msg []byte) unsafe.Pointer {
+	if len(msg) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&msg[0])
+}
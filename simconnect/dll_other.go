@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package simconnect
+
+// NewDLLBackend always fails outside windows; use a Replayer instead.
+func NewDLLBackend() (Backend, error) {
+	return nil, ErrNoDLL
+}
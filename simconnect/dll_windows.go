@@ -0,0 +1,145 @@
+package simconnect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	dllOnce sync.Once
+	dllMod  *syscall.LazyDLL
+	dllErr  error
+
+	procsMu sync.Mutex
+	procs   = map[string]*syscall.LazyProc{}
+)
+
+func loadDLL() (*syscall.LazyDLL, error) {
+	dllOnce.Do(func() {
+		exePath, err := os.Executable()
+		if err != nil {
+			dllErr = err
+			return
+		}
+
+		dllPath := filepath.Join(filepath.Dir(exePath), "SimConnect.dll")
+		if _, err = os.Stat(dllPath); os.IsNotExist(err) {
+			buf := MustAsset("MSFS-SDK/SimConnect SDK/lib/SimConnect.dll")
+
+			if err := os.WriteFile(dllPath, buf, 0644); err != nil {
+				dllErr = err
+				return
+			}
+		}
+
+		mod := syscall.NewLazyDLL(dllPath)
+		if err = mod.Load(); err != nil {
+			dllErr = err
+			return
+		}
+		dllMod = mod
+	})
+	return dllMod, dllErr
+}
+
+// dllBackend talks to the simulator through SimConnect.dll.
+type dllBackend struct {
+	mod    *syscall.LazyDLL
+	handle unsafe.Pointer
+}
+
+// NewDLLBackend loads SimConnect.dll, extracting the embedded copy next to
+// the executable if needed.
+func NewDLLBackend() (Backend, error) {
+	mod, err := loadDLL()
+	if err != nil {
+		return nil, err
+	}
+	return &dllBackend{mod: mod}, nil
+}
+
+func (b *dllBackend) proc(name string) *syscall.LazyProc {
+	procsMu.Lock()
+	defer procsMu.Unlock()
+
+	p, ok := procs[name]
+	if !ok {
+		p = b.mod.NewProc("SimConnect_" + name)
+		procs[name] = p
+	}
+	return p
+}
+
+func (b *dllBackend) Open(name string) error {
+	// SimConnect_Open(
+	//   HANDLE * phSimConnect,
+	//   LPCSTR szName,
+	//   HWND hWnd,
+	//   DWORD UserEventWin32,
+	//   HANDLE hEventHandle,
+	//   DWORD ConfigIndex
+	// );
+
+	var ptr, err = syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	args := []uintptr{
+		uintptr(unsafe.Pointer(&b.handle)),
+		uintptr(unsafe.Pointer(ptr)),
+		0,
+		0,
+		0,
+		0,
+	}
+
+	r1, _, err := b.proc("Open").Call(args...)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_Open error: %d %s", int32(r1), err)
+	}
+	return nil
+}
+
+func (b *dllBackend) Close() error {
+	// SimConnect_Close(
+	//   HANDLE hSimConnect
+	// );
+	r1, _, err := b.proc("Close").Call(uintptr(b.handle))
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_Close error: %d %s", int32(r1), err)
+	}
+	return nil
+}
+
+func (b *dllBackend) Call(proc string, args ...interface{}) error {
+	words, keep, err := marshalArgs(args)
+	if err != nil {
+		return fmt.Errorf("SimConnect_%s %w", proc, err)
+	}
+
+	r1, _, err := b.proc(proc).Call(append([]uintptr{uintptr(b.handle)}, words...)...)
+	// keep referenced buffers alive until the call returns
+	runtime.KeepAlive(keep)
+	if int32(r1) < 0 {
+		return fmt.Errorf("SimConnect_%s error: %d %s", proc, int32(r1), err)
+	}
+	return nil
+}
+
+func (b *dllBackend) GetNextDispatch() (unsafe.Pointer, int32, error) {
+	var ppData unsafe.Pointer
+	var ppDataLength DWORD
+
+	r1, _, err := b.proc("GetNextDispatch").Call(
+		uintptr(b.handle),
+		uintptr(unsafe.Pointer(&ppData)),
+		uintptr(unsafe.Pointer(&ppDataLength)),
+	)
+
+	return ppData, int32(r1), err
+}
@@ -0,0 +1,1045 @@
+// Command gendefs reads SimConnect.h and writes the Go equivalent of its
+// enums, constants and packed records, with encoders and decoders for the
+// records.
+//
+// usage: go run ./internal/gendefs -header path/to/SimConnect.h -o simconnect_h.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func main() {
+	header := flag.String("header", "", "path to SimConnect.h")
+	out := flag.String("o", "simconnect_h.go", "output file")
+	pkg := flag.String("pkg", "simconnect", "output package name")
+	var defines stringList
+	flag.Var(&defines, "D", "treat this macro as defined (repeatable)")
+	flag.Parse()
+
+	if *header == "" {
+		fmt.Fprintln(os.Stderr, "gendefs: -header is required")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*header)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gendefs:", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	h := newHeader(defines)
+	if err := h.parse(f); err != nil {
+		fmt.Fprintf(os.Stderr, "gendefs: %s: %s\n", *header, err)
+		os.Exit(1)
+	}
+	for _, w := range h.warnings {
+		fmt.Fprintf(os.Stderr, "gendefs: warning: %s\n", w)
+	}
+
+	src, err := generate(h, *pkg, filepath.Base(*header))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gendefs:", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "gendefs:", err)
+		os.Exit(1)
+	}
+}
+
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+var (
+	reDefine     = regexp.MustCompile(`^#\s*define\s+(\w+)\s+(.+)$`)
+	reIfdef      = regexp.MustCompile(`^#\s*(ifdef|ifndef)\s+(\w+)`)
+	reIf         = regexp.MustCompile(`^#\s*if\b`)
+	reElse       = regexp.MustCompile(`^#\s*else\b`)
+	reEndif      = regexp.MustCompile(`^#\s*endif\b`)
+	reConst      = regexp.MustCompile(`^static\s+const\s+(\w+)\s+(\w+)\s*=\s*([^;]+);`)
+	reTypedef    = regexp.MustCompile(`^(?:typedef\s+DWORD|SIMCONNECT_ENUM_FLAGS|SIMCONNECT_USER_ENUM)\s+(\w+)\s*;`)
+	reEnum       = regexp.MustCompile(`^SIMCONNECT_ENUM\s+(\w+)`)
+	reEnumValue  = regexp.MustCompile(`^(\w+)\s*(?:=\s*([^,]+?))?\s*,?$`)
+	reStruct     = regexp.MustCompile(`^(?:SIMCONNECT_STRUCT|SIMCONNECT_REFSTRUCT|struct)\s+(\w+)\s*(?::\s*public\s+(\w+))?\s*(\{)?$`)
+	reField      = regexp.MustCompile(`^((?:unsigned\s+)?\w+)\s+(\w+)\s*(?:\[\s*([^\]]+)\s*\])?\s*;$`)
+	reString     = regexp.MustCompile(`^SIMCONNECT_STRING\(\s*(\w+)\s*,\s*(\w+)\s*\)\s*;$`)
+	reStringV    = regexp.MustCompile(`^SIMCONNECT_STRINGV\(`)
+	reDataV      = regexp.MustCompile(`^SIMCONNECT_DATAV\(`)
+	reFixedDataV = regexp.MustCompile(`^SIMCONNECT_FIXEDTYPE_DATAV\(\s*(\w+)\s*,\s*(\w+)\s*,\s*(\w+)`)
+)
+
+// builtins are the macros SimConnect.h uses without defining.
+var builtins = map[string]int64{
+	"DWORD_MAX": 0xFFFFFFFF,
+	"MAX_PATH":  260,
+	"FLT_MAX":   0, // handled as a float constant
+}
+
+type constant struct {
+	CName   string
+	CType   string
+	Expr    string
+	Value   int64
+	Comment string
+}
+
+type constGroup struct {
+	Doc    []string
+	Consts []*constant
+}
+
+type enum struct {
+	CName  string
+	Doc    []string
+	Values []*constant
+}
+
+type field struct {
+	CName   string
+	CType   string
+	Len     int    // fixed array length, 0 for scalars
+	Count   string // C name of the count field for variable arrays
+	Comment string
+}
+
+type record struct {
+	CName   string
+	Base    string
+	Doc     []string
+	Comment string
+	Fields  []*field
+	Consts  []*constant
+}
+
+type header struct {
+	defined  map[string]bool
+	values   map[string]int64
+	typedefs map[string]bool
+	enumSet  map[string]bool
+
+	consts   []*constGroup
+	enums    []*enum
+	records  []*record
+	recordBy map[string]*record
+	warnings []string
+}
+
+func newHeader(defines []string) *header {
+	h := &header{
+		defined:  map[string]bool{},
+		values:   map[string]int64{},
+		typedefs: map[string]bool{},
+		enumSet:  map[string]bool{},
+		recordBy: map[string]*record{},
+	}
+	for name, v := range builtins {
+		h.defined[name] = true
+		h.values[name] = v
+	}
+	for _, d := range defines {
+		h.defined[d] = true
+	}
+	return h
+}
+
+func (h *header) warn(format string, args ...interface{}) {
+	h.warnings = append(h.warnings, fmt.Sprintf(format, args...))
+}
+
+// splitComment separates a trailing // comment from the code on a line.
+func splitComment(line string) (string, string) {
+	if i := strings.Index(line, "//"); i >= 0 {
+		return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+2:])
+	}
+	return strings.TrimSpace(line), ""
+}
+
+func stripBlockComments(src string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(src, "/*")
+		if i < 0 {
+			b.WriteString(src)
+			return b.String()
+		}
+		b.WriteString(src[:i])
+		j := strings.Index(src[i+2:], "*/")
+		if j < 0 {
+			return b.String()
+		}
+		src = src[i+2+j+2:]
+	}
+}
+
+func (h *header) parse(r io.Reader) error {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	src := stripBlockComments(strings.Replace(string(raw), "\r\n", "\n", -1))
+
+	// preprocessor conditionals: a stack of "is this branch active"
+	active := []bool{true}
+	isActive := func() bool { return active[len(active)-1] }
+
+	var (
+		doc      []string
+		group    *constGroup
+		curEnum  *enum
+		curRec   *record
+		nextEnum int64
+	)
+
+	endGroup := func() { group = nil }
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case reIfdef.MatchString(line):
+			m := reIfdef.FindStringSubmatch(line)
+			on := h.defined[m[2]]
+			if m[1] == "ifndef" {
+				on = !on
+			}
+			active = append(active, isActive() && on)
+			continue
+		case reIf.MatchString(line):
+			active = append(active, isActive())
+			continue
+		case reElse.MatchString(line):
+			parent := active[len(active)-2]
+			active[len(active)-1] = parent && !active[len(active)-1]
+			continue
+		case reEndif.MatchString(line):
+			if len(active) == 1 {
+				return fmt.Errorf("line %d: unbalanced #endif", lineNo)
+			}
+			active = active[:len(active)-1]
+			continue
+		}
+		if !isActive() {
+			continue
+		}
+
+		if m := reDefine.FindStringSubmatch(line); m != nil {
+			h.defined[m[1]] = true
+			if v, err := h.eval(m[2]); err == nil {
+				h.values[m[1]] = v
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		code, comment := splitComment(line)
+		if code == "" {
+			if comment != "" && curEnum == nil && curRec == nil {
+				if strings.Trim(comment, "-") == "" {
+					doc = nil
+				} else {
+					doc = append(doc, comment)
+				}
+			}
+			if comment == "" {
+				doc = nil
+				endGroup()
+			}
+			continue
+		}
+
+		switch {
+		case curEnum != nil:
+			if code == "{" {
+				continue
+			}
+			if strings.HasPrefix(code, "}") {
+				h.enums = append(h.enums, curEnum)
+				curEnum = nil
+				continue
+			}
+			m := reEnumValue.FindStringSubmatch(code)
+			if m == nil {
+				return fmt.Errorf("line %d: can't parse enum value %q", lineNo, code)
+			}
+			c := &constant{CName: m[1], CType: "DWORD", Expr: strings.TrimSpace(m[2]), Comment: comment}
+			if c.Expr != "" {
+				v, err := h.eval(c.Expr)
+				if err != nil {
+					return fmt.Errorf("line %d: %s: %s", lineNo, c.CName, err)
+				}
+				nextEnum = v
+			}
+			c.Value = nextEnum
+			nextEnum++
+			h.values[c.CName] = c.Value
+			curEnum.Values = append(curEnum.Values, c)
+
+		case curRec != nil:
+			if code == "{" {
+				continue
+			}
+			if strings.HasPrefix(code, "}") {
+				h.records = append(h.records, curRec)
+				h.recordBy[curRec.CName] = curRec
+				curRec = nil
+				continue
+			}
+			if err := h.parseField(curRec, code, comment); err != nil {
+				return fmt.Errorf("line %d: %s", lineNo, err)
+			}
+
+		case reConst.MatchString(code):
+			m := reConst.FindStringSubmatch(code)
+			c := &constant{CName: m[2], CType: m[1], Expr: strings.TrimSpace(m[3]), Comment: comment}
+			if c.Expr != "FLT_MAX" {
+				v, err := h.eval(c.Expr)
+				if err != nil {
+					return fmt.Errorf("line %d: %s: %s", lineNo, c.CName, err)
+				}
+				c.Value = v
+			}
+			h.values[c.CName] = c.Value
+			if group == nil {
+				group = &constGroup{Doc: doc}
+				h.consts = append(h.consts, group)
+				doc = nil
+			}
+			group.Consts = append(group.Consts, c)
+
+		case reTypedef.MatchString(code):
+			m := reTypedef.FindStringSubmatch(code)
+			h.typedefs[m[1]] = true
+			endGroup()
+			doc = nil
+
+		case reEnum.MatchString(code):
+			m := reEnum.FindStringSubmatch(code)
+			curEnum = &enum{CName: m[1], Doc: doc}
+			h.enumSet[m[1]] = true
+			nextEnum = 0
+			doc = nil
+			endGroup()
+			if strings.HasSuffix(code, "};") {
+				return fmt.Errorf("line %d: single-line enums are not supported", lineNo)
+			}
+
+		case reStruct.MatchString(code):
+			m := reStruct.FindStringSubmatch(code)
+			curRec = &record{CName: m[1], Base: m[2], Doc: doc, Comment: comment}
+			doc = nil
+			endGroup()
+
+		default:
+			// prototypes, typedefs of callbacks and the like
+			doc = nil
+			endGroup()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if curEnum != nil || curRec != nil {
+		return fmt.Errorf("unterminated declaration at end of file")
+	}
+	return nil
+}
+
+func (h *header) parseField(rec *record, code, comment string) error {
+	switch {
+	case reConst.MatchString(code):
+		m := reConst.FindStringSubmatch(code)
+		c := &constant{CName: m[2], CType: m[1], Expr: strings.TrimSpace(m[3]), Comment: comment}
+		v, err := h.eval(c.Expr)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %s", rec.CName, c.CName, err)
+		}
+		c.Value = v
+		rec.Consts = append(rec.Consts, c)
+
+	case reString.MatchString(code):
+		m := reString.FindStringSubmatch(code)
+		n, err := h.eval(m[2])
+		if err != nil {
+			return fmt.Errorf("%s.%s: %s", rec.CName, m[1], err)
+		}
+		rec.Fields = append(rec.Fields, &field{CName: m[1], CType: "char", Len: int(n), Comment: comment})
+
+	case reStringV.MatchString(code), reDataV.MatchString(code):
+		// variable length tail that depends on the request; left to the caller
+
+	case reFixedDataV.MatchString(code):
+		m := reFixedDataV.FindStringSubmatch(code)
+		rec.Fields = append(rec.Fields, &field{CName: m[2], CType: m[1], Count: m[3], Comment: comment})
+
+	case reField.MatchString(code):
+		m := reField.FindStringSubmatch(code)
+		f := &field{CName: m[2], CType: strings.Join(strings.Fields(m[1]), " "), Comment: comment}
+		if m[3] != "" {
+			n, err := h.eval(m[3])
+			if err != nil {
+				return fmt.Errorf("%s.%s: %s", rec.CName, f.CName, err)
+			}
+			f.Len = int(n)
+		}
+		rec.Fields = append(rec.Fields, f)
+
+	default:
+		return fmt.Errorf("%s: can't parse field %q", rec.CName, code)
+	}
+	return nil
+}
+
+// eval evaluates the integer constant expressions found in SimConnect.h.
+func (h *header) eval(expr string) (int64, error) {
+	p := &exprParser{h: h, toks: tokenize(expr)}
+	v, err := p.expr(0)
+	if err != nil {
+		return 0, err
+	}
+	if p.pos != len(p.toks) {
+		return 0, fmt.Errorf("unexpected %q in %q", p.toks[p.pos], expr)
+	}
+	return v, nil
+}
+
+var reToken = regexp.MustCompile(`0[xX][0-9a-fA-F]+[uUlL]*|[0-9]+[uUlL]*|\w+|<<|>>|\S`)
+
+func tokenize(expr string) []string {
+	return reToken.FindAllString(expr, -1)
+}
+
+type exprParser struct {
+	h    *header
+	toks []string
+	pos  int
+}
+
+var precedence = map[string]int{"|": 1, "^": 2, "&": 3, "<<": 4, ">>": 4, "+": 5, "-": 5, "*": 6, "/": 6, "%": 6}
+
+func (p *exprParser) expr(minPrec int) (int64, error) {
+	lhs, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for p.pos < len(p.toks) {
+		op := p.toks[p.pos]
+		prec, ok := precedence[op]
+		if !ok || prec <= minPrec {
+			break
+		}
+		p.pos++
+		rhs, err := p.expr(prec)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "|":
+			lhs |= rhs
+		case "^":
+			lhs ^= rhs
+		case "&":
+			lhs &= rhs
+		case "<<":
+			lhs <<= uint(rhs)
+		case ">>":
+			lhs >>= uint(rhs)
+		case "+":
+			lhs += rhs
+		case "-":
+			lhs -= rhs
+		case "*":
+			lhs *= rhs
+		case "/", "%":
+			if rhs == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			if op == "/" {
+				lhs /= rhs
+			} else {
+				lhs %= rhs
+			}
+		}
+	}
+	return lhs, nil
+}
+
+func (p *exprParser) unary() (int64, error) {
+	if p.pos >= len(p.toks) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.toks[p.pos]
+	p.pos++
+
+	switch {
+	case tok == "-":
+		v, err := p.unary()
+		return -v, err
+	case tok == "~":
+		v, err := p.unary()
+		return ^v, err
+	case tok == "(":
+		v, err := p.expr(0)
+		if err != nil {
+			return 0, err
+		}
+		if p.pos >= len(p.toks) || p.toks[p.pos] != ")" {
+			return 0, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return v, nil
+	case tok[0] >= '0' && tok[0] <= '9':
+		var v int64
+		_, err := fmt.Sscan(strings.TrimRight(tok, "uUlL"), &v)
+		if err != nil {
+			return 0, fmt.Errorf("bad number %q", tok)
+		}
+		return v, nil
+	default:
+		v, ok := p.h.values[tok]
+		if !ok {
+			return 0, fmt.Errorf("unknown identifier %q", tok)
+		}
+		return v, nil
+	}
+}
+
+// generated output
+
+// typeRenames keeps the Go names the hand-written definitions used.
+var typeRenames = map[string]string{
+	"SIMCONNECT_RECV_FACILITIES_LIST": "RecvFacilityList",
+	"SIMCONNECT_RECV_AIRPORT_LIST":    "RecvFacilityAirportList",
+	"SIMCONNECT_RECV_WAYPOINT_LIST":   "RecvFacilityWaypointList",
+	"SIMCONNECT_RECV_NDB_LIST":        "RecvFacilityNDBList",
+	"SIMCONNECT_RECV_VOR_LIST":        "RecvFacilityVORList",
+}
+
+var wordRenames = map[string]string{
+	"ID":           "ID",
+	"AI":           "AI",
+	"ATC":          "ATC",
+	"NDB":          "NDB",
+	"VOR":          "VOR",
+	"XYZ":          "XYZ",
+	"PBH":          "PBH",
+	"EX1":          "EX1",
+	"BYTYPE":       "ByType",
+	"DATATYPE":     "DataType",
+	"INITPOSITION": "InitPosition",
+	"LATLONALT":    "LatLonAlt",
+	"MARKERSTATE":  "MarkerState",
+	"ADDREMOVE":    "AddRemove",
+}
+
+var fieldRenames = map[string]string{
+	"dwentrynumber": "EntryNumber",
+	"dwoutof":       "OutOf",
+}
+
+// hungarian prefixes stripped from field names, longest first
+var hungarian = []string{"guid", "rgb", "rg", "dw", "sz", "u", "f", "b", "e"}
+
+func trimPrefix(cname string) string {
+	return strings.TrimPrefix(cname, "SIMCONNECT_")
+}
+
+func goTypeName(cname string) string {
+	if n, ok := typeRenames[cname]; ok {
+		return n
+	}
+	var b strings.Builder
+	for _, w := range strings.Split(trimPrefix(cname), "_") {
+		if w == "" {
+			continue
+		}
+		if r, ok := wordRenames[w]; ok {
+			b.WriteString(r)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + strings.ToLower(w[1:]))
+	}
+	return b.String()
+}
+
+func goFieldName(cname string) string {
+	if n, ok := fieldRenames[cname]; ok {
+		return n
+	}
+	name := cname
+	for _, p := range hungarian {
+		if len(name) > len(p) && strings.HasPrefix(name, p) {
+			next := name[len(p)]
+			if next >= 'A' && next <= 'Z' {
+				name = name[len(p):]
+				break
+			}
+		}
+	}
+	name = strings.ToUpper(name[:1]) + name[1:]
+	if strings.HasSuffix(name, "Id") {
+		name = strings.TrimSuffix(name, "Id") + "ID"
+	}
+	return name
+}
+
+// scalar describes how a C scalar type is stored.
+type scalar struct {
+	goType string
+	size   int
+}
+
+var scalars = map[string]scalar{
+	"DWORD":         {"DWORD", 4},
+	"unsigned long": {"DWORD", 4},
+	"unsigned int":  {"DWORD", 4},
+	"UINT":          {"DWORD", 4},
+	"ULONG":         {"DWORD", 4},
+	"int":           {"int32", 4},
+	"long":          {"int32", 4},
+	"LONG":          {"int32", 4},
+	"BOOL":          {"int32", 4},
+	"INT64":         {"int64", 8},
+	"__int64":       {"int64", 8},
+	"LONGLONG":      {"int64", 8},
+	"float":         {"float32", 4},
+	"double":        {"float64", 8},
+	"char":          {"byte", 1},
+	"BYTE":          {"byte", 1},
+	"unsigned char": {"byte", 1},
+	"GUID":          {"[16]byte", 16},
+}
+
+func (h *header) scalarFor(ctype string) (scalar, bool) {
+	if s, ok := scalars[ctype]; ok {
+		return s, true
+	}
+	if h.typedefs[ctype] || h.enumSet[ctype] {
+		return scalars["DWORD"], true
+	}
+	return scalar{}, false
+}
+
+// sizeOf returns the packed size of a record, not counting variable arrays.
+func (h *header) sizeOf(rec *record) (int, error) {
+	size := 0
+	if rec.Base != "" {
+		base, ok := h.recordBy[rec.Base]
+		if !ok {
+			return 0, fmt.Errorf("%s: unknown base %s", rec.CName, rec.Base)
+		}
+		n, err := h.sizeOf(base)
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	for _, f := range rec.Fields {
+		if f.Count != "" {
+			continue
+		}
+		n, err := h.fieldSize(rec, f)
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	return size, nil
+}
+
+func (h *header) fieldSize(rec *record, f *field) (int, error) {
+	elem, err := h.elemSize(f.CType)
+	if err != nil {
+		return 0, fmt.Errorf("%s.%s: %s", rec.CName, f.CName, err)
+	}
+	if f.Len > 0 {
+		return elem * f.Len, nil
+	}
+	return elem, nil
+}
+
+func (h *header) elemSize(ctype string) (int, error) {
+	if s, ok := h.scalarFor(ctype); ok {
+		return s.size, nil
+	}
+	if r, ok := h.recordBy[ctype]; ok {
+		return h.sizeOf(r)
+	}
+	return 0, fmt.Errorf("unknown type %s", ctype)
+}
+
+// elemSizeExpr is elemSize as Go source, naming the Sizeof constant of records.
+func (h *header) elemSizeExpr(ctype string) (string, error) {
+	if _, ok := h.recordBy[ctype]; ok {
+		return "Sizeof" + goTypeName(ctype), nil
+	}
+	n, err := h.elemSize(ctype)
+	return fmt.Sprint(n), err
+}
+
+func (h *header) goFieldType(f *field) string {
+	elem := ""
+	if s, ok := h.scalarFor(f.CType); ok {
+		elem = s.goType
+	} else {
+		elem = goTypeName(f.CType)
+	}
+	switch {
+	case f.Count != "":
+		return "[]" + elem
+	case f.Len > 0:
+		return fmt.Sprintf("[%d]%s", f.Len, elem)
+	}
+	return elem
+}
+
+type gen struct {
+	h   *header
+	buf bytes.Buffer
+}
+
+func (g *gen) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func generate(h *header, pkg, source string) ([]byte, error) {
+	g := &gen{h: h}
+
+	g.p("// Code generated by gendefs from %s. DO NOT EDIT.", source)
+	g.p("")
+	g.p("package %s", pkg)
+	g.p("")
+	g.p("import (")
+	g.p(`"encoding/binary"`)
+	g.p(`"fmt"`)
+	g.p(`"math"`)
+	g.p(")")
+	g.p("")
+
+	for _, grp := range h.consts {
+		g.constGroup(grp)
+	}
+	for _, e := range h.enums {
+		g.enum(e)
+	}
+
+	// skip records whose field types we can't lay out
+	for _, rec := range h.records {
+		if _, err := h.sizeOf(rec); err != nil {
+			h.warn("skipping %s", err)
+			continue
+		}
+		if err := g.record(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	g.p("func shortBuffer(name string, want, got int) error {")
+	g.p(`return fmt.Errorf("%%s: need %%d bytes, got %%d", name, want, got)`)
+	g.p("}")
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return g.buf.Bytes(), fmt.Errorf("format generated source: %s", err)
+	}
+	return src, nil
+}
+
+func writeDoc(g *gen, doc []string) {
+	for _, d := range doc {
+		g.p("// %s", d)
+	}
+}
+
+func (g *gen) constValue(c *constant) (string, string) {
+	switch c.CType {
+	case "float", "double":
+		goType := "float32"
+		if c.CType == "double" {
+			goType = "float64"
+		}
+		if c.Expr == "FLT_MAX" {
+			return goType, "math.MaxFloat32"
+		}
+		return goType, fmt.Sprintf("%d", c.Value)
+	case "int", "long":
+		return "", fmt.Sprintf("%d", c.Value)
+	}
+
+	v := uint32(c.Value)
+	if c.Value < 0 || strings.HasPrefix(strings.ToLower(c.Expr), "0x") || c.Expr == "DWORD_MAX" {
+		return "DWORD", fmt.Sprintf("0x%08X", v)
+	}
+	return "DWORD", fmt.Sprintf("%d", v)
+}
+
+func (g *gen) constLine(name string, c *constant) {
+	goType, value := g.constValue(c)
+	line := name
+	if goType != "" {
+		line += " " + goType
+	}
+	line += " = " + value
+	if c.Comment != "" {
+		line += " // " + c.Comment
+	}
+	g.p("%s", line)
+}
+
+func (g *gen) constGroup(grp *constGroup) {
+	writeDoc(g, grp.Doc)
+	g.p("const (")
+	for _, c := range grp.Consts {
+		g.constLine(trimPrefix(c.CName), c)
+	}
+	g.p(")")
+	g.p("")
+}
+
+func (g *gen) enum(e *enum) {
+	writeDoc(g, e.Doc)
+	g.p("// %s", e.CName)
+	g.p("const (")
+	for _, c := range e.Values {
+		line := fmt.Sprintf("%s DWORD = %d", trimPrefix(c.CName), c.Value)
+		if c.Expr != "" && strings.HasPrefix(strings.ToLower(c.Expr), "0x") {
+			line = fmt.Sprintf("%s DWORD = 0x%04X", trimPrefix(c.CName), c.Value)
+		}
+		if c.Comment != "" {
+			line += " // " + c.Comment
+		}
+		g.p("%s", line)
+	}
+	g.p(")")
+	g.p("")
+
+	name := goTypeName(e.CName) + "Names"
+	g.p("// %s maps %s values to their names.", name, e.CName)
+	g.p("var %s = map[DWORD]string{", name)
+	seen := map[int64]bool{}
+	for _, c := range e.Values {
+		if seen[c.Value] {
+			continue
+		}
+		seen[c.Value] = true
+		g.p("%s: %q,", trimPrefix(c.CName), trimPrefix(c.CName))
+	}
+	g.p("}")
+	g.p("")
+}
+
+func (g *gen) record(rec *record) error {
+	h := g.h
+	name := goTypeName(rec.CName)
+	size, _ := h.sizeOf(rec)
+
+	if len(rec.Consts) > 0 {
+		g.p("const (")
+		for _, c := range rec.Consts {
+			g.constLine(trimPrefix(rec.CName)+"_"+c.CName, c)
+		}
+		g.p(")")
+		g.p("")
+	}
+
+	g.p("// %s is %s.", name, rec.CName)
+	if rec.Comment != "" {
+		g.p("// %s", rec.Comment)
+	}
+	g.p("type %s struct {", name)
+	if rec.Base != "" {
+		g.p("%s", goTypeName(rec.Base))
+	}
+	for _, f := range rec.Fields {
+		line := fmt.Sprintf("%s %s", goFieldName(f.CName), h.goFieldType(f))
+		if f.Comment != "" {
+			line += " // " + f.Comment
+		}
+		g.p("%s", line)
+	}
+	g.p("}")
+	g.p("")
+
+	g.p("// Sizeof%s is the packed size of %s without any variable-length data.", name, rec.CName)
+	g.p("const Sizeof%s = %d", name, size)
+	g.p("")
+
+	var tail *field
+	for _, f := range rec.Fields {
+		if f.Count != "" {
+			tail = f
+		}
+	}
+
+	// UnmarshalBinary
+	g.p("// UnmarshalBinary decodes a packed %s.", rec.CName)
+	g.p("func (r *%s) UnmarshalBinary(b []byte) error {", name)
+	g.p("if len(b) < Sizeof%s {", name)
+	g.p("return shortBuffer(%q, Sizeof%s, len(b))", name, name)
+	g.p("}")
+	g.p("r.decode(b)")
+	if tail != nil {
+		elemSize, err := h.elemSizeExpr(tail.CType)
+		if err != nil {
+			return err
+		}
+		fname := goFieldName(tail.CName)
+		count := goFieldName(tail.Count)
+		g.p("n := int(r.%s)", count)
+		g.p("if want := Sizeof%s + n*%s; len(b) < want {", name, elemSize)
+		g.p("return shortBuffer(%q, want, len(b))", name)
+		g.p("}")
+		g.p("r.%s = make(%s, n)", fname, h.goFieldType(tail))
+		if elemSize == "1" {
+			g.p("copy(r.%s, b[Sizeof%s:])", fname, name)
+		} else {
+			g.p("for i := range r.%s {", fname)
+			g.p("off := Sizeof%s + i*%s", name, elemSize)
+			g.decodeValue(fmt.Sprintf("r.%s[i]", fname), tail.CType, "off")
+			g.p("}")
+		}
+	}
+	g.p("return nil")
+	g.p("}")
+	g.p("")
+
+	// MarshalBinary
+	g.p("// MarshalBinary encodes r as a packed %s.", rec.CName)
+	g.p("func (r *%s) MarshalBinary() ([]byte, error) {", name)
+	if tail != nil {
+		elemSize, _ := h.elemSizeExpr(tail.CType)
+		fname := goFieldName(tail.CName)
+		g.p("b := make([]byte, Sizeof%s+len(r.%s)*%s)", name, fname, elemSize)
+		g.p("r.encode(b)")
+		if elemSize == "1" {
+			g.p("copy(b[Sizeof%s:], r.%s)", name, fname)
+		} else {
+			g.p("for i := range r.%s {", fname)
+			g.p("off := Sizeof%s + i*%s", name, elemSize)
+			g.encodeValue(fmt.Sprintf("r.%s[i]", fname), tail.CType, "off")
+			g.p("}")
+		}
+	} else {
+		g.p("b := make([]byte, Sizeof%s)", name)
+		g.p("r.encode(b)")
+	}
+	g.p("return b, nil")
+	g.p("}")
+	g.p("")
+
+	// decode / encode of the fixed part
+	for _, dir := range []string{"decode", "encode"} {
+		g.p("func (r *%s) %s(b []byte) {", name, dir)
+		off := 0
+		if rec.Base != "" {
+			g.p("r.%s.%s(b)", goTypeName(rec.Base), dir)
+			n, _ := h.sizeOf(h.recordBy[rec.Base])
+			off = n
+		}
+		for _, f := range rec.Fields {
+			if f.Count != "" {
+				continue
+			}
+			target := "r." + goFieldName(f.CName)
+			fsize, _ := h.fieldSize(rec, f)
+			if f.Len > 0 {
+				elemSize, _ := h.elemSize(f.CType)
+				if elemSize == 1 {
+					if dir == "decode" {
+						g.p("copy(%s[:], b[%d:%d])", target, off, off+fsize)
+					} else {
+						g.p("copy(b[%d:%d], %s[:])", off, off+fsize, target)
+					}
+				} else {
+					g.p("for i := range %s {", target)
+					at := fmt.Sprintf("%d+i*%d", off, elemSize)
+					if dir == "decode" {
+						g.decodeValue(target+"[i]", f.CType, at)
+					} else {
+						g.encodeValue(target+"[i]", f.CType, at)
+					}
+					g.p("}")
+				}
+			} else {
+				at := fmt.Sprintf("%d", off)
+				if dir == "decode" {
+					g.decodeValue(target, f.CType, at)
+				} else {
+					g.encodeValue(target, f.CType, at)
+				}
+			}
+			off += fsize
+		}
+		g.p("}")
+		g.p("")
+	}
+
+	return nil
+}
+
+func (g *gen) decodeValue(target, ctype, off string) {
+	s, ok := g.h.scalarFor(ctype)
+	if !ok {
+		g.p("%s.decode(b[%s:])", target, off)
+		return
+	}
+	switch s.goType {
+	case "DWORD":
+		g.p("%s = DWORD(binary.LittleEndian.Uint32(b[%s:]))", target, off)
+	case "int32":
+		g.p("%s = int32(binary.LittleEndian.Uint32(b[%s:]))", target, off)
+	case "int64":
+		g.p("%s = int64(binary.LittleEndian.Uint64(b[%s:]))", target, off)
+	case "float32":
+		g.p("%s = math.Float32frombits(binary.LittleEndian.Uint32(b[%s:]))", target, off)
+	case "float64":
+		g.p("%s = math.Float64frombits(binary.LittleEndian.Uint64(b[%s:]))", target, off)
+	case "byte":
+		g.p("%s = b[%s]", target, off)
+	case "[16]byte":
+		g.p("copy(%s[:], b[%s:])", target, off)
+	}
+}
+
+func (g *gen) encodeValue(target, ctype, off string) {
+	s, ok := g.h.scalarFor(ctype)
+	if !ok {
+		g.p("%s.encode(b[%s:])", target, off)
+		return
+	}
+	switch s.goType {
+	case "DWORD", "int32":
+		g.p("binary.LittleEndian.PutUint32(b[%s:], uint32(%s))", off, target)
+	case "int64":
+		g.p("binary.LittleEndian.PutUint64(b[%s:], uint64(%s))", off, target)
+	case "float32":
+		g.p("binary.LittleEndian.PutUint32(b[%s:], math.Float32bits(%s))", off, target)
+	case "float64":
+		g.p("binary.LittleEndian.PutUint64(b[%s:], math.Float64bits(%s))", off, target)
+	case "byte":
+		g.p("b[%s] = %s", off, target)
+	case "[16]byte":
+		g.p("copy(b[%s:], %s[:])", off, target)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// an excerpt of SimConnect.h, as the SDK writes it
+const testHeader = `
+#pragma pack(push, 1)
+
+#define SIMCONNECT_STRING(name, size) char name[size]
+#define SIMCONNECT_FIXEDTYPE_DATAV(type, name, count, cliMarshalAs, cliType) type name[1]
+
+static const DWORD SIMCONNECT_UNUSED = DWORD_MAX;   // special value to indicate unused event, ID
+
+SIMCONNECT_ENUM SIMCONNECT_RECV_ID {
+    SIMCONNECT_RECV_ID_NULL,
+    SIMCONNECT_RECV_ID_EXCEPTION,
+    SIMCONNECT_RECV_ID_OPEN,
+};
+
+struct SIMCONNECT_RECV
+{
+    DWORD   dwSize;         // record size
+    DWORD   dwVersion;      // interface version
+    DWORD   dwID;           // see SIMCONNECT_RECV_ID
+};
+
+struct SIMCONNECT_RECV_SYSTEM_STATE : public SIMCONNECT_RECV // when dwID == SIMCONNECT_RECV_ID_SYSTEM_STATE
+{
+    DWORD   dwRequestID;
+    DWORD   dwInteger;
+    float   fFloat;
+    SIMCONNECT_STRING(szString, MAX_PATH);
+};
+
+struct SIMCONNECT_RECV_FACILITIES_LIST : public SIMCONNECT_RECV
+{
+    DWORD   dwRequestID;
+    DWORD   dwArraySize;
+    DWORD   dwEntryNumber;  // when the array of items is too big for one send, which send this is (0..dwOutOf-1)
+    DWORD   dwOutOf;        // total number of transmissions the list is chopped into
+};
+
+struct SIMCONNECT_DATA_FACILITY_AIRPORT
+{
+    char    Icao[9];     // ICAO of the object
+    double  Latitude;    // degrees
+    double  Longitude;   // degrees
+    double  Altitude;    // meters
+};
+
+struct SIMCONNECT_RECV_AIRPORT_LIST : public SIMCONNECT_RECV_FACILITIES_LIST
+{
+    SIMCONNECT_FIXEDTYPE_DATAV(SIMCONNECT_DATA_FACILITY_AIRPORT, rgData, dwArraySize, U1 /*member array*/, SimConnect::DataFacilityAirport);
+};
+
+struct SIMCONNECT_DATA_FACILITY_WAYPOINT : public SIMCONNECT_DATA_FACILITY_AIRPORT
+{
+    float   fMagVar;    // Magvar in degrees
+};
+
+#pragma pack(pop)
+`
+
+func TestPackedLayout(t *testing.T) {
+	h := newHeader(nil)
+	if err := h.parse(strings.NewReader(testHeader)); err != nil {
+		t.Fatal(err)
+	}
+
+	// packed sizes, as sizeof gives them under #pragma pack(1)
+	for name, want := range map[string]int{
+		"SIMCONNECT_RECV":                   12,
+		"SIMCONNECT_RECV_SYSTEM_STATE":      284,
+		"SIMCONNECT_RECV_FACILITIES_LIST":   28,
+		"SIMCONNECT_DATA_FACILITY_AIRPORT":  33,
+		"SIMCONNECT_RECV_AIRPORT_LIST":      28,
+		"SIMCONNECT_DATA_FACILITY_WAYPOINT": 37,
+	} {
+		rec, ok := h.recordBy[name]
+		if !ok {
+			t.Errorf("%s not parsed", name)
+			continue
+		}
+		if got, err := h.sizeOf(rec); err != nil || got != want {
+			t.Errorf("%s: size %d (%v), want %d", name, got, err, want)
+		}
+	}
+
+	src, err := generate(h, "simconnect", "SimConnect.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fields are read at their packed offsets, which Go's alignment would
+	// move: Latitude follows a 9-byte array, szString a float
+	got := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		"const SizeofRecvSystemState = 284",
+		"r.Float = math.Float32frombits(binary.LittleEndian.Uint32(b[20:]))",
+		"copy(r.String[:], b[24:284])",
+		"copy(r.Icao[:], b[0:9])",
+		"r.Latitude = math.Float64frombits(binary.LittleEndian.Uint64(b[9:]))",
+		"r.Altitude = math.Float64frombits(binary.LittleEndian.Uint64(b[25:]))",
+		"r.MagVar = math.Float32frombits(binary.LittleEndian.Uint32(b[33:]))",
+		"Data []DataFacilityAirport",
+		"off := SizeofRecvFacilityAirportList + i*SizeofDataFacilityAirport",
+		"UNUSED DWORD = 0xFFFFFFFF",
+		"RECV_ID_OPEN DWORD = 2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated source lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package simconnect
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Recordings are a magic header followed by a stream of records:
+//
+//	kind byte, uvarint nanoseconds since Open, payload
+//
+// A dispatch payload is the raw message (uvarint length + bytes). A call
+// payload is the procedure name (uvarint length + bytes), a uvarint argument
+// count and each argument as a tag byte followed by its value.
+var recordMagic = []byte("SCREC\x01")
+
+// RecordKind tells dispatched messages and outgoing calls apart.
+type RecordKind byte
+
+const (
+	RecordDispatch RecordKind = 1 // message returned by GetNextDispatch
+	RecordCall     RecordKind = 2 // call made by the client, including Open and Close
+)
+
+const (
+	argDWORD  byte = 1
+	argString byte = 2
+	argBytes  byte = 3
+	argFloat  byte = 4 // float32 bits, uvarint
+	argDouble byte = 5 // float64 bits, uvarint
+)
+
+// maxRecordBytes bounds the messages, names and arguments of a recording,
+// far above any SimConnect packet, so a damaged length cannot make the
+// reader allocate gigabytes.
+const maxRecordBytes = 1 << 20
+
+// ErrBadRecording is returned when a file is not a SimConnect recording.
+var ErrBadRecording = errors.New("not a simconnect recording")
+
+// Record is a single entry of a recording.
+type Record struct {
+	Kind RecordKind
+	At   time.Duration // time since Open, from the monotonic clock
+
+	Data []byte // RecordDispatch: raw message
+
+	Proc string        // RecordCall: procedure name without the SimConnect_ prefix
+	Args []interface{} // RecordCall: DWORD, float32, float64, string or []byte
+}
+
+// Recorder is a Backend that forwards to another Backend and writes every
+// dispatched message and every outgoing call to w.
+type Recorder struct {
+	backend Backend
+
+	mu    sync.Mutex
+	w     *bufio.Writer
+	start time.Time
+	err   error
+}
+
+// NewRecorder wraps b, writing the recording to w. The caller closes w after
+// the Recorder is closed.
+func NewRecorder(b Backend, w io.Writer) (*Recorder, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(recordMagic); err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		backend: b,
+		w:       bw,
+		start:   time.Now(),
+	}, nil
+}
+
+// Err returns the first error hit while writing the recording.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) Open(name string) error {
+	r.mu.Lock()
+	r.start = time.Now()
+	r.mu.Unlock()
+
+	r.writeCall("Open", []interface{}{name})
+	return r.backend.Open(name)
+}
+
+func (r *Recorder) Close() error {
+	r.writeCall("Close", nil)
+	err := r.backend.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ferr := r.w.Flush(); ferr != nil && r.err == nil {
+		r.err = ferr
+	}
+	return err
+}
+
+func (r *Recorder) Call(proc string, args ...interface{}) error {
+	r.writeCall(proc, args)
+	return r.backend.Call(proc, args...)
+}
+
+func (r *Recorder) GetNextDispatch() (unsafe.Pointer, int32, error) {
+	ppData, r1, err := r.backend.GetNextDispatch()
+	if r1 >= 0 && ppData != nil {
+		r.write(&Record{Kind: RecordDispatch, Data: MessageBytes(ppData)})
+	}
+	return ppData, r1, err
+}
+
+func (r *Recorder) writeCall(proc string, args []interface{}) {
+	r.write(&Record{Kind: RecordCall, Proc: proc, Args: args})
+}
+
+func (r *Recorder) write(rec *Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	rec.At = time.Since(r.start)
+	r.err = writeRecord(r.w, rec)
+}
+
+func writeRecord(w *bufio.Writer, rec *Record) error {
+	var tmp [binary.MaxVarintLen64]byte
+
+	if len(rec.Data) > maxRecordBytes {
+		return fmt.Errorf("record of %d bytes, at most %d fit a recording", len(rec.Data), maxRecordBytes)
+	}
+	for i, arg := range rec.Args {
+		n := 0
+		switch v := arg.(type) {
+		case string:
+			n = len(v)
+		case []byte:
+			n = len(v)
+		}
+		if n > maxRecordBytes {
+			return fmt.Errorf("record %s argument %d: %d bytes, at most %d fit a recording", rec.Proc, i, n, maxRecordBytes)
+		}
+	}
+
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(tmp[:], v)
+		w.Write(tmp[:n])
+	}
+	putBytes := func(b []byte) {
+		putUvarint(uint64(len(b)))
+		w.Write(b)
+	}
+
+	w.WriteByte(byte(rec.Kind))
+	putUvarint(uint64(rec.At))
+
+	switch rec.Kind {
+	case RecordDispatch:
+		putBytes(rec.Data)
+	case RecordCall:
+		putBytes([]byte(rec.Proc))
+		putUvarint(uint64(len(rec.Args)))
+		for i, arg := range rec.Args {
+			switch v := arg.(type) {
+			case DWORD:
+				w.WriteByte(argDWORD)
+				putUvarint(uint64(v))
+			case float32:
+				w.WriteByte(argFloat)
+				putUvarint(uint64(math.Float32bits(v)))
+			case float64:
+				w.WriteByte(argDouble)
+				putUvarint(math.Float64bits(v))
+			case string:
+				w.WriteByte(argString)
+				putBytes([]byte(v))
+			case []byte:
+				w.WriteByte(argBytes)
+				putBytes(v)
+			default:
+				return fmt.Errorf("record %s argument %d: unsupported type %T", rec.Proc, i, arg)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown record kind %d", rec.Kind)
+	}
+
+	// bufio.Writer keeps the first write error
+	_, err := w.Write(nil)
+	return err
+}
+
+// RecordReader reads the records of a recording in order.
+type RecordReader struct {
+	r *bufio.Reader
+}
+
+// NewRecordReader checks the recording header and returns a reader positioned
+// at the first record.
+func NewRecordReader(r io.Reader) (*RecordReader, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, ErrBadRecording
+	}
+	if string(magic) != string(recordMagic) {
+		return nil, ErrBadRecording
+	}
+
+	return &RecordReader{r: br}, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the recording.
+func (rr *RecordReader) Next() (*Record, error) {
+	kind, err := rr.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &Record{Kind: RecordKind(kind)}
+
+	at, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	rec.At = time.Duration(at)
+
+	switch rec.Kind {
+	case RecordDispatch:
+		if rec.Data, err = rr.readBytes(); err != nil {
+			return nil, err
+		}
+
+	case RecordCall:
+		proc, err := rr.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		rec.Proc = string(proc)
+
+		argc, err := binary.ReadUvarint(rr.r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		for i := uint64(0); i < argc; i++ {
+			tag, err := rr.r.ReadByte()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			switch tag {
+			case argDWORD:
+				v, err := binary.ReadUvarint(rr.r)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				rec.Args = append(rec.Args, DWORD(v))
+			case argFloat:
+				v, err := binary.ReadUvarint(rr.r)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				rec.Args = append(rec.Args, math.Float32frombits(uint32(v)))
+			case argDouble:
+				v, err := binary.ReadUvarint(rr.r)
+				if err != nil {
+					return nil, unexpectedEOF(err)
+				}
+				rec.Args = append(rec.Args, math.Float64frombits(v))
+			case argString:
+				v, err := rr.readBytes()
+				if err != nil {
+					return nil, err
+				}
+				rec.Args = append(rec.Args, string(v))
+			case argBytes:
+				v, err := rr.readBytes()
+				if err != nil {
+					return nil, err
+				}
+				rec.Args = append(rec.Args, v)
+			default:
+				return nil, fmt.Errorf("%s argument %d: unknown tag %d: %w", rec.Proc, i, tag, ErrBadRecording)
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown record kind %d: %w", kind, ErrBadRecording)
+	}
+
+	return rec, nil
+}
+
+func (rr *RecordReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if n > maxRecordBytes {
+		return nil, fmt.Errorf("length %d over %d: %w", n, maxRecordBytes, ErrBadRecording)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(rr.r, buf); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
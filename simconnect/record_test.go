@@ -0,0 +1,194 @@
+package simconnect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// queueBackend dispatches a fixed list of messages and ignores calls.
+type queueBackend struct {
+	messages [][]byte
+	current  []byte
+}
+
+func (b *queueBackend) Open(name string) error                      { return nil }
+func (b *queueBackend) Close() error                                { return nil }
+func (b *queueBackend) Call(proc string, args ...interface{}) error { return nil }
+
+func (b *queueBackend) GetNextDispatch() (unsafe.Pointer, int32, error) {
+	if len(b.messages) == 0 {
+		return nil, rFail, nil
+	}
+	b.current, b.messages = b.messages[0], b.messages[1:]
+	return unsafe.Pointer(&b.current[0]), 0, nil
+}
+
+// message builds a SimConnect message of type id carrying payload.
+func message(id DWORD, payload ...byte) []byte {
+	buf := make([]byte, 12, 12+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:], uint32(12+len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:], 1)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(id))
+	return append(buf, payload...)
+}
+
+var testMessages = [][]byte{
+	message(RECV_ID_OPEN, make([]byte, 64)...),
+	message(RECV_ID_EVENT, 1, 2, 3, 4, 5, 6, 7, 8),
+	message(RECV_ID_QUIT),
+}
+
+var testCallArgs = []interface{}{DWORD(7), float32(1.5), -2.25, "PAUSE_ON", []byte{0, 1, 2}}
+
+// record makes a recording of an Open, a call and testMessages.
+func record(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	msgs := append([][]byte(nil), testMessages...)
+	r, err := NewRecorder(&queueBackend{messages: msgs}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Open("test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Call("TransmitClientEvent", testCallArgs...); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		ppData, r1, _ := r.GetNextDispatch()
+		if r1 < 0 || ppData == nil {
+			break
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	recording := record(t)
+
+	rr, err := NewRecordReader(bytes.NewReader(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []*Record
+	var dispatched [][]byte
+	for {
+		rec, err := rr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch rec.Kind {
+		case RecordCall:
+			calls = append(calls, rec)
+		case RecordDispatch:
+			dispatched = append(dispatched, rec.Data)
+		}
+	}
+
+	if len(calls) != 3 || calls[0].Proc != "Open" || calls[1].Proc != "TransmitClientEvent" || calls[2].Proc != "Close" {
+		t.Fatalf("got calls %v, want Open, TransmitClientEvent and Close", calls)
+	}
+	if !reflect.DeepEqual(calls[0].Args, []interface{}{"test"}) {
+		t.Errorf("Open args %#v", calls[0].Args)
+	}
+	if !reflect.DeepEqual(calls[1].Args, testCallArgs) {
+		t.Errorf("got args %#v, want %#v", calls[1].Args, testCallArgs)
+	}
+	if !reflect.DeepEqual(dispatched, testMessages) {
+		t.Errorf("got messages %x, want %x", dispatched, testMessages)
+	}
+
+	// the replayer hands the same messages back
+	replay, err := NewReplayer(bytes.NewReader(recording), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.Open("test")
+	var replayed [][]byte
+	for {
+		ppData, r1, _ := replay.GetNextDispatch()
+		if r1 < 0 {
+			break
+		}
+		replayed = append(replayed, MessageBytes(ppData))
+	}
+	if !reflect.DeepEqual(replayed, testMessages) {
+		t.Errorf("replayed %x, want %x", replayed, testMessages)
+	}
+	select {
+	case <-replay.Done():
+	default:
+		t.Error("replay not done at the end of the recording")
+	}
+	if err := replay.Err(); err != nil {
+		t.Errorf("replay error %v", err)
+	}
+}
+
+func TestTruncatedRecording(t *testing.T) {
+	recording := record(t)
+	// cut in the middle of the last message
+	end := bytes.LastIndex(recording, testMessages[len(testMessages)-1]) + 4
+	replay, err := NewReplayer(bytes.NewReader(recording[:end]), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.Open("test")
+	for {
+		if _, r1, _ := replay.GetNextDispatch(); r1 < 0 {
+			break
+		}
+	}
+	if err := replay.Err(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestOversizedRecordLength(t *testing.T) {
+	var tmp [binary.MaxVarintLen64]byte
+	bad := append([]byte(nil), recordMagic...)
+	bad = append(bad, byte(RecordDispatch), 0)
+	bad = append(bad, tmp[:binary.PutUvarint(tmp[:], 1<<40)]...)
+
+	rr, err := NewRecordReader(bytes.NewReader(bad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rr.Next(); !errors.Is(err, ErrBadRecording) {
+		t.Errorf("got %v, want %v", err, ErrBadRecording)
+	}
+
+	// the recorder refuses to write what could not be read back
+	r, err := NewRecorder(&queueBackend{}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Call("SetDataOnSimObject", make([]byte, maxRecordBytes+1))
+	if r.Err() == nil {
+		t.Error("recorded an argument over maxRecordBytes")
+	}
+}
+
+func TestNotARecording(t *testing.T) {
+	if _, err := NewRecordReader(bytes.NewReader([]byte("SCREC"))); err != ErrBadRecording {
+		t.Errorf("got %v, want %v", err, ErrBadRecording)
+	}
+	if _, err := NewRecordReader(bytes.NewReader([]byte("NOTREC\x01"))); err != ErrBadRecording {
+		t.Errorf("got %v, want %v", err, ErrBadRecording)
+	}
+}
@@ -0,0 +1,118 @@
+package simconnect
+
+import (
+	"io"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// rFail is E_FAIL as returned in r1 by GetNextDispatch when nothing is queued.
+const rFail int32 = -2147467259
+
+// Replayer is a Backend that plays back a recording made by a Recorder.
+// Dispatched messages are returned at their recorded times, scaled by speed;
+// outgoing calls are accepted and ignored. It does not need SimConnect.dll,
+// so it also runs on linux and macos.
+type Replayer struct {
+	records *RecordReader
+	speed   float64
+
+	start   time.Time
+	next    *Record
+	current []byte
+	err     error
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+// NewReplayer reads a recording from r. A speed of 1 replays in real time,
+// 2 twice as fast and so on; 0 or less replays as fast as it is polled.
+func NewReplayer(r io.Reader, speed float64) (*Replayer, error) {
+	records, err := NewRecordReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{
+		records: records,
+		speed:   speed,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Done is closed once every message of the recording has been dispatched.
+func (r *Replayer) Done() <-chan struct{} {
+	return r.done
+}
+
+// Err returns the error that ended the replay early, if any.
+func (r *Replayer) Err() error {
+	return r.err
+}
+
+func (r *Replayer) Open(name string) error {
+	r.start = time.Now()
+	return nil
+}
+
+func (r *Replayer) Close() error {
+	r.finish(nil)
+	return nil
+}
+
+func (r *Replayer) Call(proc string, args ...interface{}) error {
+	return nil
+}
+
+func (r *Replayer) GetNextDispatch() (unsafe.Pointer, int32, error) {
+	if r.next == nil {
+		r.next = r.nextDispatch()
+		if r.next == nil {
+			return nil, rFail, nil
+		}
+	}
+
+	if r.speed > 0 {
+		elapsed := time.Duration(float64(time.Since(r.start)) * r.speed)
+		if r.next.At > elapsed {
+			return nil, rFail, nil
+		}
+	}
+
+	r.current = r.next.Data
+	r.next = nil
+	if len(r.current) == 0 {
+		return nil, rFail, nil
+	}
+	return unsafe.Pointer(&r.current[0]), 0, nil
+}
+
+func (r *Replayer) nextDispatch() *Record {
+	for {
+		select {
+		case <-r.done:
+			return nil
+		default:
+		}
+
+		rec, err := r.records.Next()
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			r.finish(err)
+			return nil
+		}
+		if rec.Kind == RecordDispatch {
+			return rec
+		}
+	}
+}
+
+func (r *Replayer) finish(err error) {
+	r.doneOnce.Do(func() {
+		r.err = err
+		close(r.done)
+	})
+}
@@ -1,127 +1,136 @@
 package simconnect
 
 //go:generate go-bindata -pkg simconnect -o bindata.go -modtime 1 -prefix "../_vendor" "../_vendor/MSFS-SDK/SimConnect SDK/lib/SimConnect.dll"
+//go:generate go run ./internal/gendefs -header "../_vendor/MSFS-SDK/SimConnect SDK/include/SimConnect.h" -o simconnect_h.go
 
 // MSFS-SDK/SimConnect\ SDK/include/SimConnect.h
 // MSFS-SDK/SimConnect\ SDK/lib/SimConnect.dll
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"reflect"
-	"syscall"
+	"strconv"
+	"sync"
 	"unsafe"
 )
 
-var proc_SimConnect_Open *syscall.LazyProc
-var proc_SimConnect_Close *syscall.LazyProc
-var proc_SimConnect_AddToDataDefinition *syscall.LazyProc
-var proc_SimConnect_SubscribeToSystemEvent *syscall.LazyProc
-var proc_SimConnect_GetNextDispatch *syscall.LazyProc
-var proc_SimConnect_RequestDataOnSimObject *syscall.LazyProc
-var proc_SimConnect_RequestDataOnSimObjectType *syscall.LazyProc
-var proc_SimConnect_SetDataOnSimObject *syscall.LazyProc
-var proc_SimConnect_SubscribeToFacilities *syscall.LazyProc
-var proc_SimConnect_UnsubscribeToFacilities *syscall.LazyProc
-var proc_SimConnect_RequestFacilitiesList *syscall.LazyProc
-var proc_SimConnect_MapClientEventToSimEvent *syscall.LazyProc
-var proc_SimConnect_MenuAddItem *syscall.LazyProc
-var proc_SimConnect_MenuDeleteItem *syscall.LazyProc
-var proc_SimConnect_AddClientEventToNotificationGroup *syscall.LazyProc
-var proc_SimConnect_SetNotificationGroupPriority *syscall.LazyProc
-var proc_SimConnect_Text *syscall.LazyProc
+// firstRequestID is where GetRequestID starts counting, leaving the IDs below
+// it for callers that reuse their define ID as request ID.
+const firstRequestID DWORD = 0x10000
 
+// SimConnect is a connection to the simulator. Its methods are safe for
+// concurrent use; calls into the backend are serialised.
 type SimConnect struct {
-	handle      unsafe.Pointer
-	DefineMap   map[string]DWORD
+	backend Backend
+	callMu  sync.Mutex
+
+	// DefineMap holds the define ID of each struct type, and the next free
+	// one under "_last".
+	//
+	// Deprecated: reading it races with other goroutines using s; use
+	// LookupDefineID.
+	DefineMap map[string]DWORD
+	// LastEventID is the next event ID GetEventID hands out.
+	//
+	// Deprecated: reading it races with other goroutines using s.
 	LastEventID DWORD
+
+	mu            sync.Mutex // guards the fields above and below
+	registered    map[DWORD]bool
+	registering   map[DWORD]chan struct{} // closed when the build is done
+	lastRequestID DWORD
 }
 
+// New connects to the running simulator through SimConnect.dll.
 func New(name string) (*SimConnect, error) {
-	s := &SimConnect{
-		DefineMap:   map[string]DWORD{"_last": 0},
-		LastEventID: 0,
+	b, err := NewDLLBackend()
+	if err != nil {
+		return nil, err
 	}
+	return NewWithBackend(name, b)
+}
 
-	if proc_SimConnect_Open == nil {
-		exePath, err := os.Executable()
-		if err != nil {
-			return nil, err
-		}
+// NewWithBackend connects through b, for example a Recorder or a Replayer.
+func NewWithBackend(name string, b Backend) (*SimConnect, error) {
+	s := &SimConnect{
+		backend:       b,
+		DefineMap:     map[string]DWORD{"_last": 0},
+		registered:    map[DWORD]bool{},
+		registering:   map[DWORD]chan struct{}{},
+		lastRequestID: firstRequestID,
+	}
 
-		dllPath := filepath.Join(filepath.Dir(exePath), "SimConnect.dll")
-		if _, err = os.Stat(dllPath); os.IsNotExist(err) {
-			buf := MustAsset("MSFS-SDK/SimConnect SDK/lib/SimConnect.dll")
+	if err := b.Open(name); err != nil {
+		return nil, err
+	}
 
-			if err := os.WriteFile(dllPath, buf, 0644); err != nil {
-				return nil, err
-			}
-		}
+	return s, nil
+}
 
-		mod := syscall.NewLazyDLL(dllPath)
-		if err = mod.Load(); err != nil {
-			return nil, err
-		}
+func (s *SimConnect) call(proc string, args ...interface{}) error {
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+	return s.backend.Call(proc, args...)
+}
 
-		proc_SimConnect_Open = mod.NewProc("SimConnect_Open")
-		proc_SimConnect_Close = mod.NewProc("SimConnect_Close")
-		proc_SimConnect_AddToDataDefinition = mod.NewProc("SimConnect_AddToDataDefinition")
-		proc_SimConnect_SubscribeToSystemEvent = mod.NewProc("SimConnect_SubscribeToSystemEvent")
-		proc_SimConnect_GetNextDispatch = mod.NewProc("SimConnect_GetNextDispatch")
-		proc_SimConnect_RequestDataOnSimObject = mod.NewProc("SimConnect_RequestDataOnSimObject")
-		proc_SimConnect_RequestDataOnSimObjectType = mod.NewProc("SimConnect_RequestDataOnSimObjectType")
-		proc_SimConnect_SetDataOnSimObject = mod.NewProc("SimConnect_SetDataOnSimObject")
-		proc_SimConnect_SubscribeToFacilities = mod.NewProc("SimConnect_SubscribeToFacilities")
-		proc_SimConnect_UnsubscribeToFacilities = mod.NewProc("SimConnect_UnsubscribeToFacilities")
-		proc_SimConnect_RequestFacilitiesList = mod.NewProc("SimConnect_RequestFacilitiesList")
-		proc_SimConnect_MapClientEventToSimEvent = mod.NewProc("SimConnect_MapClientEventToSimEvent")
-		proc_SimConnect_MenuAddItem = mod.NewProc("SimConnect_MenuAddItem")
-		proc_SimConnect_MenuDeleteItem = mod.NewProc("SimConnect_MenuDeleteItem")
-		proc_SimConnect_AddClientEventToNotificationGroup = mod.NewProc("SimConnect_AddClientEventToNotificationGroup")
-		proc_SimConnect_SetNotificationGroupPriority = mod.NewProc("SimConnect_SetNotificationGroupPriority")
-		proc_SimConnect_Text = mod.NewProc("SimConnect_Text")
-	}
-
-	// SimConnect_Open(
-	//   HANDLE * phSimConnect,
-	//   LPCSTR szName,
-	//   HWND hWnd,
-	//   DWORD UserEventWin32,
-	//   HANDLE hEventHandle,
-	//   DWORD ConfigIndex
-	// );
+// callTracked is call followed by GetLastSentPacketID, so exceptions can be
+// matched back to the call that caused them.
+func (s *SimConnect) callTracked(proc string, args ...interface{}) (DWORD, error) {
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
 
-	var ptr, err = syscall.UTF16PtrFromString(name)
-	if err != nil {
-		return nil, err
-	}
-	args := []uintptr{
-		uintptr(unsafe.Pointer(&s.handle)),
-		uintptr(unsafe.Pointer(ptr)),
-		0,
-		0,
-		0,
-		0,
+	if err := s.backend.Call(proc, args...); err != nil {
+		return 0, err
 	}
+	return s.lastSentPacketID()
+}
 
-	r1, _, err := proc_SimConnect_Open.Call(args...)
-	if int32(r1) < 0 {
-		return nil, fmt.Errorf("SimConnect_Open error: %d %s", int32(r1), err)
-	}
+// GetLastSentPacketID returns the ID of the last packet sent to the simulator,
+// which is what RecvException.SendID refers to.
+func (s *SimConnect) GetLastSentPacketID() (DWORD, error) {
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+	return s.lastSentPacketID()
+}
 
-	return s, nil
+func (s *SimConnect) lastSentPacketID() (DWORD, error) {
+	// SimConnect_GetLastSentPacketID(
+	//   HANDLE hSimConnect,
+	//   DWORD * pdwError
+	// );
+
+	var buf [4]byte
+	if err := s.backend.Call("GetLastSentPacketID", buf[:]); err != nil {
+		return 0, fmt.Errorf("GetLastSentPacketID: %w", err)
+	}
+	return *(*DWORD)(unsafe.Pointer(&buf[0])), nil
 }
 
 func (s *SimConnect) GetEventID() DWORD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	id := s.LastEventID
 	s.LastEventID += 1
 	return id
 }
 
+// GetRequestID returns a request ID that is not shared with any define ID.
+func (s *SimConnect) GetRequestID() DWORD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.lastRequestID
+	s.lastRequestID += 1
+	return id
+}
+
 func (s *SimConnect) GetDefineID(a interface{}) DWORD {
 	structName := reflect.TypeOf(a).Elem().Name()
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	id, ok := s.DefineMap[structName]
 	if !ok {
 		id = s.DefineMap["_last"]
@@ -132,19 +141,82 @@ func (s *SimConnect) GetDefineID(a interface{}) DWORD {
 	return id
 }
 
+// NewDefineID returns a define ID for a data definition that is built at
+// runtime with AddToDataDefinition rather than from a struct.
+func (s *SimConnect) NewDefineID() DWORD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.DefineMap["_last"]
+	s.DefineMap["_last"] = id + 1
+	return id
+}
+
+// LookupDefineID returns the define ID handed out for the named struct type.
+func (s *SimConnect) LookupDefineID(structName string) (DWORD, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.DefineMap[structName]
+	return id, ok
+}
+
+// RegisterDataDefinition adds the tagged fields of the struct a points to as
+// a data definition. Registering the same type again is a no-op; a caller
+// that comes while another goroutine registers it waits until that is done.
+// A definition that failed is cleared so the next call starts over.
 func (s *SimConnect) RegisterDataDefinition(a interface{}) error {
 	defineID := s.GetDefineID(a)
 
+	s.mu.Lock()
+	for {
+		if s.registered[defineID] {
+			s.mu.Unlock()
+			return nil
+		}
+		building, ok := s.registering[defineID]
+		if !ok {
+			break
+		}
+		s.mu.Unlock()
+		<-building
+		s.mu.Lock()
+	}
+	done := make(chan struct{})
+	s.registering[defineID] = done
+	s.mu.Unlock()
+
+	err := s.addDataDefinition(defineID, a)
+	if err != nil {
+		s.ClearDataDefinition(defineID)
+	}
+
+	s.mu.Lock()
+	delete(s.registering, defineID)
+	if err == nil {
+		s.registered[defineID] = true
+	}
+	close(done)
+	s.mu.Unlock()
+	return err
+}
+
+// addDataDefinition adds the fields of a to defineID.
+func (s *SimConnect) addDataDefinition(defineID DWORD, a interface{}) error {
 	v := reflect.ValueOf(a).Elem()
 	for j := 1; j < v.NumField(); j++ {
 		fieldName := v.Type().Field(j).Name
 		nameTag, _ := v.Type().Field(j).Tag.Lookup("name")
 		unitTag, _ := v.Type().Field(j).Tag.Lookup("unit")
+		epsilonTag, _ := v.Type().Field(j).Tag.Lookup("epsilon")
 
 		fieldType := v.Field(j).Kind().String()
 		if fieldType == "array" {
 			fieldType = fmt.Sprintf("[%d]byte", v.Field(j).Type().Len())
 		}
+		if fieldType == "struct" {
+			fieldType = v.Field(j).Type().Name()
+		}
 
 		if nameTag == "" {
 			return fmt.Errorf("%s name tag not found", fieldName)
@@ -155,7 +227,17 @@ func (s *SimConnect) RegisterDataDefinition(a interface{}) error {
 			return err
 		}
 
-		s.AddToDataDefinition(defineID, nameTag, unitTag, dataType)
+		var epsilon float64
+		if epsilonTag != "" {
+			epsilon, err = strconv.ParseFloat(epsilonTag, 32)
+			if err != nil {
+				return fmt.Errorf("%s epsilon tag: %w", fieldName, err)
+			}
+		}
+
+		if err := s.AddToDataDefinition(defineID, nameTag, unitTag, dataType, float32(epsilon)); err != nil {
+			return err
+		}
 		//fmt.Printf("fieldName: %s  fieldType: %s  nameTag: %s unitTag: %s\n", fieldName, fieldType, nameTag, unitTag)
 	}
 
@@ -163,17 +245,14 @@ func (s *SimConnect) RegisterDataDefinition(a interface{}) error {
 }
 
 func (s *SimConnect) Close() error {
-	// SimConnect_Open(
-	//   HANDLE * phSimConnect,
-	// );
-	r1, _, err := proc_SimConnect_Close.Call(uintptr(s.handle))
-	if int32(r1) < 0 {
-		return fmt.Errorf("SimConnect_Close error: %d %s", int32(r1), err)
-	}
-	return nil
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+	return s.backend.Close()
 }
 
-func (s *SimConnect) AddToDataDefinition(defineID DWORD, name, unit string, dataType DWORD) error {
+// AddToDataDefinition adds a simvar to a data definition. With
+// DATA_REQUEST_FLAG_CHANGED, changes smaller than epsilon are not reported.
+func (s *SimConnect) AddToDataDefinition(defineID DWORD, name, unit string, dataType DWORD, epsilon float32) error {
 	// SimConnect_AddToDataDefinition(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
@@ -184,27 +263,34 @@ func (s *SimConnect) AddToDataDefinition(defineID DWORD, name, unit string, data
 	//   DWORD DatumID = SIMCONNECT_UNUSED
 	// );
 
-	_name := []byte(name + "\x00")
-	_unit := []byte(unit + "\x00")
-
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(defineID),
-		uintptr(unsafe.Pointer(&_name[0])),
-		uintptr(0),
-		uintptr(dataType),
-		uintptr(float32(0)),
-		uintptr(UNUSED),
-	}
+	var _unit interface{} = []byte(nil)
 	if unit != "" {
-		args[3] = uintptr(unsafe.Pointer(&_unit[0]))
+		_unit = unit
 	}
 
-	r1, _, err := proc_SimConnect_AddToDataDefinition.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf("SimConnect_AddToDataDefinition for %s error: %d %s", name, r1, err)
+	err := s.call("AddToDataDefinition", defineID, name, _unit, dataType, epsilon, UNUSED)
+	if err != nil {
+		return fmt.Errorf("AddToDataDefinition for %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (s *SimConnect) ClearDataDefinition(defineID DWORD) error {
+	// SimConnect_ClearDataDefinition(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID
+	// );
+
+	err := s.call("ClearDataDefinition", defineID)
+	if err != nil {
+		return fmt.Errorf("ClearDataDefinition for defineID %d: %w", defineID, err)
 	}
 
+	s.mu.Lock()
+	delete(s.registered, defineID)
+	s.mu.Unlock()
+
 	return nil
 }
 
@@ -215,17 +301,9 @@ func (s *SimConnect) SubscribeToSystemEvent(eventID DWORD, eventName string) err
 	//   const char * SystemEventName
 	// );
 
-	_eventName := []byte(eventName + "\x00")
-
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(eventID),
-		uintptr(unsafe.Pointer(&_eventName[0])),
-	}
-
-	r1, _, err := proc_SimConnect_SubscribeToSystemEvent.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf("SimConnect_SubscribeToSystemEvent for %s error: %d %s", eventName, r1, err)
+	err := s.call("SubscribeToSystemEvent", eventID, eventName)
+	if err != nil {
+		return fmt.Errorf("SubscribeToSystemEvent for %s: %w", eventName, err)
 	}
 
 	return nil
@@ -239,19 +317,12 @@ func (s *SimConnect) RequestDataOnSimObjectType(requestID, defineID, radius, sim
 	//   DWORD dwRadiusMeters,
 	//   SIMCONNECT_SIMOBJECT_TYPE type
 	// );
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(requestID),
-		uintptr(defineID),
-		uintptr(radius),
-		uintptr(simobjectType),
-	}
 
-	r1, _, err := proc_SimConnect_RequestDataOnSimObjectType.Call(args...)
-	if int32(r1) < 0 {
+	err := s.call("RequestDataOnSimObjectType", requestID, defineID, radius, simobjectType)
+	if err != nil {
 		return fmt.Errorf(
-			"SimConnect_RequestDataOnSimObjectType for requestID %d defineID %d error: %d %s",
-			requestID, defineID, r1, err,
+			"RequestDataOnSimObjectType for requestID %d defineID %d: %w",
+			requestID, defineID, err,
 		)
 	}
 
@@ -271,23 +342,11 @@ func (s *SimConnect) RequestDataOnSimObject(requestID, defineID, objectID, perio
 	//   DWORD limit = 0
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(requestID),
-		uintptr(defineID),
-		uintptr(objectID),
-		uintptr(period),
-		uintptr(flags),
-		uintptr(origin),
-		uintptr(interval),
-		uintptr(limit),
-	}
-
-	r1, _, err := proc_SimConnect_RequestDataOnSimObject.Call(args...)
-	if int32(r1) < 0 {
+	err := s.call("RequestDataOnSimObject", requestID, defineID, objectID, period, flags, origin, interval, limit)
+	if err != nil {
 		return fmt.Errorf(
-			"SimConnect_RequestDataOnSimObject for requestID %d defineID %d error: %d %s",
-			requestID, defineID, r1, err,
+			"RequestDataOnSimObject for requestID %d defineID %d: %w",
+			requestID, defineID, err,
 		)
 	}
 
@@ -306,22 +365,12 @@ func (s *SimConnect) SetDataOnSimObject(defineID, simobjectType, flags, arrayCou
 	//   DWORD cbUnitSize,
 	//   void * pDataSet
 	// );
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(defineID),
-		uintptr(simobjectType),
-		uintptr(flags),
-		uintptr(arrayCount),
-		uintptr(size),
-		uintptr(buf),
-	}
-
-	r1, _, err := proc_SimConnect_SetDataOnSimObject.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"SimConnect_SetDataOnSimObject for defineID %d error: %d %s",
-			defineID, r1, err,
-		)
+
+	data := (*[1 << 30]byte)(buf)[:size:size]
+
+	err := s.call("SetDataOnSimObject", defineID, simobjectType, flags, arrayCount, size, data)
+	if err != nil {
+		return fmt.Errorf("SetDataOnSimObject for defineID %d: %w", defineID, err)
 	}
 
 	return nil
@@ -334,18 +383,9 @@ func (s *SimConnect) SubscribeToFacilities(facilityType, requestID DWORD) error
 	//   SIMCONNECT_DATA_REQUEST_ID RequestID
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(facilityType),
-		uintptr(requestID),
-	}
-
-	r1, _, err := proc_SimConnect_SubscribeToFacilities.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"SimConnect_SubscribeToFacilities for type %d error: %d %s",
-			facilityType, r1, err,
-		)
+	err := s.call("SubscribeToFacilities", facilityType, requestID)
+	if err != nil {
+		return fmt.Errorf("SubscribeToFacilities for type %d: %w", facilityType, err)
 	}
 
 	return nil
@@ -357,17 +397,9 @@ func (s *SimConnect) UnsubscribeToFacilities(facilityType DWORD) error {
 	//   SIMCONNECT_FACILITY_LIST_TYPE type
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(facilityType),
-	}
-
-	r1, _, err := proc_SimConnect_UnsubscribeToFacilities.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"UnsubscribeToFacilities for type %d error: %d %s",
-			facilityType, r1, err,
-		)
+	err := s.call("UnsubscribeToFacilities", facilityType)
+	if err != nil {
+		return fmt.Errorf("UnsubscribeToFacilities for type %d: %w", facilityType, err)
 	}
 
 	return nil
@@ -380,18 +412,41 @@ func (s *SimConnect) RequestFacilitiesList(facilityType, requestID DWORD) error
 	//   SIMCONNECT_DATA_REQUEST_ID RequestID
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(facilityType),
-		uintptr(requestID),
+	err := s.call("RequestFacilitiesList", facilityType, requestID)
+	if err != nil {
+		return fmt.Errorf("RequestFacilitiesList for type %d: %w", facilityType, err)
 	}
 
-	r1, _, err := proc_SimConnect_RequestFacilitiesList.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"SimConnect_RequestFacilitiesList for type %d error: %d %s",
-			facilityType, r1, err,
-		)
+	return nil
+}
+
+func (s *SimConnect) AddToFacilityDefinition(defineID DWORD, fieldName string) error {
+	// SimConnect_AddToFacilityDefinition(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
+	//   const char * FieldName
+	// );
+
+	err := s.call("AddToFacilityDefinition", defineID, fieldName)
+	if err != nil {
+		return fmt.Errorf("AddToFacilityDefinition for defineID %d field %s: %w", defineID, fieldName, err)
+	}
+
+	return nil
+}
+
+func (s *SimConnect) RequestFacilityData(defineID, requestID DWORD, icao, region string) error {
+	// SimConnect_RequestFacilityData(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_DEFINITION_ID DefineID,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   const char * ICAO,
+	//   const char * Region = ""
+	// );
+
+	err := s.call("RequestFacilityData", defineID, requestID, icao, region)
+	if err != nil {
+		return fmt.Errorf("RequestFacilityData for %s: %w", icao, err)
 	}
 
 	return nil
@@ -404,20 +459,26 @@ func (s *SimConnect) MapClientEventToSimEvent(eventID DWORD, eventName string) e
 	//   const char * EventName = ""
 	// );
 
-	_eventName := []byte(eventName + "\x00")
-
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(eventID),
-		uintptr(unsafe.Pointer(&_eventName[0])),
+	err := s.call("MapClientEventToSimEvent", eventID, eventName)
+	if err != nil {
+		return fmt.Errorf("MapClientEventToSimEvent for eventID %d: %w", eventID, err)
 	}
 
-	r1, _, err := proc_SimConnect_MapClientEventToSimEvent.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"SimConnect_MapClientEventToSimEvent for eventID %d error: %d %s",
-			eventID, r1, err,
-		)
+	return nil
+}
+
+// RequestSystemState asks for a system state such as "Sim" or
+// "AircraftLoaded". The answer is a RecvSystemState with requestID.
+func (s *SimConnect) RequestSystemState(requestID DWORD, state string) error {
+	// SimConnect_RequestSystemState(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_DATA_REQUEST_ID RequestID,
+	//   const char * szState
+	// );
+
+	err := s.call("RequestSystemState", requestID, state)
+	if err != nil {
+		return fmt.Errorf("RequestSystemState for %s: %w", state, err)
 	}
 
 	return nil
@@ -431,43 +492,55 @@ func (s *SimConnect) MenuAddItem(menuItem string, menuEventID, Data DWORD) error
 	//   DWORD dwData
 	// );
 
-	_menuItem := []byte(menuItem + "\x00")
-
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(unsafe.Pointer(&_menuItem[0])),
-		uintptr(menuEventID),
-		uintptr(Data),
-	}
-
-	r1, _, err := proc_SimConnect_MenuAddItem.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"SimConnect_MenuAddItem for menuEventID %d '%s' error: %d %s",
-			menuEventID, menuItem, r1, err,
-		)
+	err := s.call("MenuAddItem", menuItem, menuEventID, Data)
+	if err != nil {
+		return fmt.Errorf("MenuAddItem for menuEventID %d '%s': %w", menuEventID, menuItem, err)
 	}
 
 	return nil
 }
 
-func (s *SimConnect) MenuDeleteItem(menuItem string, menuEventID, Data DWORD) error {
+func (s *SimConnect) MenuDeleteItem(menuEventID DWORD) error {
 	// SimConnect_MenuDeleteItem(
 	//   HANDLE hSimConnect,
 	//   SIMCONNECT_CLIENT_EVENT_ID MenuEventID
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(menuEventID),
+	err := s.call("MenuDeleteItem", menuEventID)
+	if err != nil {
+		return fmt.Errorf("MenuDeleteItem for menuEventID %d: %w", menuEventID, err)
 	}
 
-	r1, _, err := proc_SimConnect_MenuDeleteItem.Call(args...)
-	if int32(r1) < 0 {
-		return fmt.Errorf(
-			"SimConnect_MenuDeleteItem for menuEventID %d error: %d %s",
-			menuEventID, r1, err,
-		)
+	return nil
+}
+
+func (s *SimConnect) MenuAddSubItem(menuEventID DWORD, menuItem string, subMenuEventID, data DWORD) error {
+	// SimConnect_MenuAddSubItem(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_EVENT_ID MenuEventID,
+	//   const char * szMenuItem,
+	//   SIMCONNECT_CLIENT_EVENT_ID SubMenuEventID,
+	//   DWORD dwData
+	// );
+
+	err := s.call("MenuAddSubItem", menuEventID, menuItem, subMenuEventID, data)
+	if err != nil {
+		return fmt.Errorf("MenuAddSubItem for subMenuEventID %d '%s': %w", subMenuEventID, menuItem, err)
+	}
+
+	return nil
+}
+
+func (s *SimConnect) MenuDeleteSubItem(menuEventID, subMenuEventID DWORD) error {
+	// SimConnect_MenuDeleteSubItem(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_CLIENT_EVENT_ID MenuEventID,
+	//   const SIMCONNECT_CLIENT_EVENT_ID SubMenuEventID
+	// );
+
+	err := s.call("MenuDeleteSubItem", menuEventID, subMenuEventID)
+	if err != nil {
+		return fmt.Errorf("MenuDeleteSubItem for subMenuEventID %d: %w", subMenuEventID, err)
 	}
 
 	return nil
@@ -481,17 +554,11 @@ func (s *SimConnect) AddClientEventToNotificationGroup(groupID, eventID DWORD) e
 	//   BOOL bMaskable = FALSE
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(groupID),
-		uintptr(eventID),
-	}
-
-	r1, _, err := proc_SimConnect_AddClientEventToNotificationGroup.Call(args...)
-	if int32(r1) < 0 {
+	err := s.call("AddClientEventToNotificationGroup", groupID, eventID)
+	if err != nil {
 		return fmt.Errorf(
-			"SimConnect_AddClientEventToNotificationGroup for groupID %d eventID %d error: %d %s",
-			groupID, eventID, r1, err,
+			"AddClientEventToNotificationGroup for groupID %d eventID %d: %w",
+			groupID, eventID, err,
 		)
 	}
 
@@ -505,23 +572,35 @@ func (s *SimConnect) SetNotificationGroupPriority(groupID, priority DWORD) error
 	//   DWORD uPriority
 	// );
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(groupID),
-		uintptr(priority),
-	}
-
-	r1, _, err := proc_SimConnect_SetNotificationGroupPriority.Call(args...)
-	if int32(r1) < 0 {
+	err := s.call("SetNotificationGroupPriority", groupID, priority)
+	if err != nil {
 		return fmt.Errorf(
-			"SimConnect_SetNotificationGroupPriority for groupID %d priority %d error: %d %s",
-			groupID, priority, r1, err,
+			"SetNotificationGroupPriority for groupID %d priority %d: %w",
+			groupID, priority, err,
 		)
 	}
 
 	return nil
 }
 
+func (s *SimConnect) TransmitClientEvent(objectID, eventID, data, groupID, flags DWORD) error {
+	// SimConnect_TransmitClientEvent(
+	//   HANDLE hSimConnect,
+	//   SIMCONNECT_OBJECT_ID ObjectID,
+	//   SIMCONNECT_CLIENT_EVENT_ID EventID,
+	//   DWORD dwData,
+	//   SIMCONNECT_NOTIFICATION_GROUP_ID GroupID,
+	//   SIMCONNECT_EVENT_FLAG Flags
+	// );
+
+	err := s.call("TransmitClientEvent", objectID, eventID, data, groupID, flags)
+	if err != nil {
+		return fmt.Errorf("TransmitClientEvent for eventID %d: %w", eventID, err)
+	}
+
+	return nil
+}
+
 func (s *SimConnect) ShowText(textType DWORD, duration float64, eventID DWORD, text string) error {
 	// SimConnect_Text(
 	//   HANDLE hSimConnect,
@@ -534,20 +613,11 @@ func (s *SimConnect) ShowText(textType DWORD, duration float64, eventID DWORD, t
 
 	_text := []byte(text + "\x00")
 
-	args := []uintptr{
-		uintptr(s.handle),
-		uintptr(textType),
-		uintptr(duration),
-		uintptr(eventID),
-		uintptr(DWORD(len(_text))),
-		uintptr(unsafe.Pointer(&_text[0])),
-	}
-
-	r1, _, err := proc_SimConnect_Text.Call(args...)
-	if int32(r1) < 0 {
+	err := s.call("Text", textType, float32(duration), eventID, DWORD(len(_text)), _text)
+	if err != nil {
 		return fmt.Errorf(
-			"SimConnect_Text for eventID %d textType %d text '%s' error: %d %s",
-			eventID, textType, text, r1, err,
+			"Text for eventID %d textType %d text '%s': %w",
+			eventID, textType, text, err,
 		)
 	}
 
@@ -555,14 +625,7 @@ func (s *SimConnect) ShowText(textType DWORD, duration float64, eventID DWORD, t
 }
 
 func (s *SimConnect) GetNextDispatch() (unsafe.Pointer, int32, error) {
-	var ppData unsafe.Pointer
-	var ppDataLength DWORD
-
-	r1, _, err := proc_SimConnect_GetNextDispatch.Call(
-		uintptr(s.handle),
-		uintptr(unsafe.Pointer(&ppData)),
-		uintptr(unsafe.Pointer(&ppDataLength)),
-	)
-
-	return ppData, int32(r1), err
+	s.callMu.Lock()
+	defer s.callMu.Unlock()
+	return s.backend.GetNextDispatch()
 }
@@ -0,0 +1,2169 @@
+// Code generated by gendefs from SimConnect.h. DO NOT EDIT.
+
+package simconnect
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	UNUSED         DWORD = 0xFFFFFFFF // special value to indicate unused event, ID
+	OBJECT_ID_USER DWORD = 0          // proxy value for User vehicle ObjectID
+)
+
+const (
+	CAMERA_IGNORE_FIELD float32 = math.MaxFloat32 // Used to tell the Camera API to NOT modify the value in this part of the argument.
+)
+
+const (
+	CLIENTDATA_MAX_SIZE DWORD = 8192 // maximum value for SimConnect_CreateClientData dwSize parameter
+)
+
+// Notification Group priority values
+const (
+	GROUP_PRIORITY_HIGHEST          DWORD = 1          // highest priority
+	GROUP_PRIORITY_HIGHEST_MASKABLE DWORD = 10000000   // highest priority that allows events to be masked
+	GROUP_PRIORITY_STANDARD         DWORD = 1900000000 // standard priority
+	GROUP_PRIORITY_DEFAULT          DWORD = 2000000000 // default priority
+	GROUP_PRIORITY_LOWEST           DWORD = 4000000000 // priorities lower than this will be ignored
+)
+
+// Weather observations Metar strings
+const (
+	MAX_METAR_LENGTH DWORD = 2000
+)
+
+// Maximum thermal size is 100 km.
+const (
+	MAX_THERMAL_SIZE float32 = 100000
+	MAX_THERMAL_RATE float32 = 1000
+)
+
+// SIMCONNECT_DATA_INITPOSITION.Airspeed
+const (
+	INITPOSITION_AIRSPEED_CRUISE DWORD = 0xFFFFFFFF // aircraft's cruise airspeed
+	INITPOSITION_AIRSPEED_KEEP   DWORD = 0xFFFFFFFE // keep current airspeed
+)
+
+// AddToClientDataDefinition dwSizeOrType parameter type values
+const (
+	CLIENTDATATYPE_INT8    DWORD = 0xFFFFFFFF // 8-bit integer number
+	CLIENTDATATYPE_INT16   DWORD = 0xFFFFFFFE // 16-bit integer number
+	CLIENTDATATYPE_INT32   DWORD = 0xFFFFFFFD // 32-bit integer number
+	CLIENTDATATYPE_INT64   DWORD = 0xFFFFFFFC // 64-bit integer number
+	CLIENTDATATYPE_FLOAT32 DWORD = 0xFFFFFFFB // 32-bit floating-point number (float)
+	CLIENTDATATYPE_FLOAT64 DWORD = 0xFFFFFFFA // 64-bit floating-point number (double)
+)
+
+// AddToClientDataDefinition dwOffset parameter special values
+const (
+	CLIENTDATAOFFSET_AUTO DWORD = 0xFFFFFFFF // automatically compute offset of the ClientData variable
+)
+
+// Open ConfigIndex parameter special value
+const (
+	OPEN_CONFIGINDEX_LOCAL DWORD = 0xFFFFFFFF // ignore SimConnect.cfg settings, and force local connection
+)
+
+const (
+	RECV_ID_VOR_LIST_HAS_NAV_SIGNAL  DWORD = 0x00000001 // Has Nav signal
+	RECV_ID_VOR_LIST_HAS_LOCALIZER   DWORD = 0x00000002 // Has localizer
+	RECV_ID_VOR_LIST_HAS_GLIDE_SLOPE DWORD = 0x00000004 // Has Nav signal
+	RECV_ID_VOR_LIST_HAS_DME         DWORD = 0x00000008 // Station has DME
+)
+
+const (
+	WAYPOINT_NONE                   DWORD = 0x00000000
+	WAYPOINT_SPEED_REQUESTED        DWORD = 0x00000004 // requested speed at waypoint is valid
+	WAYPOINT_THROTTLE_REQUESTED     DWORD = 0x00000008 // request a specific throttle percentage
+	WAYPOINT_COMPUTE_VERTICAL_SPEED DWORD = 0x00000010 // compute vertical to speed to reach waypoint altitude when crossing the waypoint
+	WAYPOINT_ALTITUDE_IS_AGL        DWORD = 0x00000020 // AltitudeIsAGL
+	WAYPOINT_ON_GROUND              DWORD = 0x00100000 // place this waypoint on the ground
+	WAYPOINT_REVERSE                DWORD = 0x00200000 // Back up to this waypoint. Only valid on first waypoint
+	WAYPOINT_WRAP_TO_FIRST          DWORD = 0x00400000 // Wrap around back to first waypoint. Only valid on last waypoint.
+)
+
+const (
+	EVENT_FLAG_DEFAULT             DWORD = 0x00000000
+	EVENT_FLAG_FAST_REPEAT_TIMER   DWORD = 0x00000001 // set event repeat timer to simulate fast repeat
+	EVENT_FLAG_SLOW_REPEAT_TIMER   DWORD = 0x00000002 // set event repeat timer to simulate slow repeat
+	EVENT_FLAG_GROUPID_IS_PRIORITY DWORD = 0x00000010 // interpret GroupID parameter as priority value
+)
+
+const (
+	DATA_REQUEST_FLAG_DEFAULT DWORD = 0x00000000
+	DATA_REQUEST_FLAG_CHANGED DWORD = 0x00000001 // send requested data when value(s) change
+	DATA_REQUEST_FLAG_TAGGED  DWORD = 0x00000002 // send requested data in tagged format
+)
+
+const (
+	DATA_SET_FLAG_DEFAULT DWORD = 0x00000000
+	DATA_SET_FLAG_TAGGED  DWORD = 0x00000001 // data is in tagged format
+)
+
+const (
+	CREATE_CLIENT_DATA_FLAG_DEFAULT   DWORD = 0x00000000
+	CREATE_CLIENT_DATA_FLAG_READ_ONLY DWORD = 0x00000001 // permit only ClientData creator to write into ClientData
+)
+
+const (
+	CLIENT_DATA_REQUEST_FLAG_DEFAULT DWORD = 0x00000000
+	CLIENT_DATA_REQUEST_FLAG_CHANGED DWORD = 0x00000001 // send requested ClientData when value(s) change
+	CLIENT_DATA_REQUEST_FLAG_TAGGED  DWORD = 0x00000002 // send requested ClientData in tagged format
+)
+
+const (
+	CLIENT_DATA_SET_FLAG_DEFAULT DWORD = 0x00000000
+	CLIENT_DATA_SET_FLAG_TAGGED  DWORD = 0x00000001 // data is in tagged format
+)
+
+const (
+	VIEW_SYSTEM_EVENT_DATA_COCKPIT_2D      DWORD = 0x00000001 // 2D Panels in cockpit view
+	VIEW_SYSTEM_EVENT_DATA_COCKPIT_VIRTUAL DWORD = 0x00000002 // Virtual (3D) panels in cockpit view
+	VIEW_SYSTEM_EVENT_DATA_ORTHOGONAL      DWORD = 0x00000004 // Orthogonal (Map) view
+)
+
+const (
+	SOUND_SYSTEM_EVENT_DATA_MASTER DWORD = 0x00000001 // Sound Master
+)
+
+const (
+	CLOUD_STATE_ARRAY_WIDTH = 64
+	CLOUD_STATE_ARRAY_SIZE  = 4096
+)
+
+// Receive data types
+// SIMCONNECT_RECV_ID
+const (
+	RECV_ID_NULL                             DWORD = 0
+	RECV_ID_EXCEPTION                        DWORD = 1
+	RECV_ID_OPEN                             DWORD = 2
+	RECV_ID_QUIT                             DWORD = 3
+	RECV_ID_EVENT                            DWORD = 4
+	RECV_ID_EVENT_OBJECT_ADDREMOVE           DWORD = 5
+	RECV_ID_EVENT_FILENAME                   DWORD = 6
+	RECV_ID_EVENT_FRAME                      DWORD = 7
+	RECV_ID_SIMOBJECT_DATA                   DWORD = 8
+	RECV_ID_SIMOBJECT_DATA_BYTYPE            DWORD = 9
+	RECV_ID_WEATHER_OBSERVATION              DWORD = 10
+	RECV_ID_CLOUD_STATE                      DWORD = 11
+	RECV_ID_ASSIGNED_OBJECT_ID               DWORD = 12
+	RECV_ID_RESERVED_KEY                     DWORD = 13
+	RECV_ID_CUSTOM_ACTION                    DWORD = 14
+	RECV_ID_SYSTEM_STATE                     DWORD = 15
+	RECV_ID_CLIENT_DATA                      DWORD = 16
+	RECV_ID_EVENT_WEATHER_MODE               DWORD = 17
+	RECV_ID_AIRPORT_LIST                     DWORD = 18
+	RECV_ID_VOR_LIST                         DWORD = 19
+	RECV_ID_NDB_LIST                         DWORD = 20
+	RECV_ID_WAYPOINT_LIST                    DWORD = 21
+	RECV_ID_EVENT_MULTIPLAYER_SERVER_STARTED DWORD = 22
+	RECV_ID_EVENT_MULTIPLAYER_CLIENT_STARTED DWORD = 23
+	RECV_ID_EVENT_MULTIPLAYER_SESSION_ENDED  DWORD = 24
+	RECV_ID_EVENT_RACE_END                   DWORD = 25
+	RECV_ID_EVENT_RACE_LAP                   DWORD = 26
+	RECV_ID_EVENT_EX1                        DWORD = 27
+	RECV_ID_FACILITY_DATA                    DWORD = 28
+	RECV_ID_FACILITY_DATA_END                DWORD = 29
+	RECV_ID_FACILITY_MINIMAL_LIST            DWORD = 30
+	RECV_ID_JETWAY_DATA                      DWORD = 31
+	RECV_ID_CONTROLLERS_LIST                 DWORD = 32
+	RECV_ID_ACTION_CALLBACK                  DWORD = 33
+	RECV_ID_ENUMERATE_INPUT_EVENTS           DWORD = 34
+	RECV_ID_GET_INPUT_EVENT                  DWORD = 35
+	RECV_ID_SUBSCRIBE_INPUT_EVENT            DWORD = 36
+	RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS     DWORD = 37
+)
+
+// RecvIDNames maps SIMCONNECT_RECV_ID values to their names.
+var RecvIDNames = map[DWORD]string{
+	RECV_ID_NULL:                             "RECV_ID_NULL",
+	RECV_ID_EXCEPTION:                        "RECV_ID_EXCEPTION",
+	RECV_ID_OPEN:                             "RECV_ID_OPEN",
+	RECV_ID_QUIT:                             "RECV_ID_QUIT",
+	RECV_ID_EVENT:                            "RECV_ID_EVENT",
+	RECV_ID_EVENT_OBJECT_ADDREMOVE:           "RECV_ID_EVENT_OBJECT_ADDREMOVE",
+	RECV_ID_EVENT_FILENAME:                   "RECV_ID_EVENT_FILENAME",
+	RECV_ID_EVENT_FRAME:                      "RECV_ID_EVENT_FRAME",
+	RECV_ID_SIMOBJECT_DATA:                   "RECV_ID_SIMOBJECT_DATA",
+	RECV_ID_SIMOBJECT_DATA_BYTYPE:            "RECV_ID_SIMOBJECT_DATA_BYTYPE",
+	RECV_ID_WEATHER_OBSERVATION:              "RECV_ID_WEATHER_OBSERVATION",
+	RECV_ID_CLOUD_STATE:                      "RECV_ID_CLOUD_STATE",
+	RECV_ID_ASSIGNED_OBJECT_ID:               "RECV_ID_ASSIGNED_OBJECT_ID",
+	RECV_ID_RESERVED_KEY:                     "RECV_ID_RESERVED_KEY",
+	RECV_ID_CUSTOM_ACTION:                    "RECV_ID_CUSTOM_ACTION",
+	RECV_ID_SYSTEM_STATE:                     "RECV_ID_SYSTEM_STATE",
+	RECV_ID_CLIENT_DATA:                      "RECV_ID_CLIENT_DATA",
+	RECV_ID_EVENT_WEATHER_MODE:               "RECV_ID_EVENT_WEATHER_MODE",
+	RECV_ID_AIRPORT_LIST:                     "RECV_ID_AIRPORT_LIST",
+	RECV_ID_VOR_LIST:                         "RECV_ID_VOR_LIST",
+	RECV_ID_NDB_LIST:                         "RECV_ID_NDB_LIST",
+	RECV_ID_WAYPOINT_LIST:                    "RECV_ID_WAYPOINT_LIST",
+	RECV_ID_EVENT_MULTIPLAYER_SERVER_STARTED: "RECV_ID_EVENT_MULTIPLAYER_SERVER_STARTED",
+	RECV_ID_EVENT_MULTIPLAYER_CLIENT_STARTED: "RECV_ID_EVENT_MULTIPLAYER_CLIENT_STARTED",
+	RECV_ID_EVENT_MULTIPLAYER_SESSION_ENDED:  "RECV_ID_EVENT_MULTIPLAYER_SESSION_ENDED",
+	RECV_ID_EVENT_RACE_END:                   "RECV_ID_EVENT_RACE_END",
+	RECV_ID_EVENT_RACE_LAP:                   "RECV_ID_EVENT_RACE_LAP",
+	RECV_ID_EVENT_EX1:                        "RECV_ID_EVENT_EX1",
+	RECV_ID_FACILITY_DATA:                    "RECV_ID_FACILITY_DATA",
+	RECV_ID_FACILITY_DATA_END:                "RECV_ID_FACILITY_DATA_END",
+	RECV_ID_FACILITY_MINIMAL_LIST:            "RECV_ID_FACILITY_MINIMAL_LIST",
+	RECV_ID_JETWAY_DATA:                      "RECV_ID_JETWAY_DATA",
+	RECV_ID_CONTROLLERS_LIST:                 "RECV_ID_CONTROLLERS_LIST",
+	RECV_ID_ACTION_CALLBACK:                  "RECV_ID_ACTION_CALLBACK",
+	RECV_ID_ENUMERATE_INPUT_EVENTS:           "RECV_ID_ENUMERATE_INPUT_EVENTS",
+	RECV_ID_GET_INPUT_EVENT:                  "RECV_ID_GET_INPUT_EVENT",
+	RECV_ID_SUBSCRIBE_INPUT_EVENT:            "RECV_ID_SUBSCRIBE_INPUT_EVENT",
+	RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS:     "RECV_ID_ENUMERATE_INPUT_EVENT_PARAMS",
+}
+
+// Data data types
+// SIMCONNECT_DATATYPE
+const (
+	DATATYPE_INVALID      DWORD = 0  // invalid data type
+	DATATYPE_INT32        DWORD = 1  // 32-bit integer number
+	DATATYPE_INT64        DWORD = 2  // 64-bit integer number
+	DATATYPE_FLOAT32      DWORD = 3  // 32-bit floating-point number (float)
+	DATATYPE_FLOAT64      DWORD = 4  // 64-bit floating-point number (double)
+	DATATYPE_STRING8      DWORD = 5  // 8-byte string
+	DATATYPE_STRING32     DWORD = 6  // 32-byte string
+	DATATYPE_STRING64     DWORD = 7  // 64-byte string
+	DATATYPE_STRING128    DWORD = 8  // 128-byte string
+	DATATYPE_STRING256    DWORD = 9  // 256-byte string
+	DATATYPE_STRING260    DWORD = 10 // 260-byte string
+	DATATYPE_STRINGV      DWORD = 11 // variable-length string
+	DATATYPE_INITPOSITION DWORD = 12 // see SIMCONNECT_DATA_INITPOSITION
+	DATATYPE_MARKERSTATE  DWORD = 13 // see SIMCONNECT_DATA_MARKERSTATE
+	DATATYPE_WAYPOINT     DWORD = 14 // see SIMCONNECT_DATA_WAYPOINT
+	DATATYPE_LATLONALT    DWORD = 15 // see SIMCONNECT_DATA_LATLONALT
+	DATATYPE_XYZ          DWORD = 16 // see SIMCONNECT_DATA_XYZ
+	DATATYPE_MAX          DWORD = 17 // enum limit
+)
+
+// DataTypeNames maps SIMCONNECT_DATATYPE values to their names.
+var DataTypeNames = map[DWORD]string{
+	DATATYPE_INVALID:      "DATATYPE_INVALID",
+	DATATYPE_INT32:        "DATATYPE_INT32",
+	DATATYPE_INT64:        "DATATYPE_INT64",
+	DATATYPE_FLOAT32:      "DATATYPE_FLOAT32",
+	DATATYPE_FLOAT64:      "DATATYPE_FLOAT64",
+	DATATYPE_STRING8:      "DATATYPE_STRING8",
+	DATATYPE_STRING32:     "DATATYPE_STRING32",
+	DATATYPE_STRING64:     "DATATYPE_STRING64",
+	DATATYPE_STRING128:    "DATATYPE_STRING128",
+	DATATYPE_STRING256:    "DATATYPE_STRING256",
+	DATATYPE_STRING260:    "DATATYPE_STRING260",
+	DATATYPE_STRINGV:      "DATATYPE_STRINGV",
+	DATATYPE_INITPOSITION: "DATATYPE_INITPOSITION",
+	DATATYPE_MARKERSTATE:  "DATATYPE_MARKERSTATE",
+	DATATYPE_WAYPOINT:     "DATATYPE_WAYPOINT",
+	DATATYPE_LATLONALT:    "DATATYPE_LATLONALT",
+	DATATYPE_XYZ:          "DATATYPE_XYZ",
+	DATATYPE_MAX:          "DATATYPE_MAX",
+}
+
+// Exception error types
+// SIMCONNECT_EXCEPTION
+const (
+	EXCEPTION_NONE                              DWORD = 0
+	EXCEPTION_ERROR                             DWORD = 1
+	EXCEPTION_SIZE_MISMATCH                     DWORD = 2
+	EXCEPTION_UNRECOGNIZED_ID                   DWORD = 3
+	EXCEPTION_UNOPENED                          DWORD = 4
+	EXCEPTION_VERSION_MISMATCH                  DWORD = 5
+	EXCEPTION_TOO_MANY_GROUPS                   DWORD = 6
+	EXCEPTION_NAME_UNRECOGNIZED                 DWORD = 7
+	EXCEPTION_TOO_MANY_EVENT_NAMES              DWORD = 8
+	EXCEPTION_EVENT_ID_DUPLICATE                DWORD = 9
+	EXCEPTION_TOO_MANY_MAPS                     DWORD = 10
+	EXCEPTION_TOO_MANY_OBJECTS                  DWORD = 11
+	EXCEPTION_TOO_MANY_REQUESTS                 DWORD = 12
+	EXCEPTION_WEATHER_INVALID_PORT              DWORD = 13
+	EXCEPTION_WEATHER_INVALID_METAR             DWORD = 14
+	EXCEPTION_WEATHER_UNABLE_TO_GET_OBSERVATION DWORD = 15
+	EXCEPTION_WEATHER_UNABLE_TO_CREATE_STATION  DWORD = 16
+	EXCEPTION_WEATHER_UNABLE_TO_REMOVE_STATION  DWORD = 17
+	EXCEPTION_INVALID_DATA_TYPE                 DWORD = 18
+	EXCEPTION_INVALID_DATA_SIZE                 DWORD = 19
+	EXCEPTION_DATA_ERROR                        DWORD = 20
+	EXCEPTION_INVALID_ARRAY                     DWORD = 21
+	EXCEPTION_CREATE_OBJECT_FAILED              DWORD = 22
+	EXCEPTION_LOAD_FLIGHTPLAN_FAILED            DWORD = 23
+	EXCEPTION_OPERATION_INVALID_FOR_OBJECT_TYPE DWORD = 24
+	EXCEPTION_ILLEGAL_OPERATION                 DWORD = 25
+	EXCEPTION_ALREADY_SUBSCRIBED                DWORD = 26
+	EXCEPTION_INVALID_ENUM                      DWORD = 27
+	EXCEPTION_DEFINITION_ERROR                  DWORD = 28
+	EXCEPTION_DUPLICATE_ID                      DWORD = 29
+	EXCEPTION_DATUM_ID                          DWORD = 30
+	EXCEPTION_OUT_OF_BOUNDS                     DWORD = 31
+	EXCEPTION_ALREADY_CREATED                   DWORD = 32
+	EXCEPTION_OBJECT_OUTSIDE_REALITY_BUBBLE     DWORD = 33
+	EXCEPTION_OBJECT_CONTAINER                  DWORD = 34
+	EXCEPTION_OBJECT_AI                         DWORD = 35
+	EXCEPTION_OBJECT_ATC                        DWORD = 36
+	EXCEPTION_OBJECT_SCHEDULE                   DWORD = 37
+	EXCEPTION_JETWAY_DATA                       DWORD = 38
+	EXCEPTION_ACTION_NOT_FOUND                  DWORD = 39
+	EXCEPTION_NOT_AN_ACTION                     DWORD = 40
+	EXCEPTION_INCORRECT_ACTION_PARAMS           DWORD = 41
+	EXCEPTION_GET_INPUT_EVENT_FAILED            DWORD = 42
+	EXCEPTION_SET_INPUT_EVENT_FAILED            DWORD = 43
+)
+
+// ExceptionNames maps SIMCONNECT_EXCEPTION values to their names.
+var ExceptionNames = map[DWORD]string{
+	EXCEPTION_NONE:                              "EXCEPTION_NONE",
+	EXCEPTION_ERROR:                             "EXCEPTION_ERROR",
+	EXCEPTION_SIZE_MISMATCH:                     "EXCEPTION_SIZE_MISMATCH",
+	EXCEPTION_UNRECOGNIZED_ID:                   "EXCEPTION_UNRECOGNIZED_ID",
+	EXCEPTION_UNOPENED:                          "EXCEPTION_UNOPENED",
+	EXCEPTION_VERSION_MISMATCH:                  "EXCEPTION_VERSION_MISMATCH",
+	EXCEPTION_TOO_MANY_GROUPS:                   "EXCEPTION_TOO_MANY_GROUPS",
+	EXCEPTION_NAME_UNRECOGNIZED:                 "EXCEPTION_NAME_UNRECOGNIZED",
+	EXCEPTION_TOO_MANY_EVENT_NAMES:              "EXCEPTION_TOO_MANY_EVENT_NAMES",
+	EXCEPTION_EVENT_ID_DUPLICATE:                "EXCEPTION_EVENT_ID_DUPLICATE",
+	EXCEPTION_TOO_MANY_MAPS:                     "EXCEPTION_TOO_MANY_MAPS",
+	EXCEPTION_TOO_MANY_OBJECTS:                  "EXCEPTION_TOO_MANY_OBJECTS",
+	EXCEPTION_TOO_MANY_REQUESTS:                 "EXCEPTION_TOO_MANY_REQUESTS",
+	EXCEPTION_WEATHER_INVALID_PORT:              "EXCEPTION_WEATHER_INVALID_PORT",
+	EXCEPTION_WEATHER_INVALID_METAR:             "EXCEPTION_WEATHER_INVALID_METAR",
+	EXCEPTION_WEATHER_UNABLE_TO_GET_OBSERVATION: "EXCEPTION_WEATHER_UNABLE_TO_GET_OBSERVATION",
+	EXCEPTION_WEATHER_UNABLE_TO_CREATE_STATION:  "EXCEPTION_WEATHER_UNABLE_TO_CREATE_STATION",
+	EXCEPTION_WEATHER_UNABLE_TO_REMOVE_STATION:  "EXCEPTION_WEATHER_UNABLE_TO_REMOVE_STATION",
+	EXCEPTION_INVALID_DATA_TYPE:                 "EXCEPTION_INVALID_DATA_TYPE",
+	EXCEPTION_INVALID_DATA_SIZE:                 "EXCEPTION_INVALID_DATA_SIZE",
+	EXCEPTION_DATA_ERROR:                        "EXCEPTION_DATA_ERROR",
+	EXCEPTION_INVALID_ARRAY:                     "EXCEPTION_INVALID_ARRAY",
+	EXCEPTION_CREATE_OBJECT_FAILED:              "EXCEPTION_CREATE_OBJECT_FAILED",
+	EXCEPTION_LOAD_FLIGHTPLAN_FAILED:            "EXCEPTION_LOAD_FLIGHTPLAN_FAILED",
+	EXCEPTION_OPERATION_INVALID_FOR_OBJECT_TYPE: "EXCEPTION_OPERATION_INVALID_FOR_OBJECT_TYPE",
+	EXCEPTION_ILLEGAL_OPERATION:                 "EXCEPTION_ILLEGAL_OPERATION",
+	EXCEPTION_ALREADY_SUBSCRIBED:                "EXCEPTION_ALREADY_SUBSCRIBED",
+	EXCEPTION_INVALID_ENUM:                      "EXCEPTION_INVALID_ENUM",
+	EXCEPTION_DEFINITION_ERROR:                  "EXCEPTION_DEFINITION_ERROR",
+	EXCEPTION_DUPLICATE_ID:                      "EXCEPTION_DUPLICATE_ID",
+	EXCEPTION_DATUM_ID:                          "EXCEPTION_DATUM_ID",
+	EXCEPTION_OUT_OF_BOUNDS:                     "EXCEPTION_OUT_OF_BOUNDS",
+	EXCEPTION_ALREADY_CREATED:                   "EXCEPTION_ALREADY_CREATED",
+	EXCEPTION_OBJECT_OUTSIDE_REALITY_BUBBLE:     "EXCEPTION_OBJECT_OUTSIDE_REALITY_BUBBLE",
+	EXCEPTION_OBJECT_CONTAINER:                  "EXCEPTION_OBJECT_CONTAINER",
+	EXCEPTION_OBJECT_AI:                         "EXCEPTION_OBJECT_AI",
+	EXCEPTION_OBJECT_ATC:                        "EXCEPTION_OBJECT_ATC",
+	EXCEPTION_OBJECT_SCHEDULE:                   "EXCEPTION_OBJECT_SCHEDULE",
+	EXCEPTION_JETWAY_DATA:                       "EXCEPTION_JETWAY_DATA",
+	EXCEPTION_ACTION_NOT_FOUND:                  "EXCEPTION_ACTION_NOT_FOUND",
+	EXCEPTION_NOT_AN_ACTION:                     "EXCEPTION_NOT_AN_ACTION",
+	EXCEPTION_INCORRECT_ACTION_PARAMS:           "EXCEPTION_INCORRECT_ACTION_PARAMS",
+	EXCEPTION_GET_INPUT_EVENT_FAILED:            "EXCEPTION_GET_INPUT_EVENT_FAILED",
+	EXCEPTION_SET_INPUT_EVENT_FAILED:            "EXCEPTION_SET_INPUT_EVENT_FAILED",
+}
+
+// Object types
+// SIMCONNECT_SIMOBJECT_TYPE
+const (
+	SIMOBJECT_TYPE_USER       DWORD = 0
+	SIMOBJECT_TYPE_ALL        DWORD = 1
+	SIMOBJECT_TYPE_AIRCRAFT   DWORD = 2
+	SIMOBJECT_TYPE_HELICOPTER DWORD = 3
+	SIMOBJECT_TYPE_BOAT       DWORD = 4
+	SIMOBJECT_TYPE_GROUND     DWORD = 5
+)
+
+// SimobjectTypeNames maps SIMCONNECT_SIMOBJECT_TYPE values to their names.
+var SimobjectTypeNames = map[DWORD]string{
+	SIMOBJECT_TYPE_USER:       "SIMOBJECT_TYPE_USER",
+	SIMOBJECT_TYPE_ALL:        "SIMOBJECT_TYPE_ALL",
+	SIMOBJECT_TYPE_AIRCRAFT:   "SIMOBJECT_TYPE_AIRCRAFT",
+	SIMOBJECT_TYPE_HELICOPTER: "SIMOBJECT_TYPE_HELICOPTER",
+	SIMOBJECT_TYPE_BOAT:       "SIMOBJECT_TYPE_BOAT",
+	SIMOBJECT_TYPE_GROUND:     "SIMOBJECT_TYPE_GROUND",
+}
+
+// EventState values
+// SIMCONNECT_STATE
+const (
+	STATE_OFF DWORD = 0
+	STATE_ON  DWORD = 1
+)
+
+// StateNames maps SIMCONNECT_STATE values to their names.
+var StateNames = map[DWORD]string{
+	STATE_OFF: "STATE_OFF",
+	STATE_ON:  "STATE_ON",
+}
+
+// Object Data Request Period values
+// SIMCONNECT_PERIOD
+const (
+	PERIOD_NEVER        DWORD = 0
+	PERIOD_ONCE         DWORD = 1
+	PERIOD_VISUAL_FRAME DWORD = 2
+	PERIOD_SIM_FRAME    DWORD = 3
+	PERIOD_SECOND       DWORD = 4
+)
+
+// PeriodNames maps SIMCONNECT_PERIOD values to their names.
+var PeriodNames = map[DWORD]string{
+	PERIOD_NEVER:        "PERIOD_NEVER",
+	PERIOD_ONCE:         "PERIOD_ONCE",
+	PERIOD_VISUAL_FRAME: "PERIOD_VISUAL_FRAME",
+	PERIOD_SIM_FRAME:    "PERIOD_SIM_FRAME",
+	PERIOD_SECOND:       "PERIOD_SECOND",
+}
+
+// SIMCONNECT_MISSION_END
+const (
+	MISSION_FAILED    DWORD = 0
+	MISSION_CRASHED   DWORD = 1
+	MISSION_SUCCEEDED DWORD = 2
+)
+
+// MissionEndNames maps SIMCONNECT_MISSION_END values to their names.
+var MissionEndNames = map[DWORD]string{
+	MISSION_FAILED:    "MISSION_FAILED",
+	MISSION_CRASHED:   "MISSION_CRASHED",
+	MISSION_SUCCEEDED: "MISSION_SUCCEEDED",
+}
+
+// ClientData Request Period values
+// SIMCONNECT_CLIENT_DATA_PERIOD
+const (
+	CLIENT_DATA_PERIOD_NEVER        DWORD = 0
+	CLIENT_DATA_PERIOD_ONCE         DWORD = 1
+	CLIENT_DATA_PERIOD_VISUAL_FRAME DWORD = 2
+	CLIENT_DATA_PERIOD_ON_SET       DWORD = 3
+	CLIENT_DATA_PERIOD_SECOND       DWORD = 4
+)
+
+// ClientDataPeriodNames maps SIMCONNECT_CLIENT_DATA_PERIOD values to their names.
+var ClientDataPeriodNames = map[DWORD]string{
+	CLIENT_DATA_PERIOD_NEVER:        "CLIENT_DATA_PERIOD_NEVER",
+	CLIENT_DATA_PERIOD_ONCE:         "CLIENT_DATA_PERIOD_ONCE",
+	CLIENT_DATA_PERIOD_VISUAL_FRAME: "CLIENT_DATA_PERIOD_VISUAL_FRAME",
+	CLIENT_DATA_PERIOD_ON_SET:       "CLIENT_DATA_PERIOD_ON_SET",
+	CLIENT_DATA_PERIOD_SECOND:       "CLIENT_DATA_PERIOD_SECOND",
+}
+
+// SIMCONNECT_TEXT_TYPE
+const (
+	TEXT_TYPE_SCROLL_BLACK   DWORD = 0
+	TEXT_TYPE_SCROLL_WHITE   DWORD = 1
+	TEXT_TYPE_SCROLL_RED     DWORD = 2
+	TEXT_TYPE_SCROLL_GREEN   DWORD = 3
+	TEXT_TYPE_SCROLL_BLUE    DWORD = 4
+	TEXT_TYPE_SCROLL_YELLOW  DWORD = 5
+	TEXT_TYPE_SCROLL_MAGENTA DWORD = 6
+	TEXT_TYPE_SCROLL_CYAN    DWORD = 7
+	TEXT_TYPE_PRINT_BLACK    DWORD = 0x0100
+	TEXT_TYPE_PRINT_WHITE    DWORD = 257
+	TEXT_TYPE_PRINT_RED      DWORD = 258
+	TEXT_TYPE_PRINT_GREEN    DWORD = 259
+	TEXT_TYPE_PRINT_BLUE     DWORD = 260
+	TEXT_TYPE_PRINT_YELLOW   DWORD = 261
+	TEXT_TYPE_PRINT_MAGENTA  DWORD = 262
+	TEXT_TYPE_PRINT_CYAN     DWORD = 263
+	TEXT_TYPE_MENU           DWORD = 0x0200
+)
+
+// TextTypeNames maps SIMCONNECT_TEXT_TYPE values to their names.
+var TextTypeNames = map[DWORD]string{
+	TEXT_TYPE_SCROLL_BLACK:   "TEXT_TYPE_SCROLL_BLACK",
+	TEXT_TYPE_SCROLL_WHITE:   "TEXT_TYPE_SCROLL_WHITE",
+	TEXT_TYPE_SCROLL_RED:     "TEXT_TYPE_SCROLL_RED",
+	TEXT_TYPE_SCROLL_GREEN:   "TEXT_TYPE_SCROLL_GREEN",
+	TEXT_TYPE_SCROLL_BLUE:    "TEXT_TYPE_SCROLL_BLUE",
+	TEXT_TYPE_SCROLL_YELLOW:  "TEXT_TYPE_SCROLL_YELLOW",
+	TEXT_TYPE_SCROLL_MAGENTA: "TEXT_TYPE_SCROLL_MAGENTA",
+	TEXT_TYPE_SCROLL_CYAN:    "TEXT_TYPE_SCROLL_CYAN",
+	TEXT_TYPE_PRINT_BLACK:    "TEXT_TYPE_PRINT_BLACK",
+	TEXT_TYPE_PRINT_WHITE:    "TEXT_TYPE_PRINT_WHITE",
+	TEXT_TYPE_PRINT_RED:      "TEXT_TYPE_PRINT_RED",
+	TEXT_TYPE_PRINT_GREEN:    "TEXT_TYPE_PRINT_GREEN",
+	TEXT_TYPE_PRINT_BLUE:     "TEXT_TYPE_PRINT_BLUE",
+	TEXT_TYPE_PRINT_YELLOW:   "TEXT_TYPE_PRINT_YELLOW",
+	TEXT_TYPE_PRINT_MAGENTA:  "TEXT_TYPE_PRINT_MAGENTA",
+	TEXT_TYPE_PRINT_CYAN:     "TEXT_TYPE_PRINT_CYAN",
+	TEXT_TYPE_MENU:           "TEXT_TYPE_MENU",
+}
+
+// SIMCONNECT_TEXT_RESULT
+const (
+	TEXT_RESULT_MENU_SELECT_1  DWORD = 0
+	TEXT_RESULT_MENU_SELECT_2  DWORD = 1
+	TEXT_RESULT_MENU_SELECT_3  DWORD = 2
+	TEXT_RESULT_MENU_SELECT_4  DWORD = 3
+	TEXT_RESULT_MENU_SELECT_5  DWORD = 4
+	TEXT_RESULT_MENU_SELECT_6  DWORD = 5
+	TEXT_RESULT_MENU_SELECT_7  DWORD = 6
+	TEXT_RESULT_MENU_SELECT_8  DWORD = 7
+	TEXT_RESULT_MENU_SELECT_9  DWORD = 8
+	TEXT_RESULT_MENU_SELECT_10 DWORD = 9
+	TEXT_RESULT_DISPLAYED      DWORD = 0x10000
+	TEXT_RESULT_QUEUED         DWORD = 65537
+	TEXT_RESULT_REMOVED        DWORD = 65538
+	TEXT_RESULT_REPLACED       DWORD = 65539
+	TEXT_RESULT_TIMEOUT        DWORD = 65540
+)
+
+// TextResultNames maps SIMCONNECT_TEXT_RESULT values to their names.
+var TextResultNames = map[DWORD]string{
+	TEXT_RESULT_MENU_SELECT_1:  "TEXT_RESULT_MENU_SELECT_1",
+	TEXT_RESULT_MENU_SELECT_2:  "TEXT_RESULT_MENU_SELECT_2",
+	TEXT_RESULT_MENU_SELECT_3:  "TEXT_RESULT_MENU_SELECT_3",
+	TEXT_RESULT_MENU_SELECT_4:  "TEXT_RESULT_MENU_SELECT_4",
+	TEXT_RESULT_MENU_SELECT_5:  "TEXT_RESULT_MENU_SELECT_5",
+	TEXT_RESULT_MENU_SELECT_6:  "TEXT_RESULT_MENU_SELECT_6",
+	TEXT_RESULT_MENU_SELECT_7:  "TEXT_RESULT_MENU_SELECT_7",
+	TEXT_RESULT_MENU_SELECT_8:  "TEXT_RESULT_MENU_SELECT_8",
+	TEXT_RESULT_MENU_SELECT_9:  "TEXT_RESULT_MENU_SELECT_9",
+	TEXT_RESULT_MENU_SELECT_10: "TEXT_RESULT_MENU_SELECT_10",
+	TEXT_RESULT_DISPLAYED:      "TEXT_RESULT_DISPLAYED",
+	TEXT_RESULT_QUEUED:         "TEXT_RESULT_QUEUED",
+	TEXT_RESULT_REMOVED:        "TEXT_RESULT_REMOVED",
+	TEXT_RESULT_REPLACED:       "TEXT_RESULT_REPLACED",
+	TEXT_RESULT_TIMEOUT:        "TEXT_RESULT_TIMEOUT",
+}
+
+// SIMCONNECT_WEATHER_MODE
+const (
+	WEATHER_MODE_THEME  DWORD = 0
+	WEATHER_MODE_RWW    DWORD = 1
+	WEATHER_MODE_CUSTOM DWORD = 2
+	WEATHER_MODE_GLOBAL DWORD = 3
+)
+
+// WeatherModeNames maps SIMCONNECT_WEATHER_MODE values to their names.
+var WeatherModeNames = map[DWORD]string{
+	WEATHER_MODE_THEME:  "WEATHER_MODE_THEME",
+	WEATHER_MODE_RWW:    "WEATHER_MODE_RWW",
+	WEATHER_MODE_CUSTOM: "WEATHER_MODE_CUSTOM",
+	WEATHER_MODE_GLOBAL: "WEATHER_MODE_GLOBAL",
+}
+
+// SIMCONNECT_FACILITY_LIST_TYPE
+const (
+	FACILITY_LIST_TYPE_AIRPORT  DWORD = 0
+	FACILITY_LIST_TYPE_WAYPOINT DWORD = 1
+	FACILITY_LIST_TYPE_NDB      DWORD = 2
+	FACILITY_LIST_TYPE_VOR      DWORD = 3
+	FACILITY_LIST_TYPE_COUNT    DWORD = 4 // invalid
+)
+
+// FacilityListTypeNames maps SIMCONNECT_FACILITY_LIST_TYPE values to their names.
+var FacilityListTypeNames = map[DWORD]string{
+	FACILITY_LIST_TYPE_AIRPORT:  "FACILITY_LIST_TYPE_AIRPORT",
+	FACILITY_LIST_TYPE_WAYPOINT: "FACILITY_LIST_TYPE_WAYPOINT",
+	FACILITY_LIST_TYPE_NDB:      "FACILITY_LIST_TYPE_NDB",
+	FACILITY_LIST_TYPE_VOR:      "FACILITY_LIST_TYPE_VOR",
+	FACILITY_LIST_TYPE_COUNT:    "FACILITY_LIST_TYPE_COUNT",
+}
+
+// SIMCONNECT_FACILITY_DATA_TYPE
+const (
+	FACILITY_DATA_AIRPORT             DWORD = 0
+	FACILITY_DATA_RUNWAY              DWORD = 1
+	FACILITY_DATA_START               DWORD = 2
+	FACILITY_DATA_FREQUENCY           DWORD = 3
+	FACILITY_DATA_HELIPAD             DWORD = 4
+	FACILITY_DATA_APPROACH            DWORD = 5
+	FACILITY_DATA_APPROACH_TRANSITION DWORD = 6
+	FACILITY_DATA_APPROACH_LEG        DWORD = 7
+	FACILITY_DATA_FINAL_APPROACH_LEG  DWORD = 8
+	FACILITY_DATA_MISSED_APPROACH_LEG DWORD = 9
+	FACILITY_DATA_DEPARTURE           DWORD = 10
+	FACILITY_DATA_ARRIVAL             DWORD = 11
+	FACILITY_DATA_RUNWAY_TRANSITION   DWORD = 12
+	FACILITY_DATA_ENROUTE_TRANSITION  DWORD = 13
+	FACILITY_DATA_TAXI_POINT          DWORD = 14
+	FACILITY_DATA_TAXI_PARKING        DWORD = 15
+	FACILITY_DATA_TAXI_PATH           DWORD = 16
+	FACILITY_DATA_TAXI_NAME           DWORD = 17
+	FACILITY_DATA_JETWAY              DWORD = 18
+	FACILITY_DATA_VOR                 DWORD = 19
+	FACILITY_DATA_NDB                 DWORD = 20
+	FACILITY_DATA_WAYPOINT            DWORD = 21
+	FACILITY_DATA_ROUTE               DWORD = 22
+	FACILITY_DATA_PAVEMENT            DWORD = 23
+	FACILITY_DATA_APPROACH_LIGHTS     DWORD = 24
+	FACILITY_DATA_VASI                DWORD = 25
+)
+
+// FacilityDataTypeNames maps SIMCONNECT_FACILITY_DATA_TYPE values to their names.
+var FacilityDataTypeNames = map[DWORD]string{
+	FACILITY_DATA_AIRPORT:             "FACILITY_DATA_AIRPORT",
+	FACILITY_DATA_RUNWAY:              "FACILITY_DATA_RUNWAY",
+	FACILITY_DATA_START:               "FACILITY_DATA_START",
+	FACILITY_DATA_FREQUENCY:           "FACILITY_DATA_FREQUENCY",
+	FACILITY_DATA_HELIPAD:             "FACILITY_DATA_HELIPAD",
+	FACILITY_DATA_APPROACH:            "FACILITY_DATA_APPROACH",
+	FACILITY_DATA_APPROACH_TRANSITION: "FACILITY_DATA_APPROACH_TRANSITION",
+	FACILITY_DATA_APPROACH_LEG:        "FACILITY_DATA_APPROACH_LEG",
+	FACILITY_DATA_FINAL_APPROACH_LEG:  "FACILITY_DATA_FINAL_APPROACH_LEG",
+	FACILITY_DATA_MISSED_APPROACH_LEG: "FACILITY_DATA_MISSED_APPROACH_LEG",
+	FACILITY_DATA_DEPARTURE:           "FACILITY_DATA_DEPARTURE",
+	FACILITY_DATA_ARRIVAL:             "FACILITY_DATA_ARRIVAL",
+	FACILITY_DATA_RUNWAY_TRANSITION:   "FACILITY_DATA_RUNWAY_TRANSITION",
+	FACILITY_DATA_ENROUTE_TRANSITION:  "FACILITY_DATA_ENROUTE_TRANSITION",
+	FACILITY_DATA_TAXI_POINT:          "FACILITY_DATA_TAXI_POINT",
+	FACILITY_DATA_TAXI_PARKING:        "FACILITY_DATA_TAXI_PARKING",
+	FACILITY_DATA_TAXI_PATH:           "FACILITY_DATA_TAXI_PATH",
+	FACILITY_DATA_TAXI_NAME:           "FACILITY_DATA_TAXI_NAME",
+	FACILITY_DATA_JETWAY:              "FACILITY_DATA_JETWAY",
+	FACILITY_DATA_VOR:                 "FACILITY_DATA_VOR",
+	FACILITY_DATA_NDB:                 "FACILITY_DATA_NDB",
+	FACILITY_DATA_WAYPOINT:            "FACILITY_DATA_WAYPOINT",
+	FACILITY_DATA_ROUTE:               "FACILITY_DATA_ROUTE",
+	FACILITY_DATA_PAVEMENT:            "FACILITY_DATA_PAVEMENT",
+	FACILITY_DATA_APPROACH_LIGHTS:     "FACILITY_DATA_APPROACH_LIGHTS",
+	FACILITY_DATA_VASI:                "FACILITY_DATA_VASI",
+}
+
+// Recv is SIMCONNECT_RECV.
+type Recv struct {
+	Size    DWORD // record size
+	Version DWORD // interface version
+	ID      DWORD // see SIMCONNECT_RECV_ID
+}
+
+// SizeofRecv is the packed size of SIMCONNECT_RECV without any variable-length data.
+const SizeofRecv = 12
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV.
+func (r *Recv) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecv {
+		return shortBuffer("Recv", SizeofRecv, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV.
+func (r *Recv) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecv)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *Recv) decode(b []byte) {
+	r.Size = DWORD(binary.LittleEndian.Uint32(b[0:]))
+	r.Version = DWORD(binary.LittleEndian.Uint32(b[4:]))
+	r.ID = DWORD(binary.LittleEndian.Uint32(b[8:]))
+}
+
+func (r *Recv) encode(b []byte) {
+	binary.LittleEndian.PutUint32(b[0:], uint32(r.Size))
+	binary.LittleEndian.PutUint32(b[4:], uint32(r.Version))
+	binary.LittleEndian.PutUint32(b[8:], uint32(r.ID))
+}
+
+const (
+	RECV_EXCEPTION_UNKNOWN_SENDID DWORD = 0
+	RECV_EXCEPTION_UNKNOWN_INDEX  DWORD = 0xFFFFFFFF
+)
+
+// RecvException is SIMCONNECT_RECV_EXCEPTION.
+// when dwID == SIMCONNECT_RECV_ID_EXCEPTION
+type RecvException struct {
+	Recv
+	Exception DWORD // see SIMCONNECT_EXCEPTION
+	SendID    DWORD // see SimConnect_GetLastSentPacketID
+	Index     DWORD // index of parameter that was source of error
+}
+
+// SizeofRecvException is the packed size of SIMCONNECT_RECV_EXCEPTION without any variable-length data.
+const SizeofRecvException = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EXCEPTION.
+func (r *RecvException) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvException {
+		return shortBuffer("RecvException", SizeofRecvException, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EXCEPTION.
+func (r *RecvException) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvException)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvException) decode(b []byte) {
+	r.Recv.decode(b)
+	r.Exception = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.SendID = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.Index = DWORD(binary.LittleEndian.Uint32(b[20:]))
+}
+
+func (r *RecvException) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.Exception))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.SendID))
+	binary.LittleEndian.PutUint32(b[20:], uint32(r.Index))
+}
+
+// RecvOpen is SIMCONNECT_RECV_OPEN.
+// when dwID == SIMCONNECT_RECV_ID_OPEN
+type RecvOpen struct {
+	Recv
+	ApplicationName         [256]byte
+	ApplicationVersionMajor DWORD
+	ApplicationVersionMinor DWORD
+	ApplicationBuildMajor   DWORD
+	ApplicationBuildMinor   DWORD
+	SimConnectVersionMajor  DWORD
+	SimConnectVersionMinor  DWORD
+	SimConnectBuildMajor    DWORD
+	SimConnectBuildMinor    DWORD
+	Reserved1               DWORD
+	Reserved2               DWORD
+}
+
+// SizeofRecvOpen is the packed size of SIMCONNECT_RECV_OPEN without any variable-length data.
+const SizeofRecvOpen = 308
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_OPEN.
+func (r *RecvOpen) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvOpen {
+		return shortBuffer("RecvOpen", SizeofRecvOpen, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_OPEN.
+func (r *RecvOpen) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvOpen)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvOpen) decode(b []byte) {
+	r.Recv.decode(b)
+	copy(r.ApplicationName[:], b[12:268])
+	r.ApplicationVersionMajor = DWORD(binary.LittleEndian.Uint32(b[268:]))
+	r.ApplicationVersionMinor = DWORD(binary.LittleEndian.Uint32(b[272:]))
+	r.ApplicationBuildMajor = DWORD(binary.LittleEndian.Uint32(b[276:]))
+	r.ApplicationBuildMinor = DWORD(binary.LittleEndian.Uint32(b[280:]))
+	r.SimConnectVersionMajor = DWORD(binary.LittleEndian.Uint32(b[284:]))
+	r.SimConnectVersionMinor = DWORD(binary.LittleEndian.Uint32(b[288:]))
+	r.SimConnectBuildMajor = DWORD(binary.LittleEndian.Uint32(b[292:]))
+	r.SimConnectBuildMinor = DWORD(binary.LittleEndian.Uint32(b[296:]))
+	r.Reserved1 = DWORD(binary.LittleEndian.Uint32(b[300:]))
+	r.Reserved2 = DWORD(binary.LittleEndian.Uint32(b[304:]))
+}
+
+func (r *RecvOpen) encode(b []byte) {
+	r.Recv.encode(b)
+	copy(b[12:268], r.ApplicationName[:])
+	binary.LittleEndian.PutUint32(b[268:], uint32(r.ApplicationVersionMajor))
+	binary.LittleEndian.PutUint32(b[272:], uint32(r.ApplicationVersionMinor))
+	binary.LittleEndian.PutUint32(b[276:], uint32(r.ApplicationBuildMajor))
+	binary.LittleEndian.PutUint32(b[280:], uint32(r.ApplicationBuildMinor))
+	binary.LittleEndian.PutUint32(b[284:], uint32(r.SimConnectVersionMajor))
+	binary.LittleEndian.PutUint32(b[288:], uint32(r.SimConnectVersionMinor))
+	binary.LittleEndian.PutUint32(b[292:], uint32(r.SimConnectBuildMajor))
+	binary.LittleEndian.PutUint32(b[296:], uint32(r.SimConnectBuildMinor))
+	binary.LittleEndian.PutUint32(b[300:], uint32(r.Reserved1))
+	binary.LittleEndian.PutUint32(b[304:], uint32(r.Reserved2))
+}
+
+// RecvQuit is SIMCONNECT_RECV_QUIT.
+// when dwID == SIMCONNECT_RECV_ID_QUIT
+type RecvQuit struct {
+	Recv
+}
+
+// SizeofRecvQuit is the packed size of SIMCONNECT_RECV_QUIT without any variable-length data.
+const SizeofRecvQuit = 12
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_QUIT.
+func (r *RecvQuit) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvQuit {
+		return shortBuffer("RecvQuit", SizeofRecvQuit, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_QUIT.
+func (r *RecvQuit) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvQuit)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvQuit) decode(b []byte) {
+	r.Recv.decode(b)
+}
+
+func (r *RecvQuit) encode(b []byte) {
+	r.Recv.encode(b)
+}
+
+const (
+	RECV_EVENT_UNKNOWN_GROUP DWORD = 0xFFFFFFFF
+)
+
+// RecvEvent is SIMCONNECT_RECV_EVENT.
+// when dwID == SIMCONNECT_RECV_ID_EVENT
+type RecvEvent struct {
+	Recv
+	GroupID DWORD
+	EventID DWORD
+	Data    DWORD // uEventID-dependent context
+}
+
+// SizeofRecvEvent is the packed size of SIMCONNECT_RECV_EVENT without any variable-length data.
+const SizeofRecvEvent = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT.
+func (r *RecvEvent) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEvent {
+		return shortBuffer("RecvEvent", SizeofRecvEvent, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT.
+func (r *RecvEvent) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEvent)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEvent) decode(b []byte) {
+	r.Recv.decode(b)
+	r.GroupID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.EventID = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.Data = DWORD(binary.LittleEndian.Uint32(b[20:]))
+}
+
+func (r *RecvEvent) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.GroupID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.EventID))
+	binary.LittleEndian.PutUint32(b[20:], uint32(r.Data))
+}
+
+// RecvEventFilename is SIMCONNECT_RECV_EVENT_FILENAME.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_FILENAME
+type RecvEventFilename struct {
+	RecvEvent
+	FileName [260]byte // uEventID-dependent context
+	Flags    DWORD
+}
+
+// SizeofRecvEventFilename is the packed size of SIMCONNECT_RECV_EVENT_FILENAME without any variable-length data.
+const SizeofRecvEventFilename = 288
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_FILENAME.
+func (r *RecvEventFilename) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventFilename {
+		return shortBuffer("RecvEventFilename", SizeofRecvEventFilename, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_FILENAME.
+func (r *RecvEventFilename) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventFilename)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventFilename) decode(b []byte) {
+	r.RecvEvent.decode(b)
+	copy(r.FileName[:], b[24:284])
+	r.Flags = DWORD(binary.LittleEndian.Uint32(b[284:]))
+}
+
+func (r *RecvEventFilename) encode(b []byte) {
+	r.RecvEvent.encode(b)
+	copy(b[24:284], r.FileName[:])
+	binary.LittleEndian.PutUint32(b[284:], uint32(r.Flags))
+}
+
+// RecvEventObjectAddRemove is SIMCONNECT_RECV_EVENT_OBJECT_ADDREMOVE.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_FILENAME
+type RecvEventObjectAddRemove struct {
+	RecvEvent
+	ObjType DWORD
+}
+
+// SizeofRecvEventObjectAddRemove is the packed size of SIMCONNECT_RECV_EVENT_OBJECT_ADDREMOVE without any variable-length data.
+const SizeofRecvEventObjectAddRemove = 28
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_OBJECT_ADDREMOVE.
+func (r *RecvEventObjectAddRemove) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventObjectAddRemove {
+		return shortBuffer("RecvEventObjectAddRemove", SizeofRecvEventObjectAddRemove, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_OBJECT_ADDREMOVE.
+func (r *RecvEventObjectAddRemove) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventObjectAddRemove)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventObjectAddRemove) decode(b []byte) {
+	r.RecvEvent.decode(b)
+	r.ObjType = DWORD(binary.LittleEndian.Uint32(b[24:]))
+}
+
+func (r *RecvEventObjectAddRemove) encode(b []byte) {
+	r.RecvEvent.encode(b)
+	binary.LittleEndian.PutUint32(b[24:], uint32(r.ObjType))
+}
+
+// RecvEventFrame is SIMCONNECT_RECV_EVENT_FRAME.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_FRAME
+type RecvEventFrame struct {
+	RecvEvent
+	FrameRate float32
+	SimSpeed  float32
+}
+
+// SizeofRecvEventFrame is the packed size of SIMCONNECT_RECV_EVENT_FRAME without any variable-length data.
+const SizeofRecvEventFrame = 32
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_FRAME.
+func (r *RecvEventFrame) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventFrame {
+		return shortBuffer("RecvEventFrame", SizeofRecvEventFrame, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_FRAME.
+func (r *RecvEventFrame) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventFrame)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventFrame) decode(b []byte) {
+	r.RecvEvent.decode(b)
+	r.FrameRate = math.Float32frombits(binary.LittleEndian.Uint32(b[24:]))
+	r.SimSpeed = math.Float32frombits(binary.LittleEndian.Uint32(b[28:]))
+}
+
+func (r *RecvEventFrame) encode(b []byte) {
+	r.RecvEvent.encode(b)
+	binary.LittleEndian.PutUint32(b[24:], math.Float32bits(r.FrameRate))
+	binary.LittleEndian.PutUint32(b[28:], math.Float32bits(r.SimSpeed))
+}
+
+// RecvEventMultiplayerServerStarted is SIMCONNECT_RECV_EVENT_MULTIPLAYER_SERVER_STARTED.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_MULTIPLAYER_SERVER_STARTED
+type RecvEventMultiplayerServerStarted struct {
+	RecvEvent
+}
+
+// SizeofRecvEventMultiplayerServerStarted is the packed size of SIMCONNECT_RECV_EVENT_MULTIPLAYER_SERVER_STARTED without any variable-length data.
+const SizeofRecvEventMultiplayerServerStarted = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_MULTIPLAYER_SERVER_STARTED.
+func (r *RecvEventMultiplayerServerStarted) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventMultiplayerServerStarted {
+		return shortBuffer("RecvEventMultiplayerServerStarted", SizeofRecvEventMultiplayerServerStarted, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_MULTIPLAYER_SERVER_STARTED.
+func (r *RecvEventMultiplayerServerStarted) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventMultiplayerServerStarted)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventMultiplayerServerStarted) decode(b []byte) {
+	r.RecvEvent.decode(b)
+}
+
+func (r *RecvEventMultiplayerServerStarted) encode(b []byte) {
+	r.RecvEvent.encode(b)
+}
+
+// RecvEventMultiplayerClientStarted is SIMCONNECT_RECV_EVENT_MULTIPLAYER_CLIENT_STARTED.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_MULTIPLAYER_CLIENT_STARTED
+type RecvEventMultiplayerClientStarted struct {
+	RecvEvent
+}
+
+// SizeofRecvEventMultiplayerClientStarted is the packed size of SIMCONNECT_RECV_EVENT_MULTIPLAYER_CLIENT_STARTED without any variable-length data.
+const SizeofRecvEventMultiplayerClientStarted = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_MULTIPLAYER_CLIENT_STARTED.
+func (r *RecvEventMultiplayerClientStarted) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventMultiplayerClientStarted {
+		return shortBuffer("RecvEventMultiplayerClientStarted", SizeofRecvEventMultiplayerClientStarted, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_MULTIPLAYER_CLIENT_STARTED.
+func (r *RecvEventMultiplayerClientStarted) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventMultiplayerClientStarted)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventMultiplayerClientStarted) decode(b []byte) {
+	r.RecvEvent.decode(b)
+}
+
+func (r *RecvEventMultiplayerClientStarted) encode(b []byte) {
+	r.RecvEvent.encode(b)
+}
+
+// RecvEventMultiplayerSessionEnded is SIMCONNECT_RECV_EVENT_MULTIPLAYER_SESSION_ENDED.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_MULTIPLAYER_SESSION_ENDED
+type RecvEventMultiplayerSessionEnded struct {
+	RecvEvent
+}
+
+// SizeofRecvEventMultiplayerSessionEnded is the packed size of SIMCONNECT_RECV_EVENT_MULTIPLAYER_SESSION_ENDED without any variable-length data.
+const SizeofRecvEventMultiplayerSessionEnded = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_MULTIPLAYER_SESSION_ENDED.
+func (r *RecvEventMultiplayerSessionEnded) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventMultiplayerSessionEnded {
+		return shortBuffer("RecvEventMultiplayerSessionEnded", SizeofRecvEventMultiplayerSessionEnded, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_MULTIPLAYER_SESSION_ENDED.
+func (r *RecvEventMultiplayerSessionEnded) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventMultiplayerSessionEnded)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventMultiplayerSessionEnded) decode(b []byte) {
+	r.RecvEvent.decode(b)
+}
+
+func (r *RecvEventMultiplayerSessionEnded) encode(b []byte) {
+	r.RecvEvent.encode(b)
+}
+
+// RecvSimobjectData is SIMCONNECT_RECV_SIMOBJECT_DATA.
+// when dwID == SIMCONNECT_RECV_ID_SIMOBJECT_DATA
+type RecvSimobjectData struct {
+	Recv
+	RequestID   DWORD
+	ObjectID    DWORD
+	DefineID    DWORD
+	Flags       DWORD // SIMCONNECT_DATA_REQUEST_FLAG
+	EntryNumber DWORD // if multiple objects returned, this is number <entrynumber> out of <outof>.
+	OutOf       DWORD // note: starts with 1, not 0.
+	DefineCount DWORD // data count (number of datums, *not* byte count)
+}
+
+// SizeofRecvSimobjectData is the packed size of SIMCONNECT_RECV_SIMOBJECT_DATA without any variable-length data.
+const SizeofRecvSimobjectData = 40
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_SIMOBJECT_DATA.
+func (r *RecvSimobjectData) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvSimobjectData {
+		return shortBuffer("RecvSimobjectData", SizeofRecvSimobjectData, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_SIMOBJECT_DATA.
+func (r *RecvSimobjectData) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvSimobjectData)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvSimobjectData) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.ObjectID = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.DefineID = DWORD(binary.LittleEndian.Uint32(b[20:]))
+	r.Flags = DWORD(binary.LittleEndian.Uint32(b[24:]))
+	r.EntryNumber = DWORD(binary.LittleEndian.Uint32(b[28:]))
+	r.OutOf = DWORD(binary.LittleEndian.Uint32(b[32:]))
+	r.DefineCount = DWORD(binary.LittleEndian.Uint32(b[36:]))
+}
+
+func (r *RecvSimobjectData) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.ObjectID))
+	binary.LittleEndian.PutUint32(b[20:], uint32(r.DefineID))
+	binary.LittleEndian.PutUint32(b[24:], uint32(r.Flags))
+	binary.LittleEndian.PutUint32(b[28:], uint32(r.EntryNumber))
+	binary.LittleEndian.PutUint32(b[32:], uint32(r.OutOf))
+	binary.LittleEndian.PutUint32(b[36:], uint32(r.DefineCount))
+}
+
+// RecvSimobjectDataByType is SIMCONNECT_RECV_SIMOBJECT_DATA_BYTYPE.
+// when dwID == SIMCONNECT_RECV_ID_SIMOBJECT_DATA_BYTYPE
+type RecvSimobjectDataByType struct {
+	RecvSimobjectData
+}
+
+// SizeofRecvSimobjectDataByType is the packed size of SIMCONNECT_RECV_SIMOBJECT_DATA_BYTYPE without any variable-length data.
+const SizeofRecvSimobjectDataByType = 40
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_SIMOBJECT_DATA_BYTYPE.
+func (r *RecvSimobjectDataByType) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvSimobjectDataByType {
+		return shortBuffer("RecvSimobjectDataByType", SizeofRecvSimobjectDataByType, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_SIMOBJECT_DATA_BYTYPE.
+func (r *RecvSimobjectDataByType) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvSimobjectDataByType)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvSimobjectDataByType) decode(b []byte) {
+	r.RecvSimobjectData.decode(b)
+}
+
+func (r *RecvSimobjectDataByType) encode(b []byte) {
+	r.RecvSimobjectData.encode(b)
+}
+
+// RecvClientData is SIMCONNECT_RECV_CLIENT_DATA.
+// when dwID == SIMCONNECT_RECV_ID_CLIENT_DATA
+type RecvClientData struct {
+	RecvSimobjectData
+}
+
+// SizeofRecvClientData is the packed size of SIMCONNECT_RECV_CLIENT_DATA without any variable-length data.
+const SizeofRecvClientData = 40
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_CLIENT_DATA.
+func (r *RecvClientData) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvClientData {
+		return shortBuffer("RecvClientData", SizeofRecvClientData, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_CLIENT_DATA.
+func (r *RecvClientData) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvClientData)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvClientData) decode(b []byte) {
+	r.RecvSimobjectData.decode(b)
+}
+
+func (r *RecvClientData) encode(b []byte) {
+	r.RecvSimobjectData.encode(b)
+}
+
+// RecvWeatherObservation is SIMCONNECT_RECV_WEATHER_OBSERVATION.
+// when dwID == SIMCONNECT_RECV_ID_WEATHER_OBSERVATION
+type RecvWeatherObservation struct {
+	Recv
+	RequestID DWORD
+}
+
+// SizeofRecvWeatherObservation is the packed size of SIMCONNECT_RECV_WEATHER_OBSERVATION without any variable-length data.
+const SizeofRecvWeatherObservation = 16
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_WEATHER_OBSERVATION.
+func (r *RecvWeatherObservation) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvWeatherObservation {
+		return shortBuffer("RecvWeatherObservation", SizeofRecvWeatherObservation, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_WEATHER_OBSERVATION.
+func (r *RecvWeatherObservation) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvWeatherObservation)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvWeatherObservation) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+}
+
+func (r *RecvWeatherObservation) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+}
+
+// RecvCloudState is SIMCONNECT_RECV_CLOUD_STATE.
+// when dwID == SIMCONNECT_RECV_ID_CLOUD_STATE
+type RecvCloudState struct {
+	Recv
+	RequestID DWORD
+	ArraySize DWORD
+	Data      []byte
+}
+
+// SizeofRecvCloudState is the packed size of SIMCONNECT_RECV_CLOUD_STATE without any variable-length data.
+const SizeofRecvCloudState = 20
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_CLOUD_STATE.
+func (r *RecvCloudState) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvCloudState {
+		return shortBuffer("RecvCloudState", SizeofRecvCloudState, len(b))
+	}
+	r.decode(b)
+	n := int(r.ArraySize)
+	if want := SizeofRecvCloudState + n*1; len(b) < want {
+		return shortBuffer("RecvCloudState", want, len(b))
+	}
+	r.Data = make([]byte, n)
+	copy(r.Data, b[SizeofRecvCloudState:])
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_CLOUD_STATE.
+func (r *RecvCloudState) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvCloudState+len(r.Data)*1)
+	r.encode(b)
+	copy(b[SizeofRecvCloudState:], r.Data)
+	return b, nil
+}
+
+func (r *RecvCloudState) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.ArraySize = DWORD(binary.LittleEndian.Uint32(b[16:]))
+}
+
+func (r *RecvCloudState) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.ArraySize))
+}
+
+// RecvAssignedObjectID is SIMCONNECT_RECV_ASSIGNED_OBJECT_ID.
+// when dwID == SIMCONNECT_RECV_ID_ASSIGNED_OBJECT_ID
+type RecvAssignedObjectID struct {
+	Recv
+	RequestID DWORD
+	ObjectID  DWORD
+}
+
+// SizeofRecvAssignedObjectID is the packed size of SIMCONNECT_RECV_ASSIGNED_OBJECT_ID without any variable-length data.
+const SizeofRecvAssignedObjectID = 20
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_ASSIGNED_OBJECT_ID.
+func (r *RecvAssignedObjectID) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvAssignedObjectID {
+		return shortBuffer("RecvAssignedObjectID", SizeofRecvAssignedObjectID, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_ASSIGNED_OBJECT_ID.
+func (r *RecvAssignedObjectID) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvAssignedObjectID)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvAssignedObjectID) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.ObjectID = DWORD(binary.LittleEndian.Uint32(b[16:]))
+}
+
+func (r *RecvAssignedObjectID) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.ObjectID))
+}
+
+// RecvReservedKey is SIMCONNECT_RECV_RESERVED_KEY.
+// when dwID == SIMCONNECT_RECV_ID_RESERVED_KEY
+type RecvReservedKey struct {
+	Recv
+	ChoiceReserved [30]byte
+	ReservedKey    [50]byte
+}
+
+// SizeofRecvReservedKey is the packed size of SIMCONNECT_RECV_RESERVED_KEY without any variable-length data.
+const SizeofRecvReservedKey = 92
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_RESERVED_KEY.
+func (r *RecvReservedKey) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvReservedKey {
+		return shortBuffer("RecvReservedKey", SizeofRecvReservedKey, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_RESERVED_KEY.
+func (r *RecvReservedKey) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvReservedKey)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvReservedKey) decode(b []byte) {
+	r.Recv.decode(b)
+	copy(r.ChoiceReserved[:], b[12:42])
+	copy(r.ReservedKey[:], b[42:92])
+}
+
+func (r *RecvReservedKey) encode(b []byte) {
+	r.Recv.encode(b)
+	copy(b[12:42], r.ChoiceReserved[:])
+	copy(b[42:92], r.ReservedKey[:])
+}
+
+// RecvSystemState is SIMCONNECT_RECV_SYSTEM_STATE.
+// when dwID == SIMCONNECT_RECV_ID_SYSTEM_STATE
+type RecvSystemState struct {
+	Recv
+	RequestID DWORD
+	Integer   DWORD
+	Float     float32
+	String    [260]byte
+}
+
+// SizeofRecvSystemState is the packed size of SIMCONNECT_RECV_SYSTEM_STATE without any variable-length data.
+const SizeofRecvSystemState = 284
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_SYSTEM_STATE.
+func (r *RecvSystemState) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvSystemState {
+		return shortBuffer("RecvSystemState", SizeofRecvSystemState, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_SYSTEM_STATE.
+func (r *RecvSystemState) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvSystemState)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvSystemState) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.Integer = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.Float = math.Float32frombits(binary.LittleEndian.Uint32(b[20:]))
+	copy(r.String[:], b[24:284])
+}
+
+func (r *RecvSystemState) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.Integer))
+	binary.LittleEndian.PutUint32(b[20:], math.Float32bits(r.Float))
+	copy(b[24:284], r.String[:])
+}
+
+// RecvEventWeatherMode is SIMCONNECT_RECV_EVENT_WEATHER_MODE.
+type RecvEventWeatherMode struct {
+	RecvEvent
+}
+
+// SizeofRecvEventWeatherMode is the packed size of SIMCONNECT_RECV_EVENT_WEATHER_MODE without any variable-length data.
+const SizeofRecvEventWeatherMode = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_WEATHER_MODE.
+func (r *RecvEventWeatherMode) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventWeatherMode {
+		return shortBuffer("RecvEventWeatherMode", SizeofRecvEventWeatherMode, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_WEATHER_MODE.
+func (r *RecvEventWeatherMode) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventWeatherMode)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventWeatherMode) decode(b []byte) {
+	r.RecvEvent.decode(b)
+}
+
+func (r *RecvEventWeatherMode) encode(b []byte) {
+	r.RecvEvent.encode(b)
+}
+
+// RecvFacilityList is SIMCONNECT_RECV_FACILITIES_LIST.
+type RecvFacilityList struct {
+	Recv
+	RequestID   DWORD
+	ArraySize   DWORD
+	EntryNumber DWORD // when the array of items is too big for one send, which send this is (0..dwOutOf-1)
+	OutOf       DWORD // total number of transmissions the list is chopped into
+}
+
+// SizeofRecvFacilityList is the packed size of SIMCONNECT_RECV_FACILITIES_LIST without any variable-length data.
+const SizeofRecvFacilityList = 28
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_FACILITIES_LIST.
+func (r *RecvFacilityList) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityList {
+		return shortBuffer("RecvFacilityList", SizeofRecvFacilityList, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_FACILITIES_LIST.
+func (r *RecvFacilityList) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityList)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvFacilityList) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.ArraySize = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.EntryNumber = DWORD(binary.LittleEndian.Uint32(b[20:]))
+	r.OutOf = DWORD(binary.LittleEndian.Uint32(b[24:]))
+}
+
+func (r *RecvFacilityList) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.ArraySize))
+	binary.LittleEndian.PutUint32(b[20:], uint32(r.EntryNumber))
+	binary.LittleEndian.PutUint32(b[24:], uint32(r.OutOf))
+}
+
+// DataFacilityAirport is SIMCONNECT_DATA_FACILITY_AIRPORT.
+type DataFacilityAirport struct {
+	Icao      [9]byte // ICAO of the object
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // meters
+}
+
+// SizeofDataFacilityAirport is the packed size of SIMCONNECT_DATA_FACILITY_AIRPORT without any variable-length data.
+const SizeofDataFacilityAirport = 33
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_FACILITY_AIRPORT.
+func (r *DataFacilityAirport) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataFacilityAirport {
+		return shortBuffer("DataFacilityAirport", SizeofDataFacilityAirport, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_FACILITY_AIRPORT.
+func (r *DataFacilityAirport) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataFacilityAirport)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataFacilityAirport) decode(b []byte) {
+	copy(r.Icao[:], b[0:9])
+	r.Latitude = math.Float64frombits(binary.LittleEndian.Uint64(b[9:]))
+	r.Longitude = math.Float64frombits(binary.LittleEndian.Uint64(b[17:]))
+	r.Altitude = math.Float64frombits(binary.LittleEndian.Uint64(b[25:]))
+}
+
+func (r *DataFacilityAirport) encode(b []byte) {
+	copy(b[0:9], r.Icao[:])
+	binary.LittleEndian.PutUint64(b[9:], math.Float64bits(r.Latitude))
+	binary.LittleEndian.PutUint64(b[17:], math.Float64bits(r.Longitude))
+	binary.LittleEndian.PutUint64(b[25:], math.Float64bits(r.Altitude))
+}
+
+// RecvFacilityAirportList is SIMCONNECT_RECV_AIRPORT_LIST.
+type RecvFacilityAirportList struct {
+	RecvFacilityList
+	Data []DataFacilityAirport
+}
+
+// SizeofRecvFacilityAirportList is the packed size of SIMCONNECT_RECV_AIRPORT_LIST without any variable-length data.
+const SizeofRecvFacilityAirportList = 28
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_AIRPORT_LIST.
+func (r *RecvFacilityAirportList) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityAirportList {
+		return shortBuffer("RecvFacilityAirportList", SizeofRecvFacilityAirportList, len(b))
+	}
+	r.decode(b)
+	n := int(r.ArraySize)
+	if want := SizeofRecvFacilityAirportList + n*SizeofDataFacilityAirport; len(b) < want {
+		return shortBuffer("RecvFacilityAirportList", want, len(b))
+	}
+	r.Data = make([]DataFacilityAirport, n)
+	for i := range r.Data {
+		off := SizeofRecvFacilityAirportList + i*SizeofDataFacilityAirport
+		r.Data[i].decode(b[off:])
+	}
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_AIRPORT_LIST.
+func (r *RecvFacilityAirportList) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityAirportList+len(r.Data)*SizeofDataFacilityAirport)
+	r.encode(b)
+	for i := range r.Data {
+		off := SizeofRecvFacilityAirportList + i*SizeofDataFacilityAirport
+		r.Data[i].encode(b[off:])
+	}
+	return b, nil
+}
+
+func (r *RecvFacilityAirportList) decode(b []byte) {
+	r.RecvFacilityList.decode(b)
+}
+
+func (r *RecvFacilityAirportList) encode(b []byte) {
+	r.RecvFacilityList.encode(b)
+}
+
+// DataFacilityWaypoint is SIMCONNECT_DATA_FACILITY_WAYPOINT.
+type DataFacilityWaypoint struct {
+	DataFacilityAirport
+	MagVar float32 // Magvar in degrees
+}
+
+// SizeofDataFacilityWaypoint is the packed size of SIMCONNECT_DATA_FACILITY_WAYPOINT without any variable-length data.
+const SizeofDataFacilityWaypoint = 37
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_FACILITY_WAYPOINT.
+func (r *DataFacilityWaypoint) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataFacilityWaypoint {
+		return shortBuffer("DataFacilityWaypoint", SizeofDataFacilityWaypoint, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_FACILITY_WAYPOINT.
+func (r *DataFacilityWaypoint) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataFacilityWaypoint)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataFacilityWaypoint) decode(b []byte) {
+	r.DataFacilityAirport.decode(b)
+	r.MagVar = math.Float32frombits(binary.LittleEndian.Uint32(b[33:]))
+}
+
+func (r *DataFacilityWaypoint) encode(b []byte) {
+	r.DataFacilityAirport.encode(b)
+	binary.LittleEndian.PutUint32(b[33:], math.Float32bits(r.MagVar))
+}
+
+// RecvFacilityWaypointList is SIMCONNECT_RECV_WAYPOINT_LIST.
+type RecvFacilityWaypointList struct {
+	RecvFacilityList
+	Data []DataFacilityWaypoint
+}
+
+// SizeofRecvFacilityWaypointList is the packed size of SIMCONNECT_RECV_WAYPOINT_LIST without any variable-length data.
+const SizeofRecvFacilityWaypointList = 28
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_WAYPOINT_LIST.
+func (r *RecvFacilityWaypointList) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityWaypointList {
+		return shortBuffer("RecvFacilityWaypointList", SizeofRecvFacilityWaypointList, len(b))
+	}
+	r.decode(b)
+	n := int(r.ArraySize)
+	if want := SizeofRecvFacilityWaypointList + n*SizeofDataFacilityWaypoint; len(b) < want {
+		return shortBuffer("RecvFacilityWaypointList", want, len(b))
+	}
+	r.Data = make([]DataFacilityWaypoint, n)
+	for i := range r.Data {
+		off := SizeofRecvFacilityWaypointList + i*SizeofDataFacilityWaypoint
+		r.Data[i].decode(b[off:])
+	}
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_WAYPOINT_LIST.
+func (r *RecvFacilityWaypointList) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityWaypointList+len(r.Data)*SizeofDataFacilityWaypoint)
+	r.encode(b)
+	for i := range r.Data {
+		off := SizeofRecvFacilityWaypointList + i*SizeofDataFacilityWaypoint
+		r.Data[i].encode(b[off:])
+	}
+	return b, nil
+}
+
+func (r *RecvFacilityWaypointList) decode(b []byte) {
+	r.RecvFacilityList.decode(b)
+}
+
+func (r *RecvFacilityWaypointList) encode(b []byte) {
+	r.RecvFacilityList.encode(b)
+}
+
+// DataFacilityNDB is SIMCONNECT_DATA_FACILITY_NDB.
+type DataFacilityNDB struct {
+	DataFacilityWaypoint
+	Frequency DWORD // frequency in Hz
+}
+
+// SizeofDataFacilityNDB is the packed size of SIMCONNECT_DATA_FACILITY_NDB without any variable-length data.
+const SizeofDataFacilityNDB = 41
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_FACILITY_NDB.
+func (r *DataFacilityNDB) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataFacilityNDB {
+		return shortBuffer("DataFacilityNDB", SizeofDataFacilityNDB, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_FACILITY_NDB.
+func (r *DataFacilityNDB) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataFacilityNDB)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataFacilityNDB) decode(b []byte) {
+	r.DataFacilityWaypoint.decode(b)
+	r.Frequency = DWORD(binary.LittleEndian.Uint32(b[37:]))
+}
+
+func (r *DataFacilityNDB) encode(b []byte) {
+	r.DataFacilityWaypoint.encode(b)
+	binary.LittleEndian.PutUint32(b[37:], uint32(r.Frequency))
+}
+
+// RecvFacilityNDBList is SIMCONNECT_RECV_NDB_LIST.
+type RecvFacilityNDBList struct {
+	RecvFacilityList
+	Data []DataFacilityNDB
+}
+
+// SizeofRecvFacilityNDBList is the packed size of SIMCONNECT_RECV_NDB_LIST without any variable-length data.
+const SizeofRecvFacilityNDBList = 28
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_NDB_LIST.
+func (r *RecvFacilityNDBList) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityNDBList {
+		return shortBuffer("RecvFacilityNDBList", SizeofRecvFacilityNDBList, len(b))
+	}
+	r.decode(b)
+	n := int(r.ArraySize)
+	if want := SizeofRecvFacilityNDBList + n*SizeofDataFacilityNDB; len(b) < want {
+		return shortBuffer("RecvFacilityNDBList", want, len(b))
+	}
+	r.Data = make([]DataFacilityNDB, n)
+	for i := range r.Data {
+		off := SizeofRecvFacilityNDBList + i*SizeofDataFacilityNDB
+		r.Data[i].decode(b[off:])
+	}
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_NDB_LIST.
+func (r *RecvFacilityNDBList) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityNDBList+len(r.Data)*SizeofDataFacilityNDB)
+	r.encode(b)
+	for i := range r.Data {
+		off := SizeofRecvFacilityNDBList + i*SizeofDataFacilityNDB
+		r.Data[i].encode(b[off:])
+	}
+	return b, nil
+}
+
+func (r *RecvFacilityNDBList) decode(b []byte) {
+	r.RecvFacilityList.decode(b)
+}
+
+func (r *RecvFacilityNDBList) encode(b []byte) {
+	r.RecvFacilityList.encode(b)
+}
+
+// DataFacilityVOR is SIMCONNECT_DATA_FACILITY_VOR.
+type DataFacilityVOR struct {
+	DataFacilityNDB
+	Flags           DWORD   // SIMCONNECT_VOR_FLAGS
+	Localizer       float32 // Localizer in degrees
+	GlideLat        float64 // Glide Slope Location (deg, deg, meters)
+	GlideLon        float64
+	GlideAlt        float64
+	GlideSlopeAngle float32 // Glide Slope in degrees
+}
+
+// SizeofDataFacilityVOR is the packed size of SIMCONNECT_DATA_FACILITY_VOR without any variable-length data.
+const SizeofDataFacilityVOR = 77
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_FACILITY_VOR.
+func (r *DataFacilityVOR) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataFacilityVOR {
+		return shortBuffer("DataFacilityVOR", SizeofDataFacilityVOR, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_FACILITY_VOR.
+func (r *DataFacilityVOR) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataFacilityVOR)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataFacilityVOR) decode(b []byte) {
+	r.DataFacilityNDB.decode(b)
+	r.Flags = DWORD(binary.LittleEndian.Uint32(b[41:]))
+	r.Localizer = math.Float32frombits(binary.LittleEndian.Uint32(b[45:]))
+	r.GlideLat = math.Float64frombits(binary.LittleEndian.Uint64(b[49:]))
+	r.GlideLon = math.Float64frombits(binary.LittleEndian.Uint64(b[57:]))
+	r.GlideAlt = math.Float64frombits(binary.LittleEndian.Uint64(b[65:]))
+	r.GlideSlopeAngle = math.Float32frombits(binary.LittleEndian.Uint32(b[73:]))
+}
+
+func (r *DataFacilityVOR) encode(b []byte) {
+	r.DataFacilityNDB.encode(b)
+	binary.LittleEndian.PutUint32(b[41:], uint32(r.Flags))
+	binary.LittleEndian.PutUint32(b[45:], math.Float32bits(r.Localizer))
+	binary.LittleEndian.PutUint64(b[49:], math.Float64bits(r.GlideLat))
+	binary.LittleEndian.PutUint64(b[57:], math.Float64bits(r.GlideLon))
+	binary.LittleEndian.PutUint64(b[65:], math.Float64bits(r.GlideAlt))
+	binary.LittleEndian.PutUint32(b[73:], math.Float32bits(r.GlideSlopeAngle))
+}
+
+// RecvFacilityVORList is SIMCONNECT_RECV_VOR_LIST.
+type RecvFacilityVORList struct {
+	RecvFacilityList
+	Data []DataFacilityVOR
+}
+
+// SizeofRecvFacilityVORList is the packed size of SIMCONNECT_RECV_VOR_LIST without any variable-length data.
+const SizeofRecvFacilityVORList = 28
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_VOR_LIST.
+func (r *RecvFacilityVORList) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityVORList {
+		return shortBuffer("RecvFacilityVORList", SizeofRecvFacilityVORList, len(b))
+	}
+	r.decode(b)
+	n := int(r.ArraySize)
+	if want := SizeofRecvFacilityVORList + n*SizeofDataFacilityVOR; len(b) < want {
+		return shortBuffer("RecvFacilityVORList", want, len(b))
+	}
+	r.Data = make([]DataFacilityVOR, n)
+	for i := range r.Data {
+		off := SizeofRecvFacilityVORList + i*SizeofDataFacilityVOR
+		r.Data[i].decode(b[off:])
+	}
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_VOR_LIST.
+func (r *RecvFacilityVORList) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityVORList+len(r.Data)*SizeofDataFacilityVOR)
+	r.encode(b)
+	for i := range r.Data {
+		off := SizeofRecvFacilityVORList + i*SizeofDataFacilityVOR
+		r.Data[i].encode(b[off:])
+	}
+	return b, nil
+}
+
+func (r *RecvFacilityVORList) decode(b []byte) {
+	r.RecvFacilityList.decode(b)
+}
+
+func (r *RecvFacilityVORList) encode(b []byte) {
+	r.RecvFacilityList.encode(b)
+}
+
+// RecvFacilityData is SIMCONNECT_RECV_FACILITY_DATA.
+type RecvFacilityData struct {
+	Recv
+	UserRequestID         DWORD
+	UniqueRequestID       DWORD
+	ParentUniqueRequestID DWORD
+	Type                  DWORD
+	IsListItem            DWORD
+	ItemIndex             DWORD
+	ListSize              DWORD
+}
+
+// SizeofRecvFacilityData is the packed size of SIMCONNECT_RECV_FACILITY_DATA without any variable-length data.
+const SizeofRecvFacilityData = 40
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_FACILITY_DATA.
+func (r *RecvFacilityData) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityData {
+		return shortBuffer("RecvFacilityData", SizeofRecvFacilityData, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_FACILITY_DATA.
+func (r *RecvFacilityData) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityData)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvFacilityData) decode(b []byte) {
+	r.Recv.decode(b)
+	r.UserRequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.UniqueRequestID = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.ParentUniqueRequestID = DWORD(binary.LittleEndian.Uint32(b[20:]))
+	r.Type = DWORD(binary.LittleEndian.Uint32(b[24:]))
+	r.IsListItem = DWORD(binary.LittleEndian.Uint32(b[28:]))
+	r.ItemIndex = DWORD(binary.LittleEndian.Uint32(b[32:]))
+	r.ListSize = DWORD(binary.LittleEndian.Uint32(b[36:]))
+}
+
+func (r *RecvFacilityData) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.UserRequestID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.UniqueRequestID))
+	binary.LittleEndian.PutUint32(b[20:], uint32(r.ParentUniqueRequestID))
+	binary.LittleEndian.PutUint32(b[24:], uint32(r.Type))
+	binary.LittleEndian.PutUint32(b[28:], uint32(r.IsListItem))
+	binary.LittleEndian.PutUint32(b[32:], uint32(r.ItemIndex))
+	binary.LittleEndian.PutUint32(b[36:], uint32(r.ListSize))
+}
+
+// RecvFacilityDataEnd is SIMCONNECT_RECV_FACILITY_DATA_END.
+type RecvFacilityDataEnd struct {
+	Recv
+	RequestID DWORD
+}
+
+// SizeofRecvFacilityDataEnd is the packed size of SIMCONNECT_RECV_FACILITY_DATA_END without any variable-length data.
+const SizeofRecvFacilityDataEnd = 16
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_FACILITY_DATA_END.
+func (r *RecvFacilityDataEnd) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvFacilityDataEnd {
+		return shortBuffer("RecvFacilityDataEnd", SizeofRecvFacilityDataEnd, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_FACILITY_DATA_END.
+func (r *RecvFacilityDataEnd) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvFacilityDataEnd)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvFacilityDataEnd) decode(b []byte) {
+	r.Recv.decode(b)
+	r.RequestID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+}
+
+func (r *RecvFacilityDataEnd) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.RequestID))
+}
+
+// RecvEventEX1 is SIMCONNECT_RECV_EVENT_EX1.
+// when dwID == SIMCONNECT_RECV_ID_EVENT_EX1
+type RecvEventEX1 struct {
+	Recv
+	GroupID DWORD
+	EventID DWORD
+	Data0   DWORD
+	Data1   DWORD
+	Data2   DWORD
+	Data3   DWORD
+	Data4   DWORD
+}
+
+// SizeofRecvEventEX1 is the packed size of SIMCONNECT_RECV_EVENT_EX1 without any variable-length data.
+const SizeofRecvEventEX1 = 40
+
+// UnmarshalBinary decodes a packed SIMCONNECT_RECV_EVENT_EX1.
+func (r *RecvEventEX1) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofRecvEventEX1 {
+		return shortBuffer("RecvEventEX1", SizeofRecvEventEX1, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_RECV_EVENT_EX1.
+func (r *RecvEventEX1) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofRecvEventEX1)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *RecvEventEX1) decode(b []byte) {
+	r.Recv.decode(b)
+	r.GroupID = DWORD(binary.LittleEndian.Uint32(b[12:]))
+	r.EventID = DWORD(binary.LittleEndian.Uint32(b[16:]))
+	r.Data0 = DWORD(binary.LittleEndian.Uint32(b[20:]))
+	r.Data1 = DWORD(binary.LittleEndian.Uint32(b[24:]))
+	r.Data2 = DWORD(binary.LittleEndian.Uint32(b[28:]))
+	r.Data3 = DWORD(binary.LittleEndian.Uint32(b[32:]))
+	r.Data4 = DWORD(binary.LittleEndian.Uint32(b[36:]))
+}
+
+func (r *RecvEventEX1) encode(b []byte) {
+	r.Recv.encode(b)
+	binary.LittleEndian.PutUint32(b[12:], uint32(r.GroupID))
+	binary.LittleEndian.PutUint32(b[16:], uint32(r.EventID))
+	binary.LittleEndian.PutUint32(b[20:], uint32(r.Data0))
+	binary.LittleEndian.PutUint32(b[24:], uint32(r.Data1))
+	binary.LittleEndian.PutUint32(b[28:], uint32(r.Data2))
+	binary.LittleEndian.PutUint32(b[32:], uint32(r.Data3))
+	binary.LittleEndian.PutUint32(b[36:], uint32(r.Data4))
+}
+
+// DataInitPosition is SIMCONNECT_DATA_INITPOSITION.
+type DataInitPosition struct {
+	Latitude  float64 // degrees
+	Longitude float64 // degrees
+	Altitude  float64 // feet
+	Pitch     float64 // degrees
+	Bank      float64 // degrees
+	Heading   float64 // degrees
+	OnGround  DWORD   // 1=force to be on the ground
+	Airspeed  DWORD   // knots
+}
+
+// SizeofDataInitPosition is the packed size of SIMCONNECT_DATA_INITPOSITION without any variable-length data.
+const SizeofDataInitPosition = 56
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_INITPOSITION.
+func (r *DataInitPosition) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataInitPosition {
+		return shortBuffer("DataInitPosition", SizeofDataInitPosition, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_INITPOSITION.
+func (r *DataInitPosition) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataInitPosition)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataInitPosition) decode(b []byte) {
+	r.Latitude = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
+	r.Longitude = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+	r.Altitude = math.Float64frombits(binary.LittleEndian.Uint64(b[16:]))
+	r.Pitch = math.Float64frombits(binary.LittleEndian.Uint64(b[24:]))
+	r.Bank = math.Float64frombits(binary.LittleEndian.Uint64(b[32:]))
+	r.Heading = math.Float64frombits(binary.LittleEndian.Uint64(b[40:]))
+	r.OnGround = DWORD(binary.LittleEndian.Uint32(b[48:]))
+	r.Airspeed = DWORD(binary.LittleEndian.Uint32(b[52:]))
+}
+
+func (r *DataInitPosition) encode(b []byte) {
+	binary.LittleEndian.PutUint64(b[0:], math.Float64bits(r.Latitude))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(r.Longitude))
+	binary.LittleEndian.PutUint64(b[16:], math.Float64bits(r.Altitude))
+	binary.LittleEndian.PutUint64(b[24:], math.Float64bits(r.Pitch))
+	binary.LittleEndian.PutUint64(b[32:], math.Float64bits(r.Bank))
+	binary.LittleEndian.PutUint64(b[40:], math.Float64bits(r.Heading))
+	binary.LittleEndian.PutUint32(b[48:], uint32(r.OnGround))
+	binary.LittleEndian.PutUint32(b[52:], uint32(r.Airspeed))
+}
+
+// DataMarkerState is SIMCONNECT_DATA_MARKERSTATE.
+type DataMarkerState struct {
+	MarkerName  [64]byte
+	MarkerState DWORD
+}
+
+// SizeofDataMarkerState is the packed size of SIMCONNECT_DATA_MARKERSTATE without any variable-length data.
+const SizeofDataMarkerState = 68
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_MARKERSTATE.
+func (r *DataMarkerState) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataMarkerState {
+		return shortBuffer("DataMarkerState", SizeofDataMarkerState, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_MARKERSTATE.
+func (r *DataMarkerState) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataMarkerState)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataMarkerState) decode(b []byte) {
+	copy(r.MarkerName[:], b[0:64])
+	r.MarkerState = DWORD(binary.LittleEndian.Uint32(b[64:]))
+}
+
+func (r *DataMarkerState) encode(b []byte) {
+	copy(b[0:64], r.MarkerName[:])
+	binary.LittleEndian.PutUint32(b[64:], uint32(r.MarkerState))
+}
+
+// DataWaypoint is SIMCONNECT_DATA_WAYPOINT.
+type DataWaypoint struct {
+	Latitude        float64 // degrees
+	Longitude       float64 // degrees
+	Altitude        float64 // feet
+	Flags           DWORD
+	KtsSpeed        float64 // knots
+	PercentThrottle float64
+}
+
+// SizeofDataWaypoint is the packed size of SIMCONNECT_DATA_WAYPOINT without any variable-length data.
+const SizeofDataWaypoint = 44
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_WAYPOINT.
+func (r *DataWaypoint) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataWaypoint {
+		return shortBuffer("DataWaypoint", SizeofDataWaypoint, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_WAYPOINT.
+func (r *DataWaypoint) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataWaypoint)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataWaypoint) decode(b []byte) {
+	r.Latitude = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
+	r.Longitude = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+	r.Altitude = math.Float64frombits(binary.LittleEndian.Uint64(b[16:]))
+	r.Flags = DWORD(binary.LittleEndian.Uint32(b[24:]))
+	r.KtsSpeed = math.Float64frombits(binary.LittleEndian.Uint64(b[28:]))
+	r.PercentThrottle = math.Float64frombits(binary.LittleEndian.Uint64(b[36:]))
+}
+
+func (r *DataWaypoint) encode(b []byte) {
+	binary.LittleEndian.PutUint64(b[0:], math.Float64bits(r.Latitude))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(r.Longitude))
+	binary.LittleEndian.PutUint64(b[16:], math.Float64bits(r.Altitude))
+	binary.LittleEndian.PutUint32(b[24:], uint32(r.Flags))
+	binary.LittleEndian.PutUint64(b[28:], math.Float64bits(r.KtsSpeed))
+	binary.LittleEndian.PutUint64(b[36:], math.Float64bits(r.PercentThrottle))
+}
+
+// DataLatLonAlt is SIMCONNECT_DATA_LATLONALT.
+type DataLatLonAlt struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// SizeofDataLatLonAlt is the packed size of SIMCONNECT_DATA_LATLONALT without any variable-length data.
+const SizeofDataLatLonAlt = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_LATLONALT.
+func (r *DataLatLonAlt) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataLatLonAlt {
+		return shortBuffer("DataLatLonAlt", SizeofDataLatLonAlt, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_LATLONALT.
+func (r *DataLatLonAlt) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataLatLonAlt)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataLatLonAlt) decode(b []byte) {
+	r.Latitude = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
+	r.Longitude = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+	r.Altitude = math.Float64frombits(binary.LittleEndian.Uint64(b[16:]))
+}
+
+func (r *DataLatLonAlt) encode(b []byte) {
+	binary.LittleEndian.PutUint64(b[0:], math.Float64bits(r.Latitude))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(r.Longitude))
+	binary.LittleEndian.PutUint64(b[16:], math.Float64bits(r.Altitude))
+}
+
+// DataXYZ is SIMCONNECT_DATA_XYZ.
+type DataXYZ struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// SizeofDataXYZ is the packed size of SIMCONNECT_DATA_XYZ without any variable-length data.
+const SizeofDataXYZ = 24
+
+// UnmarshalBinary decodes a packed SIMCONNECT_DATA_XYZ.
+func (r *DataXYZ) UnmarshalBinary(b []byte) error {
+	if len(b) < SizeofDataXYZ {
+		return shortBuffer("DataXYZ", SizeofDataXYZ, len(b))
+	}
+	r.decode(b)
+	return nil
+}
+
+// MarshalBinary encodes r as a packed SIMCONNECT_DATA_XYZ.
+func (r *DataXYZ) MarshalBinary() ([]byte, error) {
+	b := make([]byte, SizeofDataXYZ)
+	r.encode(b)
+	return b, nil
+}
+
+func (r *DataXYZ) decode(b []byte) {
+	r.X = math.Float64frombits(binary.LittleEndian.Uint64(b[0:]))
+	r.Y = math.Float64frombits(binary.LittleEndian.Uint64(b[8:]))
+	r.Z = math.Float64frombits(binary.LittleEndian.Uint64(b[16:]))
+}
+
+func (r *DataXYZ) encode(b []byte) {
+	binary.LittleEndian.PutUint64(b[0:], math.Float64bits(r.X))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(r.Y))
+	binary.LittleEndian.PutUint64(b[16:], math.Float64bits(r.Z))
+}
+
+func shortBuffer(name string, want, got int) error {
+	return fmt.Errorf("%s: need %d bytes, got %d", name, want, got)
+}
@@ -0,0 +1,152 @@
+package simconnect
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// sizeof of each record in SimConnect.h, which packs them to 1 byte
+var headerSizes = []struct {
+	name      string
+	got, want int
+}{
+	{"SIMCONNECT_RECV", SizeofRecv, 12},
+	{"SIMCONNECT_RECV_EXCEPTION", SizeofRecvException, 24},
+	{"SIMCONNECT_RECV_OPEN", SizeofRecvOpen, 308},
+	{"SIMCONNECT_RECV_QUIT", SizeofRecvQuit, 12},
+	{"SIMCONNECT_RECV_EVENT", SizeofRecvEvent, 24},
+	{"SIMCONNECT_RECV_EVENT_FILENAME", SizeofRecvEventFilename, 288},
+	{"SIMCONNECT_RECV_EVENT_OBJECT_ADDREMOVE", SizeofRecvEventObjectAddRemove, 28},
+	{"SIMCONNECT_RECV_EVENT_FRAME", SizeofRecvEventFrame, 32},
+	{"SIMCONNECT_RECV_EVENT_MULTIPLAYER_SERVER_STARTED", SizeofRecvEventMultiplayerServerStarted, 24},
+	{"SIMCONNECT_RECV_EVENT_MULTIPLAYER_CLIENT_STARTED", SizeofRecvEventMultiplayerClientStarted, 24},
+	{"SIMCONNECT_RECV_EVENT_MULTIPLAYER_SESSION_ENDED", SizeofRecvEventMultiplayerSessionEnded, 24},
+	{"SIMCONNECT_RECV_SIMOBJECT_DATA", SizeofRecvSimobjectData, 40},
+	{"SIMCONNECT_RECV_SIMOBJECT_DATA_BYTYPE", SizeofRecvSimobjectDataByType, 40},
+	{"SIMCONNECT_RECV_CLIENT_DATA", SizeofRecvClientData, 40},
+	{"SIMCONNECT_RECV_WEATHER_OBSERVATION", SizeofRecvWeatherObservation, 16},
+	{"SIMCONNECT_RECV_CLOUD_STATE", SizeofRecvCloudState, 20},
+	{"SIMCONNECT_RECV_ASSIGNED_OBJECT_ID", SizeofRecvAssignedObjectID, 20},
+	{"SIMCONNECT_RECV_RESERVED_KEY", SizeofRecvReservedKey, 92},
+	{"SIMCONNECT_RECV_SYSTEM_STATE", SizeofRecvSystemState, 284},
+	{"SIMCONNECT_RECV_EVENT_WEATHER_MODE", SizeofRecvEventWeatherMode, 24},
+	{"SIMCONNECT_RECV_FACILITIES_LIST", SizeofRecvFacilityList, 28},
+	{"SIMCONNECT_DATA_FACILITY_AIRPORT", SizeofDataFacilityAirport, 33},
+	{"SIMCONNECT_RECV_AIRPORT_LIST", SizeofRecvFacilityAirportList, 28},
+	{"SIMCONNECT_DATA_FACILITY_WAYPOINT", SizeofDataFacilityWaypoint, 37},
+	{"SIMCONNECT_RECV_WAYPOINT_LIST", SizeofRecvFacilityWaypointList, 28},
+	{"SIMCONNECT_DATA_FACILITY_NDB", SizeofDataFacilityNDB, 41},
+	{"SIMCONNECT_RECV_NDB_LIST", SizeofRecvFacilityNDBList, 28},
+	{"SIMCONNECT_DATA_FACILITY_VOR", SizeofDataFacilityVOR, 77},
+	{"SIMCONNECT_RECV_VOR_LIST", SizeofRecvFacilityVORList, 28},
+	{"SIMCONNECT_RECV_FACILITY_DATA", SizeofRecvFacilityData, 40},
+	{"SIMCONNECT_RECV_FACILITY_DATA_END", SizeofRecvFacilityDataEnd, 16},
+	{"SIMCONNECT_RECV_EVENT_EX1", SizeofRecvEventEX1, 40},
+	{"SIMCONNECT_DATA_INITPOSITION", SizeofDataInitPosition, 56},
+	{"SIMCONNECT_DATA_MARKERSTATE", SizeofDataMarkerState, 68},
+	{"SIMCONNECT_DATA_WAYPOINT", SizeofDataWaypoint, 44},
+	{"SIMCONNECT_DATA_LATLONALT", SizeofDataLatLonAlt, 24},
+	{"SIMCONNECT_DATA_XYZ", SizeofDataXYZ, 24},
+}
+
+func TestHeaderSizes(t *testing.T) {
+	for _, s := range headerSizes {
+		if s.got != s.want {
+			t.Errorf("%s: size %d, want %d", s.name, s.got, s.want)
+		}
+	}
+}
+
+// the records read in place from ppData must lay out as the header does
+func TestPointerRecordLayout(t *testing.T) {
+	var (
+		exception RecvException
+		open      RecvOpen
+		event     RecvEvent
+		data      RecvSimobjectData
+		state     RecvSystemState
+		assigned  RecvAssignedObjectID
+		position  DataInitPosition
+	)
+	for _, tc := range []struct {
+		name      string
+		got, want uintptr
+	}{
+		{"RecvException", unsafe.Sizeof(exception), SizeofRecvException},
+		{"RecvException.SendID", unsafe.Offsetof(exception.SendID), 16},
+		{"RecvOpen", unsafe.Sizeof(open), SizeofRecvOpen},
+		{"RecvOpen.ApplicationVersionMajor", unsafe.Offsetof(open.ApplicationVersionMajor), 268},
+		{"RecvOpen.Reserved2", unsafe.Offsetof(open.Reserved2), 304},
+		{"RecvEvent", unsafe.Sizeof(event), SizeofRecvEvent},
+		{"RecvEvent.Data", unsafe.Offsetof(event.Data), 20},
+		{"RecvSimobjectData", unsafe.Sizeof(data), SizeofRecvSimobjectData},
+		{"RecvSimobjectData.DefineID", unsafe.Offsetof(data.DefineID), 20},
+		{"RecvSimobjectData.DefineCount", unsafe.Offsetof(data.DefineCount), 36},
+		{"RecvSimobjectDataByType", unsafe.Sizeof(RecvSimobjectDataByType{}), SizeofRecvSimobjectDataByType},
+		{"RecvSystemState", unsafe.Sizeof(state), SizeofRecvSystemState},
+		{"RecvSystemState.Float", unsafe.Offsetof(state.Float), 20},
+		{"RecvSystemState.String", unsafe.Offsetof(state.String), 24},
+		{"RecvAssignedObjectID", unsafe.Sizeof(assigned), SizeofRecvAssignedObjectID},
+		{"RecvAssignedObjectID.ObjectID", unsafe.Offsetof(assigned.ObjectID), 16},
+		{"DataInitPosition", unsafe.Sizeof(position), SizeofDataInitPosition},
+		{"DataInitPosition.OnGround", unsafe.Offsetof(position.OnGround), 48},
+		{"DataInitPosition.Airspeed", unsafe.Offsetof(position.Airspeed), 52},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: %d, want %d", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+// packed records that Go would align differently go through UnmarshalBinary
+func TestPackedFacilityList(t *testing.T) {
+	list := RecvFacilityWaypointList{
+		RecvFacilityList: RecvFacilityList{
+			Recv:      Recv{Size: SizeofRecvFacilityWaypointList + 2*SizeofDataFacilityWaypoint, Version: 1, ID: RECV_ID_WAYPOINT_LIST},
+			RequestID: 3,
+			ArraySize: 2,
+			OutOf:     1,
+		},
+		Data: make([]DataFacilityWaypoint, 2),
+	}
+	copy(list.Data[0].Icao[:], "ABC")
+	list.Data[0].Latitude = 59.5
+	list.Data[0].Altitude = 100
+	list.Data[0].MagVar = -3.5
+	copy(list.Data[1].Icao[:], "DEFGH")
+	list.Data[1].Longitude = 10.25
+	list.Data[1].MagVar = 1.5
+
+	b, err := list.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != int(list.Size) {
+		t.Fatalf("%d bytes, want %d", len(b), list.Size)
+	}
+	first := b[SizeofRecvFacilityWaypointList:]
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(first[9:])); got != 59.5 {
+		t.Errorf("latitude %v at byte 9", got)
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(first[33:])); got != -3.5 {
+		t.Errorf("magvar %v at byte 33", got)
+	}
+	second := first[SizeofDataFacilityWaypoint:]
+	if got := math.Float64frombits(binary.LittleEndian.Uint64(second[17:])); got != 10.25 {
+		t.Errorf("second longitude %v at byte 17", got)
+	}
+
+	var back RecvFacilityWaypointList
+	if err := back.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, list) {
+		t.Errorf("got %+v, want %+v", back, list)
+	}
+	if err := back.UnmarshalBinary(b[:len(b)-1]); err == nil {
+		t.Error("decoded a list shorter than its ArraySize")
+	}
+}
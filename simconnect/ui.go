@@ -0,0 +1,309 @@
+package simconnect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPromptChoices is the most items a TEXT_TYPE_MENU can show.
+const maxPromptChoices = 10
+
+// ErrTooManyChoices is returned by Prompt for more than ten choices.
+var ErrTooManyChoices = errors.New("a prompt can show at most 10 choices")
+
+// ErrUIClosed is returned by Print after Close.
+var ErrUIClosed = errors.New("the UI is closed")
+
+// UI shows menus, prompts and messages in the simulator and routes the
+// events they produce back to callbacks. Feed it every RECV_ID_EVENT with
+// HandleEvent; callbacks run on the goroutine that calls HandleEvent. Close
+// it before closing s.
+type UI struct {
+	s *SimConnect
+
+	mu      sync.Mutex
+	items   map[DWORD]*MenuItem
+	prompts map[DWORD]*prompt
+	lines   map[DWORD]*textLine
+	closed  bool
+}
+
+// MenuItem is an entry of the Add-ons menu, or of a submenu of one.
+type MenuItem struct {
+	ui       *UI
+	parent   *MenuItem
+	eventID  DWORD
+	title    string
+	onSelect func()
+	children []*MenuItem
+}
+
+type prompt struct {
+	answer func(choice int, ok bool)
+}
+
+// textLine queues messages for one display area, so that a message is not
+// replaced by the next before its time is up.
+type textLine struct {
+	eventID DWORD
+	queue   []textMessage
+	showing bool
+	shown   int // messages shown so far, to ignore stale timers
+	timer   *time.Timer
+}
+
+type textMessage struct {
+	textType DWORD
+	seconds  float64
+	text     string
+}
+
+// NewUI returns a UI drawing through s.
+func NewUI(s *SimConnect) *UI {
+	return &UI{
+		s:       s,
+		items:   map[DWORD]*MenuItem{},
+		prompts: map[DWORD]*prompt{},
+		lines:   map[DWORD]*textLine{},
+	}
+}
+
+// AddMenu adds an item to the Add-ons menu. onSelect may be nil for an item
+// that only holds sub items.
+func (u *UI) AddMenu(title string, onSelect func()) (*MenuItem, error) {
+	item := &MenuItem{ui: u, eventID: u.s.GetEventID(), title: title, onSelect: onSelect}
+
+	if err := u.s.MenuAddItem(title, item.eventID, 0); err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	u.items[item.eventID] = item
+	u.mu.Unlock()
+
+	return item, nil
+}
+
+// AddItem adds a sub item below m.
+func (m *MenuItem) AddItem(title string, onSelect func()) (*MenuItem, error) {
+	u := m.ui
+	item := &MenuItem{ui: u, parent: m, eventID: u.s.GetEventID(), title: title, onSelect: onSelect}
+
+	if err := u.s.MenuAddSubItem(m.eventID, title, item.eventID, 0); err != nil {
+		return nil, err
+	}
+
+	u.mu.Lock()
+	u.items[item.eventID] = item
+	m.children = append(m.children, item)
+	u.mu.Unlock()
+
+	return item, nil
+}
+
+// Title returns the text the item was added with.
+func (m *MenuItem) Title() string {
+	return m.title
+}
+
+// Remove takes the item and its sub items out of the menu.
+func (m *MenuItem) Remove() error {
+	u := m.ui
+
+	u.mu.Lock()
+	m.forget()
+	if m.parent != nil {
+		siblings := m.parent.children
+		for i, c := range siblings {
+			if c == m {
+				m.parent.children = append(siblings[:i], siblings[i+1:]...)
+				break
+			}
+		}
+	}
+	u.mu.Unlock()
+
+	if m.parent != nil {
+		return u.s.MenuDeleteSubItem(m.parent.eventID, m.eventID)
+	}
+	return u.s.MenuDeleteItem(m.eventID)
+}
+
+// forget drops m and its children from the event table; u.mu is held.
+func (m *MenuItem) forget() {
+	delete(m.ui.items, m.eventID)
+	for _, c := range m.children {
+		c.forget()
+	}
+	m.children = nil
+}
+
+// Prompt shows a TEXT_TYPE_MENU with a title, a question and up to ten
+// choices. answer is called once with the index of the chosen item, or with
+// ok false when the prompt timed out or was removed or replaced. A timeout
+// of 0 keeps the prompt up until it is answered.
+func (u *UI) Prompt(title, question string, choices []string, timeout time.Duration, answer func(choice int, ok bool)) error {
+	if len(choices) > maxPromptChoices {
+		return ErrTooManyChoices
+	}
+
+	eventID := u.s.GetEventID()
+
+	u.mu.Lock()
+	u.prompts[eventID] = &prompt{answer: answer}
+	u.mu.Unlock()
+
+	// the menu is the title, the prompt and the choices, each NUL-terminated
+	text := strings.Join(append([]string{title, question}, choices...), "\x00")
+
+	if err := u.s.ShowText(TEXT_TYPE_MENU, timeout.Seconds(), eventID, text); err != nil {
+		u.mu.Lock()
+		delete(u.prompts, eventID)
+		u.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Print queues a message for seconds. Scrolling (TEXT_TYPE_SCROLL_*) and
+// printed (TEXT_TYPE_PRINT_*) messages queue separately; each is shown once
+// the one before it in its area has timed out.
+func (u *UI) Print(textType DWORD, seconds float64, text string) error {
+	if textType == TEXT_TYPE_MENU {
+		return fmt.Errorf("Print: use Prompt for TEXT_TYPE_MENU")
+	}
+
+	area := textType &^ 0xff
+
+	u.mu.Lock()
+	if u.closed {
+		u.mu.Unlock()
+		return ErrUIClosed
+	}
+	line, ok := u.lines[area]
+	if !ok {
+		line = &textLine{eventID: u.s.GetEventID()}
+		u.lines[area] = line
+	}
+	line.queue = append(line.queue, textMessage{textType: textType, seconds: seconds, text: text})
+	idle, shown := !line.showing, line.shown
+	u.mu.Unlock()
+
+	if idle {
+		return u.showNext(line, shown)
+	}
+	return nil
+}
+
+// showNext shows the next queued message of line, if any, unless line has
+// moved on since shown messages.
+func (u *UI) showNext(line *textLine, shown int) error {
+	u.mu.Lock()
+	if u.closed || line.shown != shown {
+		u.mu.Unlock()
+		return nil
+	}
+	if line.timer != nil {
+		line.timer.Stop()
+		line.timer = nil
+	}
+	if len(line.queue) == 0 {
+		line.showing = false
+		u.mu.Unlock()
+		return nil
+	}
+	msg := line.queue[0]
+	line.queue = line.queue[1:]
+	line.showing = true
+	line.shown++
+	next := line.shown
+
+	// fall back to our own clock in case the result event never arrives
+	line.timer = time.AfterFunc(time.Duration((msg.seconds+1)*float64(time.Second)), func() {
+		u.showNext(line, next)
+	})
+	u.mu.Unlock()
+
+	return u.s.ShowText(msg.textType, msg.seconds, line.eventID, msg.text)
+}
+
+// Close stops showing queued messages, whose timers would otherwise draw
+// through s after it is closed, and drops them.
+func (u *UI) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.closed = true
+	for _, line := range u.lines {
+		if line.timer != nil {
+			line.timer.Stop()
+			line.timer = nil
+		}
+		line.queue = nil
+	}
+}
+
+// HandleEvent runs the callback belonging to e, if it was caused by a menu,
+// prompt or message of u, and reports whether it was.
+func (u *UI) HandleEvent(e *RecvEvent) bool {
+	u.mu.Lock()
+
+	if item, ok := u.items[e.EventID]; ok {
+		u.mu.Unlock()
+		if item.onSelect != nil {
+			item.onSelect()
+		}
+		return true
+	}
+
+	if p, ok := u.prompts[e.EventID]; ok {
+		choice, answered := promptChoice(e.Data)
+		if !answered && !promptClosed(e.Data) {
+			// DISPLAYED or QUEUED, the answer is still to come
+			u.mu.Unlock()
+			return true
+		}
+		delete(u.prompts, e.EventID)
+		u.mu.Unlock()
+
+		if p.answer != nil {
+			p.answer(choice, answered)
+		}
+		return true
+	}
+
+	for _, line := range u.lines {
+		if line.eventID != e.EventID {
+			continue
+		}
+		shown := line.shown
+		u.mu.Unlock()
+		if promptClosed(e.Data) {
+			u.showNext(line, shown)
+		}
+		return true
+	}
+
+	u.mu.Unlock()
+	return false
+}
+
+// promptChoice decodes a TEXT_RESULT_MENU_SELECT_n result to n-1.
+func promptChoice(result DWORD) (int, bool) {
+	if result >= TEXT_RESULT_MENU_SELECT_1 && result <= TEXT_RESULT_MENU_SELECT_10 {
+		return int(result - TEXT_RESULT_MENU_SELECT_1), true
+	}
+	return -1, false
+}
+
+// promptClosed reports whether result means the text is gone from the screen.
+func promptClosed(result DWORD) bool {
+	switch result {
+	case TEXT_RESULT_REMOVED, TEXT_RESULT_REPLACED, TEXT_RESULT_TIMEOUT:
+		return true
+	}
+	return false
+}